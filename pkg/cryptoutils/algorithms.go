@@ -0,0 +1,176 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+)
+
+// Canonical IDs of the signing algorithms in the registry. They follow the names of the
+// PublicKeyDetails in the Sigstore protobuf specs, with the "-sha512" RSA variants supported by
+// some KMS services added.
+const (
+	AlgorithmECDSAP256SHA256       = "ecdsa-sha2-256-nistp256"
+	AlgorithmECDSAP384SHA384       = "ecdsa-sha2-384-nistp384"
+	AlgorithmECDSAP521SHA512       = "ecdsa-sha2-512-nistp521"
+	AlgorithmED25519               = "ed25519"
+	AlgorithmED25519ph             = "ed25519-ph"
+	AlgorithmRSAPKCS1v152048SHA256 = "rsa-sign-pkcs1-2048-sha256"
+	AlgorithmRSAPKCS1v153072SHA256 = "rsa-sign-pkcs1-3072-sha256"
+	AlgorithmRSAPKCS1v154096SHA256 = "rsa-sign-pkcs1-4096-sha256"
+	AlgorithmRSAPKCS1v154096SHA512 = "rsa-sign-pkcs1-4096-sha512"
+	AlgorithmRSAPSS2048SHA256      = "rsa-sign-pss-2048-sha256"
+	AlgorithmRSAPSS3072SHA256      = "rsa-sign-pss-3072-sha256"
+	AlgorithmRSAPSS4096SHA256      = "rsa-sign-pss-4096-sha256"
+	AlgorithmRSAPSS4096SHA512      = "rsa-sign-pss-4096-sha512"
+)
+
+// KeyType is the type of key used by a SigningAlgorithm
+type KeyType string
+
+const (
+	// KeyTypeECDSA is an ECDSA key
+	KeyTypeECDSA KeyType = "ecdsa"
+	// KeyTypeED25519 is an Ed25519 key
+	KeyTypeED25519 KeyType = "ed25519"
+	// KeyTypeRSA is an RSA key
+	KeyTypeRSA KeyType = "rsa"
+)
+
+// RSAPadding is the signature scheme of an RSA SigningAlgorithm
+type RSAPadding string
+
+const (
+	// RSAPaddingPKCS1v15 is RSASSA-PKCS1-v1_5
+	RSAPaddingPKCS1v15 RSAPadding = "pkcs1v15"
+	// RSAPaddingPSS is RSASSA-PSS
+	RSAPaddingPSS RSAPadding = "pss"
+)
+
+// SigningAlgorithm describes a key and the signature scheme used with it
+type SigningAlgorithm struct {
+	// ID is the canonical ID of the algorithm
+	ID string
+	// KeyType is the type of key
+	KeyType KeyType
+	// Curve is the curve of an ECDSA key
+	Curve elliptic.Curve
+	// RSAKeySize is the size in bits of an RSA key
+	RSAKeySize int
+	// Hash is the hash function of the message digest that is signed. It is crypto.SHA512 for
+	// ed25519, which hashes the message internally.
+	Hash crypto.Hash
+	// Padding is the signature scheme of an RSA key
+	Padding RSAPadding
+	// Prehashed is set for ed25519-ph, which signs a SHA-512 digest of the message
+	Prehashed bool
+}
+
+// signingAlgorithms is the registry, in the order returned by SigningAlgorithms
+var signingAlgorithms = []SigningAlgorithm{
+	{ID: AlgorithmECDSAP256SHA256, KeyType: KeyTypeECDSA, Curve: elliptic.P256(), Hash: crypto.SHA256},
+	{ID: AlgorithmECDSAP384SHA384, KeyType: KeyTypeECDSA, Curve: elliptic.P384(), Hash: crypto.SHA384},
+	{ID: AlgorithmECDSAP521SHA512, KeyType: KeyTypeECDSA, Curve: elliptic.P521(), Hash: crypto.SHA512},
+	{ID: AlgorithmED25519, KeyType: KeyTypeED25519, Hash: crypto.SHA512},
+	{ID: AlgorithmED25519ph, KeyType: KeyTypeED25519, Hash: crypto.SHA512, Prehashed: true},
+	{ID: AlgorithmRSAPKCS1v152048SHA256, KeyType: KeyTypeRSA, RSAKeySize: 2048, Hash: crypto.SHA256, Padding: RSAPaddingPKCS1v15},
+	{ID: AlgorithmRSAPKCS1v153072SHA256, KeyType: KeyTypeRSA, RSAKeySize: 3072, Hash: crypto.SHA256, Padding: RSAPaddingPKCS1v15},
+	{ID: AlgorithmRSAPKCS1v154096SHA256, KeyType: KeyTypeRSA, RSAKeySize: 4096, Hash: crypto.SHA256, Padding: RSAPaddingPKCS1v15},
+	{ID: AlgorithmRSAPKCS1v154096SHA512, KeyType: KeyTypeRSA, RSAKeySize: 4096, Hash: crypto.SHA512, Padding: RSAPaddingPKCS1v15},
+	{ID: AlgorithmRSAPSS2048SHA256, KeyType: KeyTypeRSA, RSAKeySize: 2048, Hash: crypto.SHA256, Padding: RSAPaddingPSS},
+	{ID: AlgorithmRSAPSS3072SHA256, KeyType: KeyTypeRSA, RSAKeySize: 3072, Hash: crypto.SHA256, Padding: RSAPaddingPSS},
+	{ID: AlgorithmRSAPSS4096SHA256, KeyType: KeyTypeRSA, RSAKeySize: 4096, Hash: crypto.SHA256, Padding: RSAPaddingPSS},
+	{ID: AlgorithmRSAPSS4096SHA512, KeyType: KeyTypeRSA, RSAKeySize: 4096, Hash: crypto.SHA512, Padding: RSAPaddingPSS},
+}
+
+// SigningAlgorithms returns every algorithm in the registry
+func SigningAlgorithms() []SigningAlgorithm {
+	out := make([]SigningAlgorithm, len(signingAlgorithms))
+	copy(out, signingAlgorithms)
+	return out
+}
+
+// GetSigningAlgorithm returns the algorithm with the canonical ID id
+func GetSigningAlgorithm(id string) (SigningAlgorithm, error) {
+	for _, a := range signingAlgorithms {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return SigningAlgorithm{}, fmt.Errorf("unknown signing algorithm: %s", id)
+}
+
+// SigningAlgorithmForPublicKey returns the default algorithm for the type, curve or size of pub:
+// the ECDSA algorithm for its curve, ed25519, or RSA PKCS#1 v1.5 with SHA-256.
+func SigningAlgorithmForPublicKey(pub crypto.PublicKey) (SigningAlgorithm, error) {
+	for _, a := range signingAlgorithms {
+		if a.Prehashed || a.KeyType == KeyTypeRSA && (a.Padding != RSAPaddingPKCS1v15 || a.Hash != crypto.SHA256) {
+			continue
+		}
+		if a.MatchesPublicKey(pub) {
+			return a, nil
+		}
+	}
+	return SigningAlgorithm{}, fmt.Errorf("no signing algorithm for public key of type %T", pub)
+}
+
+// MatchesPublicKey reports whether pub has the type and curve or size of the algorithm's key
+func (a SigningAlgorithm) MatchesPublicKey(pub crypto.PublicKey) bool {
+	switch pk := pub.(type) {
+	case *ecdsa.PublicKey:
+		return a.KeyType == KeyTypeECDSA && pk.Curve == a.Curve
+	case ed25519.PublicKey:
+		return a.KeyType == KeyTypeED25519
+	case *rsa.PublicKey:
+		return a.KeyType == KeyTypeRSA && pk.N.BitLen() == a.RSAKeySize
+	}
+	return false
+}
+
+// GenerateKey generates a private key for the algorithm
+func (a SigningAlgorithm) GenerateKey() (crypto.Signer, error) {
+	switch a.KeyType {
+	case KeyTypeECDSA:
+		return ecdsa.GenerateKey(a.Curve, rand.Reader)
+	case KeyTypeED25519:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	case KeyTypeRSA:
+		return rsa.GenerateKey(rand.Reader, a.RSAKeySize)
+	}
+	return nil, errors.New("unsupported key type")
+}
+
+// GeneratePEMEncodedKeyPair generates a keypair for the algorithm with the canonical ID id, optionally
+// password encrypted using a provided PassFunc, and PEM encoded.
+func GeneratePEMEncodedKeyPair(id string, pf PassFunc) (privPEM, pubPEM []byte, err error) {
+	a, err := GetSigningAlgorithm(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, err := a.GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pemEncodeKeyPair(priv, priv.Public(), pf)
+}
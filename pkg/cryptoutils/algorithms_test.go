@@ -0,0 +1,110 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestGetSigningAlgorithm(t *testing.T) {
+	for _, a := range SigningAlgorithms() {
+		got, err := GetSigningAlgorithm(a.ID)
+		if err != nil || got.ID != a.ID {
+			t.Errorf("GetSigningAlgorithm(%s) = %v, %v", a.ID, got.ID, err)
+		}
+	}
+	if _, err := GetSigningAlgorithm("dsa-1024"); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
+
+func TestGeneratePEMEncodedKeyPair(t *testing.T) {
+	for _, id := range []string{AlgorithmECDSAP256SHA256, AlgorithmECDSAP521SHA512, AlgorithmED25519, AlgorithmRSAPKCS1v152048SHA256} {
+		t.Run(id, func(t *testing.T) {
+			a, _ := GetSigningAlgorithm(id)
+			privPEM, pubPEM, err := GeneratePEMEncodedKeyPair(id, StaticPasswordFunc([]byte("hunter2")))
+			if err != nil {
+				t.Fatalf("unexpected error generating key pair: %v", err)
+			}
+			priv, err := UnmarshalPEMToPrivateKey(privPEM, StaticPasswordFunc([]byte("hunter2")))
+			if err != nil {
+				t.Fatalf("unexpected error decrypting private key: %v", err)
+			}
+			pub, err := UnmarshalPEMToPublicKey(pubPEM)
+			if err != nil {
+				t.Fatalf("unexpected error parsing public key: %v", err)
+			}
+			if !a.MatchesPublicKey(pub) {
+				t.Fatalf("public key %T does not match algorithm", pub)
+			}
+			if err := EqualKeys(pub, priv.(crypto.Signer).Public()); err != nil {
+				t.Fatalf("private and public keys differ: %v", err)
+			}
+		})
+	}
+	if _, _, err := GeneratePEMEncodedKeyPair("dsa-1024", SkipPassword); err == nil {
+		t.Error("expected error for unknown algorithm")
+	}
+}
+
+func TestGeneratePEMEncodedED25519KeyPair(t *testing.T) {
+	privPEM, pubPEM, err := GeneratePEMEncodedED25519KeyPair(SkipPassword)
+	if err != nil {
+		t.Fatalf("unexpected error generating key pair: %v", err)
+	}
+	priv, err := UnmarshalPEMToPrivateKey(privPEM, SkipPassword)
+	if err != nil {
+		t.Fatalf("unexpected error parsing private key: %v", err)
+	}
+	pub, err := UnmarshalPEMToPublicKey(pubPEM)
+	if err != nil {
+		t.Fatalf("unexpected error parsing public key: %v", err)
+	}
+	if err := EqualKeys(pub, priv.(ed25519.PrivateKey).Public()); err != nil {
+		t.Fatalf("private and public keys differ: %v", err)
+	}
+}
+
+func TestSigningAlgorithmForPublicKey(t *testing.T) {
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p224, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+
+	tests := []struct {
+		pub      crypto.PublicKey
+		expected string
+	}{
+		{pub: &p384.PublicKey, expected: AlgorithmECDSAP384SHA384},
+		{pub: edPub, expected: AlgorithmED25519},
+		{pub: &rsaKey.PublicKey, expected: AlgorithmRSAPKCS1v152048SHA256},
+	}
+	for _, tt := range tests {
+		a, err := SigningAlgorithmForPublicKey(tt.pub)
+		if err != nil || a.ID != tt.expected {
+			t.Errorf("SigningAlgorithmForPublicKey(%T) = %s, %v, want %s", tt.pub, a.ID, err, tt.expected)
+		}
+	}
+	if _, err := SigningAlgorithmForPublicKey(&p224.PublicKey); err == nil {
+		t.Error("expected error for unsupported curve")
+	}
+}
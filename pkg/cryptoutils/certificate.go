@@ -31,6 +31,8 @@ import (
 const (
 	// CertificatePEMType is the string "CERTIFICATE" to be used during PEM encoding and decoding
 	CertificatePEMType PEMType = "CERTIFICATE"
+	// CertificateRequestPEMType is the string "CERTIFICATE REQUEST" to be used during PEM encoding and decoding of CSRs
+	CertificateRequestPEMType PEMType = "CERTIFICATE REQUEST"
 )
 
 // MarshalCertificateToPEM converts the provided X509 certificate into PEM format
@@ -146,7 +148,7 @@ func ParseCSR(csr []byte) (*x509.CertificateRequest, error) {
 		return nil, errors.New("no CSR found while decoding")
 	}
 	correctType := false
-	acceptedHeaders := []string{"CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST"}
+	acceptedHeaders := []string{string(CertificateRequestPEMType), "NEW CERTIFICATE REQUEST"}
 	for _, v := range acceptedHeaders {
 		if derBlock.Type == v {
 			correctType = true
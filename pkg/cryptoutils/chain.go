@@ -0,0 +1,240 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SANMatcher matches a subject alternative name, either exactly or with a regular expression
+type SANMatcher struct {
+	// Types restricts the kinds of names that are matched. If empty, names of any type are matched.
+	Types []SANType
+	// Value must equal the name's value, if set
+	Value string
+	// Regexp must match the name's value, if set and Value is empty
+	Regexp *regexp.Regexp
+}
+
+// Match reports whether san is matched
+func (m SANMatcher) Match(san SubjectAlternativeName) bool {
+	if len(m.Types) > 0 && !slices.Contains(m.Types, san.Type) {
+		return false
+	}
+	return matchValue(m.Value, m.Regexp, san.Value)
+}
+
+func (m SANMatcher) String() string {
+	types := make([]string, 0, len(m.Types))
+	for _, t := range m.Types {
+		types = append(types, t.String())
+	}
+	prefix := ""
+	if len(types) > 0 {
+		prefix = strings.Join(types, "|") + ":"
+	}
+	if m.Value == "" && m.Regexp != nil {
+		return fmt.Sprintf("%s/%s/", prefix, m.Regexp)
+	}
+	return fmt.Sprintf("%s%q", prefix, m.Value)
+}
+
+// IssuerMatcher matches the OIDC issuer recorded in a Fulcio certificate, either exactly or with a
+// regular expression
+type IssuerMatcher struct {
+	// Value must equal the issuer, if set
+	Value string
+	// Regexp must match the issuer, if set and Value is empty
+	Regexp *regexp.Regexp
+}
+
+// Match reports whether issuer is matched
+func (m IssuerMatcher) Match(issuer string) bool {
+	return matchValue(m.Value, m.Regexp, issuer)
+}
+
+func (m IssuerMatcher) String() string {
+	if m.Value == "" && m.Regexp != nil {
+		return fmt.Sprintf("/%s/", m.Regexp)
+	}
+	return fmt.Sprintf("%q", m.Value)
+}
+
+func matchValue(value string, re *regexp.Regexp, s string) bool {
+	if value != "" || re == nil {
+		return value == s
+	}
+	return re.MatchString(s)
+}
+
+// ChainVerifyOptions configures VerifyCertificateChain
+type ChainVerifyOptions struct {
+	// Roots are the trusted root certificates. At least one is required.
+	Roots []*x509.Certificate
+	// Intermediates are untrusted certificates which may be used to build a chain to a root
+	Intermediates []*x509.Certificate
+	// CurrentTime is the time at which every certificate in the chain must be valid. For
+	// short-lived certificates such as those issued by Fulcio, this must be the time the
+	// signature was created, for example from a transparency log entry or a signed timestamp.
+	// If zero, the current time is used.
+	CurrentTime time.Time
+	// KeyUsages are the extended key usages the leaf must be valid for, and which every
+	// certificate in the chain must permit. If empty, x509.ExtKeyUsageCodeSigning is required.
+	// Use x509.ExtKeyUsageAny to accept any usage.
+	KeyUsages []x509.ExtKeyUsage
+	// SANMatchers, if set, require at least one subject alternative name of the leaf to be
+	// matched by at least one of the matchers
+	SANMatchers []SANMatcher
+	// IssuerMatchers, if set, require the OIDC issuer extension of the leaf to be matched by at
+	// least one of the matchers
+	IssuerMatchers []IssuerMatcher
+}
+
+// CertificateChainError is returned when no chain from a certificate to a trusted root is valid at
+// the verification time. Err is the error returned by x509.Certificate.Verify, such as an
+// x509.UnknownAuthorityError or x509.CertificateInvalidError.
+type CertificateChainError struct {
+	Time time.Time
+	Err  error
+}
+
+func (e *CertificateChainError) Error() string {
+	return fmt.Sprintf("certificate chain verification failed at %s: %v", formatTime(e.Time), e.Err)
+}
+
+func (e *CertificateChainError) Unwrap() error {
+	return e.Err
+}
+
+// SANMismatchError is returned when none of a certificate's subject alternative names are matched
+type SANMismatchError struct {
+	SANs     []SubjectAlternativeName
+	Matchers []SANMatcher
+}
+
+func (e *SANMismatchError) Error() string {
+	sans := make([]string, 0, len(e.SANs))
+	for _, san := range e.SANs {
+		sans = append(sans, fmt.Sprintf("%s:%q", san.Type, san.Value))
+	}
+	matchers := make([]string, 0, len(e.Matchers))
+	for _, m := range e.Matchers {
+		matchers = append(matchers, m.String())
+	}
+	return fmt.Sprintf("certificate subject alternative names [%s] do not match any of [%s]", strings.Join(sans, ", "), strings.Join(matchers, ", "))
+}
+
+// IssuerMismatchError is returned when a certificate's OIDC issuer is missing or not matched
+type IssuerMismatchError struct {
+	Issuer   string
+	Matchers []IssuerMatcher
+}
+
+func (e *IssuerMismatchError) Error() string {
+	matchers := make([]string, 0, len(e.Matchers))
+	for _, m := range e.Matchers {
+		matchers = append(matchers, m.String())
+	}
+	if e.Issuer == "" {
+		return fmt.Sprintf("certificate has no OIDC issuer, expected one of [%s]", strings.Join(matchers, ", "))
+	}
+	return fmt.Sprintf("certificate OIDC issuer %q does not match any of [%s]", e.Issuer, strings.Join(matchers, ", "))
+}
+
+// VerifyCertificateChain verifies that leaf chains to one of the roots, optionally through the
+// intermediates, with every certificate valid at opts.CurrentTime and permitting opts.KeyUsages.
+// It then checks the leaf's subject alternative names and OIDC issuer against the matchers.
+// It returns the verified chains, each starting with leaf and ending with a root.
+//
+// Errors are a *CertificateChainError if no valid chain was found, a *SANMismatchError or an
+// *IssuerMismatchError.
+func VerifyCertificateChain(leaf *x509.Certificate, opts ChainVerifyOptions) ([][]*x509.Certificate, error) {
+	if leaf == nil {
+		return nil, errors.New("certificate is nil")
+	}
+	if len(opts.Roots) == 0 {
+		return nil, errors.New("at least one root certificate is required")
+	}
+	roots := x509.NewCertPool()
+	for _, root := range opts.Roots {
+		roots.AddCert(root)
+	}
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range opts.Intermediates {
+		intermediates.AddCert(intermediate)
+	}
+	currentTime := opts.CurrentTime
+	if currentTime.IsZero() {
+		currentTime = time.Now()
+	}
+	keyUsages := opts.KeyUsages
+	if len(keyUsages) == 0 {
+		keyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	}
+
+	// Fulcio certificates may only contain OtherName SANs in a critical extension, which
+	// crypto/x509 does not handle. They are checked with the SAN matchers below instead.
+	toVerify := leaf
+	if i := slices.IndexFunc(leaf.UnhandledCriticalExtensions, SANOID.Equal); i >= 0 {
+		c := *leaf
+		c.UnhandledCriticalExtensions = slices.Delete(slices.Clone(leaf.UnhandledCriticalExtensions), i, i+1)
+		toVerify = &c
+	}
+	chains, err := toVerify.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   currentTime,
+		KeyUsages:     keyUsages,
+	})
+	if err != nil {
+		return nil, &CertificateChainError{Time: currentTime, Err: err}
+	}
+	for _, chain := range chains {
+		chain[0] = leaf
+	}
+
+	if len(opts.SANMatchers) > 0 {
+		sans, err := GetTypedSubjectAlternateNames(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("parsing subject alternative names: %w", err)
+		}
+		matched := slices.ContainsFunc(sans, func(san SubjectAlternativeName) bool {
+			return slices.ContainsFunc(opts.SANMatchers, func(m SANMatcher) bool { return m.Match(san) })
+		})
+		if !matched {
+			return nil, &SANMismatchError{SANs: sans, Matchers: opts.SANMatchers}
+		}
+	}
+
+	if len(opts.IssuerMatchers) > 0 {
+		exts, err := UnmarshalFulcioExtensions(leaf.Extensions)
+		if err != nil {
+			return nil, err
+		}
+		issuer := exts.Issuer
+		matched := issuer != "" && slices.ContainsFunc(opts.IssuerMatchers, func(m IssuerMatcher) bool { return m.Match(issuer) })
+		if !matched {
+			return nil, &IssuerMismatchError{Issuer: issuer, Matchers: opts.IssuerMatchers}
+		}
+	}
+	return chains, nil
+}
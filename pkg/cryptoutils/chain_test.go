@@ -0,0 +1,238 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/test"
+)
+
+func TestVerifyCertificateChain(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+	leafCert, _, _ := test.GenerateLeafCert("subject@example.com", "https://accounts.example.com", subCert, subKey)
+	otherRoot, _, _ := test.GenerateRootCa()
+
+	chains, err := VerifyCertificateChain(leafCert, ChainVerifyOptions{
+		Roots:         []*x509.Certificate{rootCert},
+		Intermediates: []*x509.Certificate{subCert},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error verifying chain: %v", err)
+	}
+	if len(chains) != 1 || len(chains[0]) != 3 {
+		t.Fatalf("expected one chain of 3 certificates, got %v", chains)
+	}
+	if chains[0][0] != leafCert || !chains[0][1].Equal(subCert) || !chains[0][2].Equal(rootCert) {
+		t.Fatalf("unexpected chain")
+	}
+
+	// verification at an earlier time within the validity period of every certificate
+	if _, err := VerifyCertificateChain(leafCert, ChainVerifyOptions{
+		Roots:         []*x509.Certificate{rootCert},
+		Intermediates: []*x509.Certificate{subCert},
+		CurrentTime:   leafCert.NotBefore.Add(time.Second),
+	}); err != nil {
+		t.Fatalf("unexpected error verifying chain at signing time: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   ChainVerifyOptions
+		reason x509.InvalidReason
+	}{
+		{
+			name: "expired",
+			opts: ChainVerifyOptions{
+				Roots:         []*x509.Certificate{rootCert},
+				Intermediates: []*x509.Certificate{subCert},
+				CurrentTime:   leafCert.NotAfter.Add(time.Second),
+			},
+			reason: x509.Expired,
+		},
+		{
+			name: "not yet valid",
+			opts: ChainVerifyOptions{
+				Roots:         []*x509.Certificate{rootCert},
+				Intermediates: []*x509.Certificate{subCert},
+				CurrentTime:   leafCert.NotBefore.Add(-time.Second),
+			},
+			reason: x509.Expired,
+		},
+		{
+			name: "wrong key usage",
+			opts: ChainVerifyOptions{
+				Roots:         []*x509.Certificate{rootCert},
+				Intermediates: []*x509.Certificate{subCert},
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			},
+			reason: x509.IncompatibleUsage,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := VerifyCertificateChain(leafCert, tc.opts)
+			var chainErr *CertificateChainError
+			if !errors.As(err, &chainErr) {
+				t.Fatalf("expected CertificateChainError, got %v", err)
+			}
+			var invalidErr x509.CertificateInvalidError
+			if !errors.As(err, &invalidErr) || invalidErr.Reason != tc.reason {
+				t.Fatalf("expected invalid certificate with reason %v, got %v", tc.reason, err)
+			}
+		})
+	}
+
+	for name, opts := range map[string]ChainVerifyOptions{
+		"untrusted root": {
+			Roots:         []*x509.Certificate{otherRoot},
+			Intermediates: []*x509.Certificate{subCert},
+		},
+		"missing intermediate": {
+			Roots: []*x509.Certificate{rootCert},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := VerifyCertificateChain(leafCert, opts)
+			var authorityErr x509.UnknownAuthorityError
+			if !errors.As(err, &authorityErr) {
+				t.Fatalf("expected UnknownAuthorityError, got %v", err)
+			}
+		})
+	}
+
+	if _, err := VerifyCertificateChain(leafCert, ChainVerifyOptions{}); err == nil {
+		t.Fatal("expected error without roots")
+	}
+	if _, err := VerifyCertificateChain(nil, ChainVerifyOptions{Roots: []*x509.Certificate{rootCert}}); err == nil {
+		t.Fatal("expected error with nil certificate")
+	}
+}
+
+func TestVerifyCertificateChainMatchers(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+	leafCert, _, _ := test.GenerateLeafCert("subject@example.com", "https://accounts.example.com", subCert, subKey)
+	baseOpts := ChainVerifyOptions{
+		Roots:         []*x509.Certificate{rootCert},
+		Intermediates: []*x509.Certificate{subCert},
+	}
+
+	tests := []struct {
+		name    string
+		sans    []SANMatcher
+		issuers []IssuerMatcher
+		err     interface{}
+	}{
+		{name: "exact SAN", sans: []SANMatcher{{Value: "subject@example.com"}}},
+		{name: "typed SAN", sans: []SANMatcher{{Types: []SANType{SANTypeEmail}, Value: "subject@example.com"}}},
+		{name: "regexp SAN", sans: []SANMatcher{{Regexp: regexp.MustCompile(`@example\.com$`)}}},
+		{name: "any of SANs", sans: []SANMatcher{{Value: "other@example.com"}, {Value: "subject@example.com"}}},
+		{name: "wrong SAN type", sans: []SANMatcher{{Types: []SANType{SANTypeURI}, Value: "subject@example.com"}}, err: &SANMismatchError{}},
+		{name: "wrong SAN", sans: []SANMatcher{{Value: "other@example.com"}}, err: &SANMismatchError{}},
+		{name: "exact issuer", issuers: []IssuerMatcher{{Value: "https://accounts.example.com"}}},
+		{name: "regexp issuer", issuers: []IssuerMatcher{{Regexp: regexp.MustCompile(`^https://accounts\.`)}}},
+		{name: "wrong issuer", issuers: []IssuerMatcher{{Value: "https://other.example.com"}}, err: &IssuerMismatchError{}},
+		{
+			name:    "SAN and issuer",
+			sans:    []SANMatcher{{Value: "subject@example.com"}},
+			issuers: []IssuerMatcher{{Value: "https://accounts.example.com"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := baseOpts
+			opts.SANMatchers = tc.sans
+			opts.IssuerMatchers = tc.issuers
+			_, err := VerifyCertificateChain(leafCert, opts)
+			switch expected := tc.err.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			case *SANMismatchError:
+				if !errors.As(err, &expected) {
+					t.Fatalf("expected SANMismatchError, got %v", err)
+				}
+				if len(expected.SANs) != 1 || expected.SANs[0].Value != "subject@example.com" {
+					t.Fatalf("unexpected SANs in error: %v", expected.SANs)
+				}
+			case *IssuerMismatchError:
+				if !errors.As(err, &expected) {
+					t.Fatalf("expected IssuerMismatchError, got %v", err)
+				}
+				if expected.Issuer != "https://accounts.example.com" {
+					t.Fatalf("unexpected issuer in error: %v", expected.Issuer)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyCertificateChainFulcioExtensions(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+
+	// a critical SAN extension containing only an OtherName, and a DER encoded issuer which
+	// takes precedence over the legacy one set by GenerateLeafCert
+	san, err := MarshalOtherNameSAN("foo!example.com", true)
+	if err != nil {
+		t.Fatalf("error marshalling SAN: %v", err)
+	}
+	issuer, _ := asn1.MarshalWithParams("https://issuer-v2.example.com", "utf8")
+	leafCert, _, err := test.GenerateLeafCert("unused", "https://legacy.example.com", subCert, subKey,
+		*san, pkix.Extension{Id: OIDIssuerV2, Value: issuer})
+	if err != nil {
+		t.Fatalf("error generating leaf certificate: %v", err)
+	}
+	if len(leafCert.UnhandledCriticalExtensions) != 1 {
+		t.Fatalf("expected the OtherName SAN extension to be unhandled by crypto/x509")
+	}
+
+	_, err = VerifyCertificateChain(leafCert, ChainVerifyOptions{
+		Roots:          []*x509.Certificate{rootCert},
+		Intermediates:  []*x509.Certificate{subCert},
+		SANMatchers:    []SANMatcher{{Types: []SANType{SANTypeOtherName}, Value: "foo!example.com"}},
+		IssuerMatchers: []IssuerMatcher{{Value: "https://issuer-v2.example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(leafCert.UnhandledCriticalExtensions) != 1 {
+		t.Fatalf("the certificate must not be modified")
+	}
+
+	// without an issuer
+	noIssuer := *leafCert
+	noIssuer.Extensions = nil
+	_, err = VerifyCertificateChain(&noIssuer, ChainVerifyOptions{
+		Roots:          []*x509.Certificate{rootCert},
+		Intermediates:  []*x509.Certificate{subCert},
+		IssuerMatchers: []IssuerMatcher{{Value: "https://issuer-v2.example.com"}},
+	})
+	var issuerErr *IssuerMismatchError
+	if !errors.As(err, &issuerErr) || !strings.Contains(err.Error(), "no OIDC issuer") {
+		t.Fatalf("expected missing issuer error, got %v", err)
+	}
+}
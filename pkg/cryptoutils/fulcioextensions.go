@@ -0,0 +1,227 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// OIDs of the extensions in Fulcio-issued certificates, see
+// https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md
+var (
+	// OIDIssuer is the deprecated OIDC issuer extension, whose value is a raw string. Use OIDIssuerV2.
+	OIDIssuer = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// OIDGitHubWorkflowTrigger is the deprecated GitHub workflow trigger extension, whose value is a raw string
+	OIDGitHubWorkflowTrigger = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 2}
+	// OIDGitHubWorkflowSHA is the deprecated GitHub workflow SHA extension, whose value is a raw string
+	OIDGitHubWorkflowSHA = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 3}
+	// OIDGitHubWorkflowName is the deprecated GitHub workflow name extension, whose value is a raw string
+	OIDGitHubWorkflowName = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 4}
+	// OIDGitHubWorkflowRepository is the deprecated GitHub workflow repository extension, whose value is a raw string
+	OIDGitHubWorkflowRepository = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}
+	// OIDGitHubWorkflowRef is the deprecated GitHub workflow ref extension, whose value is a raw string
+	OIDGitHubWorkflowRef = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 6}
+
+	// OIDIssuerV2 is the OIDC issuer extension, whose value is a DER encoded UTF8String
+	OIDIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	// OIDBuildSignerURI is the build signer URI extension
+	OIDBuildSignerURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 9}
+	// OIDBuildSignerDigest is the build signer digest extension
+	OIDBuildSignerDigest = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 10}
+	// OIDRunnerEnvironment is the runner environment extension
+	OIDRunnerEnvironment = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 11}
+	// OIDSourceRepositoryURI is the source repository URI extension
+	OIDSourceRepositoryURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 12}
+	// OIDSourceRepositoryDigest is the source repository digest extension
+	OIDSourceRepositoryDigest = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 13}
+	// OIDSourceRepositoryRef is the source repository ref extension
+	OIDSourceRepositoryRef = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 14}
+	// OIDSourceRepositoryIdentifier is the source repository identifier extension
+	OIDSourceRepositoryIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 15}
+	// OIDSourceRepositoryOwnerURI is the source repository owner URI extension
+	OIDSourceRepositoryOwnerURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 16}
+	// OIDSourceRepositoryOwnerIdentifier is the source repository owner identifier extension
+	OIDSourceRepositoryOwnerIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 17}
+	// OIDBuildConfigURI is the build config URI extension
+	OIDBuildConfigURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 18}
+	// OIDBuildConfigDigest is the build config digest extension
+	OIDBuildConfigDigest = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 19}
+	// OIDBuildTrigger is the build trigger extension
+	OIDBuildTrigger = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 20}
+	// OIDRunInvocationURI is the run invocation URI extension
+	OIDRunInvocationURI = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 21}
+	// OIDSourceRepositoryVisibilityAtSigning is the source repository visibility at signing extension
+	OIDSourceRepositoryVisibilityAtSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 22}
+)
+
+// FulcioExtensions holds the values of the extensions in a Fulcio-issued certificate
+type FulcioExtensions struct {
+	// Issuer is the OIDC issuer of the identity token used to request the certificate. It is
+	// read from OIDIssuerV2 if present, and otherwise from the deprecated OIDIssuer.
+	Issuer string
+
+	// Deprecated: use BuildTrigger
+	GithubWorkflowTrigger string
+	// Deprecated: use SourceRepositoryDigest
+	GithubWorkflowSHA string
+	// Deprecated: use BuildConfigURI or BuildConfigDigest
+	GithubWorkflowName string
+	// Deprecated: use SourceRepositoryURI
+	GithubWorkflowRepository string
+	// Deprecated: use SourceRepositoryRef
+	GithubWorkflowRef string
+
+	// BuildSignerURI is a reference to the signer's specific build instructions
+	BuildSignerURI string
+	// BuildSignerDigest is an immutable reference to the build instructions
+	BuildSignerDigest string
+	// RunnerEnvironment is whether the build took place on platform-hosted or self-hosted infrastructure
+	RunnerEnvironment string
+	// SourceRepositoryURI is the source repository the build was based on
+	SourceRepositoryURI string
+	// SourceRepositoryDigest is the immutable reference to the source code the build was based on
+	SourceRepositoryDigest string
+	// SourceRepositoryRef is the ref of the source code the build was based on
+	SourceRepositoryRef string
+	// SourceRepositoryIdentifier is the immutable identifier of the source repository
+	SourceRepositoryIdentifier string
+	// SourceRepositoryOwnerURI is the owner of the source repository
+	SourceRepositoryOwnerURI string
+	// SourceRepositoryOwnerIdentifier is the immutable identifier of the owner of the source repository
+	SourceRepositoryOwnerIdentifier string
+	// BuildConfigURI is the build configuration, such as a workflow file
+	BuildConfigURI string
+	// BuildConfigDigest is an immutable reference to the build configuration
+	BuildConfigDigest string
+	// BuildTrigger is the event that triggered the build
+	BuildTrigger string
+	// RunInvocationURI is the run that produced the signature
+	RunInvocationURI string
+	// SourceRepositoryVisibilityAtSigning is the visibility of the source repository when it was signed
+	SourceRepositoryVisibilityAtSigning string
+}
+
+// rawStringExtensions maps the deprecated extensions, whose values are raw strings, to their fields
+func (e *FulcioExtensions) rawStringExtensions() []fulcioExtension {
+	return []fulcioExtension{
+		{OIDGitHubWorkflowTrigger, &e.GithubWorkflowTrigger},
+		{OIDGitHubWorkflowSHA, &e.GithubWorkflowSHA},
+		{OIDGitHubWorkflowName, &e.GithubWorkflowName},
+		{OIDGitHubWorkflowRepository, &e.GithubWorkflowRepository},
+		{OIDGitHubWorkflowRef, &e.GithubWorkflowRef},
+	}
+}
+
+// derStringExtensions maps the extensions whose values are DER encoded UTF8Strings to their fields
+func (e *FulcioExtensions) derStringExtensions() []fulcioExtension {
+	return []fulcioExtension{
+		{OIDIssuerV2, &e.Issuer},
+		{OIDBuildSignerURI, &e.BuildSignerURI},
+		{OIDBuildSignerDigest, &e.BuildSignerDigest},
+		{OIDRunnerEnvironment, &e.RunnerEnvironment},
+		{OIDSourceRepositoryURI, &e.SourceRepositoryURI},
+		{OIDSourceRepositoryDigest, &e.SourceRepositoryDigest},
+		{OIDSourceRepositoryRef, &e.SourceRepositoryRef},
+		{OIDSourceRepositoryIdentifier, &e.SourceRepositoryIdentifier},
+		{OIDSourceRepositoryOwnerURI, &e.SourceRepositoryOwnerURI},
+		{OIDSourceRepositoryOwnerIdentifier, &e.SourceRepositoryOwnerIdentifier},
+		{OIDBuildConfigURI, &e.BuildConfigURI},
+		{OIDBuildConfigDigest, &e.BuildConfigDigest},
+		{OIDBuildTrigger, &e.BuildTrigger},
+		{OIDRunInvocationURI, &e.RunInvocationURI},
+		{OIDSourceRepositoryVisibilityAtSigning, &e.SourceRepositoryVisibilityAtSigning},
+	}
+}
+
+type fulcioExtension struct {
+	id    asn1.ObjectIdentifier
+	value *string
+}
+
+// MarshalFulcioExtensions returns the certificate extensions for the non-empty values in e, in
+// OID order. The issuer is encoded in both OIDIssuer and OIDIssuerV2 for compatibility with
+// clients that only read the deprecated extension, as Fulcio does.
+func MarshalFulcioExtensions(e *FulcioExtensions) ([]pkix.Extension, error) {
+	if e == nil {
+		return nil, errors.New("nil extensions provided")
+	}
+	exts := []pkix.Extension{}
+	if e.Issuer != "" {
+		exts = append(exts, pkix.Extension{Id: OIDIssuer, Value: []byte(e.Issuer)})
+	}
+	for _, ext := range e.rawStringExtensions() {
+		if *ext.value != "" {
+			exts = append(exts, pkix.Extension{Id: ext.id, Value: []byte(*ext.value)})
+		}
+	}
+	for _, ext := range e.derStringExtensions() {
+		if *ext.value == "" {
+			continue
+		}
+		value, err := asn1.MarshalWithParams(*ext.value, "utf8")
+		if err != nil {
+			return nil, fmt.Errorf("marshaling extension %v: %w", ext.id, err)
+		}
+		exts = append(exts, pkix.Extension{Id: ext.id, Value: value})
+	}
+	return exts, nil
+}
+
+// UnmarshalFulcioExtensions extracts the Fulcio extensions from exts, such as the Extensions of a
+// parsed certificate. Other extensions, including unknown Fulcio extensions, are ignored.
+func UnmarshalFulcioExtensions(exts []pkix.Extension) (*FulcioExtensions, error) {
+	out := &FulcioExtensions{}
+	rawStrings := out.rawStringExtensions()
+	derStrings := out.derStringExtensions()
+	var legacyIssuer string
+	for _, ext := range exts {
+		if ext.Id.Equal(OIDIssuer) {
+			legacyIssuer = string(ext.Value)
+			continue
+		}
+		if field := findFulcioExtension(rawStrings, ext.Id); field != nil {
+			*field = string(ext.Value)
+			continue
+		}
+		if field := findFulcioExtension(derStrings, ext.Id); field != nil {
+			var value string
+			rest, err := asn1.Unmarshal(ext.Value, &value)
+			if err != nil {
+				return nil, fmt.Errorf("parsing extension %v: %w", ext.Id, err)
+			}
+			if len(rest) != 0 {
+				return nil, fmt.Errorf("trailing data after extension %v", ext.Id)
+			}
+			*field = value
+		}
+	}
+	if out.Issuer == "" {
+		out.Issuer = legacyIssuer
+	}
+	return out, nil
+}
+
+func findFulcioExtension(exts []fulcioExtension, id asn1.ObjectIdentifier) *string {
+	for _, ext := range exts {
+		if ext.id.Equal(id) {
+			return ext.value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/sigstore/sigstore/test"
+)
+
+func TestMarshalAndUnmarshalFulcioExtensions(t *testing.T) {
+	exts := &FulcioExtensions{
+		Issuer:                              "https://token.actions.githubusercontent.com",
+		GithubWorkflowTrigger:               "push",
+		GithubWorkflowSHA:                   "a1b2c3",
+		GithubWorkflowName:                  "release",
+		GithubWorkflowRepository:            "sigstore/sigstore",
+		GithubWorkflowRef:                   "refs/heads/main",
+		BuildSignerURI:                      "https://github.com/sigstore/sigstore/.github/workflows/release.yml@refs/heads/main",
+		BuildSignerDigest:                   "a1b2c3",
+		RunnerEnvironment:                   "github-hosted",
+		SourceRepositoryURI:                 "https://github.com/sigstore/sigstore",
+		SourceRepositoryDigest:              "a1b2c3",
+		SourceRepositoryRef:                 "refs/heads/main",
+		SourceRepositoryIdentifier:          "1",
+		SourceRepositoryOwnerURI:            "https://github.com/sigstore",
+		SourceRepositoryOwnerIdentifier:     "2",
+		BuildConfigURI:                      "https://github.com/sigstore/sigstore/.github/workflows/release.yml@refs/heads/main",
+		BuildConfigDigest:                   "a1b2c3",
+		BuildTrigger:                        "push",
+		RunInvocationURI:                    "https://github.com/sigstore/sigstore/actions/runs/1/attempts/1",
+		SourceRepositoryVisibilityAtSigning: "public",
+	}
+	marshaled, err := MarshalFulcioExtensions(exts)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling extensions: %v", err)
+	}
+	// every field, with the issuer in both the deprecated and v2 extensions
+	if len(marshaled) != 21 {
+		t.Fatalf("expected 21 extensions, got %d", len(marshaled))
+	}
+	for _, ext := range marshaled {
+		switch {
+		case ext.Id.Equal(OIDIssuer):
+			if string(ext.Value) != exts.Issuer {
+				t.Errorf("unexpected deprecated issuer value %q", ext.Value)
+			}
+		case ext.Id.Equal(OIDIssuerV2):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err != nil || issuer != exts.Issuer {
+				t.Errorf("unexpected issuer value %x", ext.Value)
+			}
+		}
+	}
+
+	unmarshaled, err := UnmarshalFulcioExtensions(marshaled)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling extensions: %v", err)
+	}
+	if !reflect.DeepEqual(unmarshaled, exts) {
+		t.Fatalf("extensions did not round trip, expected %+v, got %+v", exts, unmarshaled)
+	}
+
+	// empty values are omitted
+	marshaled, err = MarshalFulcioExtensions(&FulcioExtensions{})
+	if err != nil || len(marshaled) != 0 {
+		t.Fatalf("expected no extensions, got %v, %v", marshaled, err)
+	}
+	if _, err := MarshalFulcioExtensions(nil); err == nil {
+		t.Fatal("expected error with nil extensions")
+	}
+}
+
+func TestUnmarshalFulcioExtensionsEncodings(t *testing.T) {
+	// UTF8String "https://issuer-v2.example.com"
+	v2Issuer, _ := hex.DecodeString("0c1d68747470733a2f2f6973737565722d76322e6578616d706c652e636f6d")
+
+	// only the deprecated issuer
+	exts, err := UnmarshalFulcioExtensions([]pkix.Extension{{Id: OIDIssuer, Value: []byte("https://legacy.example.com")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exts.Issuer != "https://legacy.example.com" {
+		t.Errorf("unexpected issuer %q", exts.Issuer)
+	}
+
+	// the v2 issuer takes precedence regardless of order
+	for _, ordered := range [][]pkix.Extension{
+		{{Id: OIDIssuer, Value: []byte("https://legacy.example.com")}, {Id: OIDIssuerV2, Value: v2Issuer}},
+		{{Id: OIDIssuerV2, Value: v2Issuer}, {Id: OIDIssuer, Value: []byte("https://legacy.example.com")}},
+	} {
+		exts, err = UnmarshalFulcioExtensions(ordered)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if exts.Issuer != "https://issuer-v2.example.com" {
+			t.Errorf("unexpected issuer %q", exts.Issuer)
+		}
+	}
+
+	// unrelated and unknown Fulcio extensions are ignored
+	exts, err = UnmarshalFulcioExtensions([]pkix.Extension{
+		{Id: SANOID, Value: []byte{0x30, 0x00}},
+		{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 99}, Value: []byte("unknown")},
+	})
+	if err != nil || !reflect.DeepEqual(exts, &FulcioExtensions{}) {
+		t.Fatalf("expected empty extensions, got %+v, %v", exts, err)
+	}
+
+	// v2 extensions must be DER encoded
+	for name, value := range map[string][]byte{
+		"raw string":    []byte("https://issuer-v2.example.com"),
+		"trailing data": append(bytes.Clone(v2Issuer), 0x00),
+	} {
+		if _, err := UnmarshalFulcioExtensions([]pkix.Extension{{Id: OIDBuildSignerURI, Value: value}}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFulcioExtensionsInCertificate(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+
+	expected := &FulcioExtensions{
+		Issuer:              "https://token.actions.githubusercontent.com",
+		SourceRepositoryURI: "https://github.com/sigstore/sigstore",
+		RunnerEnvironment:   "github-hosted",
+	}
+	exts, err := MarshalFulcioExtensions(expected)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling extensions: %v", err)
+	}
+	leafCert, _, err := test.GenerateLeafCertWithExtensions("subject@example.com", subCert, subKey, exts...)
+	if err != nil {
+		t.Fatalf("error generating leaf certificate: %v", err)
+	}
+	got, err := UnmarshalFulcioExtensions(leafCert.Extensions)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling extensions: %v", err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got)
+	}
+}
@@ -0,0 +1,243 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v3"
+	"golang.org/x/crypto/ssh"
+)
+
+// OpenSSHPrivateKeyPEMType is the string "OPENSSH PRIVATE KEY" used for private keys in the OpenSSH format
+const OpenSSHPrivateKeyPEMType PEMType = "OPENSSH PRIVATE KEY"
+
+// UnmarshalPublicKey parses a public key from any of the following formats, detected from its content:
+//
+// - PEM encoded SubjectPublicKeyInfo or PKCS#1, as accepted by UnmarshalPEMToPublicKey
+//
+// - an OpenSSH authorized_keys line of type ssh-ed25519, ecdsa-sha2-nistp256/384/521 or ssh-rsa
+//
+// - an RFC 7517 JWK, or a JWK set containing exactly one key
+//
+// The returned key is an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func UnmarshalPublicKey(data []byte) (crypto.PublicKey, error) {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		jwk, err := unmarshalSingleJWK(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return checkPublicKeyType(jwk.Public().Key)
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+		return UnmarshalPEMToPublicKey(trimmed)
+	}
+	sshPub, _, _, _, err := ssh.ParseAuthorizedKey(trimmed)
+	if err != nil {
+		return nil, errors.New("unrecognized public key format")
+	}
+	// security key types such as sk-ssh-ed25519@openssh.com also expose a plain public key, but their
+	// signatures cover additional data and never verify as plain signatures
+	switch sshPub.Type() {
+	case ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521, ssh.KeyAlgoRSA:
+	default:
+		return nil, fmt.Errorf("unsupported OpenSSH key type %s", sshPub.Type())
+	}
+	cryptoPub, ok := sshPub.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported OpenSSH key type %s", sshPub.Type())
+	}
+	return checkPublicKeyType(cryptoPub.CryptoPublicKey())
+}
+
+// UnmarshalPrivateKey parses a private key from any of the following formats, detected from its content:
+//
+// - the PEM encoded formats accepted by UnmarshalPEMToPrivateKey
+//
+// - an OpenSSH private key, decrypted with a passphrase from pf if it is encrypted
+//
+// - an RFC 7517 JWK, or a JWK set containing exactly one key
+//
+// The returned key is an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+func UnmarshalPrivateKey(data []byte, pf PassFunc) (crypto.PrivateKey, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		jwk, err := unmarshalSingleJWK(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		if jwk.IsPublic() {
+			return nil, errors.New("JWK does not contain a private key")
+		}
+		return checkPrivateKeyType(jwk.Key)
+	}
+
+	block, _ := pem.Decode(trimmed)
+	if block == nil {
+		return nil, errors.New("unrecognized private key format")
+	}
+	if block.Type != string(OpenSSHPrivateKeyPEMType) {
+		return UnmarshalPEMToPrivateKey(trimmed, pf)
+	}
+	priv, err := ssh.ParseRawPrivateKey(trimmed)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if pf == nil {
+			return nil, errors.New("a passphrase is required to decrypt the OpenSSH private key")
+		}
+		passphrase, pfErr := readPassword(pf, false)
+		if pfErr != nil {
+			return nil, pfErr
+		}
+		defer ZeroPassword(passphrase)
+		if passphrase == nil {
+			return nil, errors.New("a passphrase is required to decrypt the OpenSSH private key")
+		}
+		priv, err = ssh.ParseRawPrivateKeyWithPassphrase(trimmed, passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// ssh returns a pointer for ed25519 keys, unlike the rest of the standard library
+	if p, ok := priv.(*ed25519.PrivateKey); ok {
+		priv = *p
+	}
+	return checkPrivateKeyType(priv)
+}
+
+// UnmarshalJWKSToPublicKeys returns the public keys in an RFC 7517 JWK set, in the order they appear
+func UnmarshalJWKSToPublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("parsing JWK set: %w", err)
+	}
+	pubs := make([]crypto.PublicKey, 0, len(jwks.Keys))
+	for i, jwk := range jwks.Keys {
+		pub, err := checkPublicKeyType(jwk.Public().Key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		pubs = append(pubs, pub)
+	}
+	return pubs, nil
+}
+
+// unmarshalSingleJWK parses a JWK, or a JWK set containing exactly one key
+func unmarshalSingleJWK(data []byte) (*jose.JSONWebKey, error) {
+	var probe struct {
+		Keys json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parsing JWK: %w", err)
+	}
+	if probe.Keys != nil {
+		var jwks jose.JSONWebKeySet
+		if err := json.Unmarshal(data, &jwks); err != nil {
+			return nil, fmt.Errorf("parsing JWK set: %w", err)
+		}
+		if len(jwks.Keys) != 1 {
+			return nil, fmt.Errorf("JWK set contains %d keys, expected exactly one", len(jwks.Keys))
+		}
+		return &jwks.Keys[0], nil
+	}
+	jwk := &jose.JSONWebKey{}
+	if err := jwk.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("parsing JWK: %w", err)
+	}
+	return jwk, nil
+}
+
+func checkPublicKeyType(pub crypto.PublicKey) (crypto.PublicKey, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return pub, nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+func checkPrivateKeyType(priv crypto.PrivateKey) (crypto.PrivateKey, error) {
+	switch priv.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return priv, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T", priv)
+}
+
+// newJWK returns a JWK for key with its RFC 7638 SHA-256 thumbprint as the key ID
+func newJWK(key interface{}) (*jose.JSONWebKey, error) {
+	jwk := &jose.JSONWebKey{Key: key, Use: "sig"}
+	if !jwk.Valid() {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+	return jwk, nil
+}
+
+// MarshalPublicKeyToJWK converts a crypto.PublicKey into an RFC 7517 JWK, with its RFC 7638
+// SHA-256 thumbprint as the key ID
+func MarshalPublicKeyToJWK(pub crypto.PublicKey) ([]byte, error) {
+	if _, err := checkPublicKeyType(pub); err != nil {
+		return nil, err
+	}
+	jwk, err := newJWK(pub)
+	if err != nil {
+		return nil, err
+	}
+	return jwk.MarshalJSON()
+}
+
+// MarshalPrivateKeyToJWK converts a crypto.PrivateKey into an RFC 7517 JWK, with the RFC 7638
+// SHA-256 thumbprint of its public key as the key ID
+func MarshalPrivateKeyToJWK(priv crypto.PrivateKey) ([]byte, error) {
+	if _, err := checkPrivateKeyType(priv); err != nil {
+		return nil, err
+	}
+	jwk, err := newJWK(priv)
+	if err != nil {
+		return nil, err
+	}
+	return jwk.MarshalJSON()
+}
+
+// MarshalPublicKeysToJWKS converts public keys into an RFC 7517 JWK set
+func MarshalPublicKeysToJWKS(pubs ...crypto.PublicKey) ([]byte, error) {
+	jwks := jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(pubs))}
+	for _, pub := range pubs {
+		if _, err := checkPublicKeyType(pub); err != nil {
+			return nil, err
+		}
+		jwk, err := newJWK(pub)
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, *jwk)
+	}
+	return json.Marshal(jwks)
+}
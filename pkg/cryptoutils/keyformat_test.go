@@ -0,0 +1,230 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func generateTestKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]crypto.Signer{"ecdsa": ecKey, "rsa": rsaKey, "ed25519": edKey}
+}
+
+func TestUnmarshalOpenSSHKeys(t *testing.T) {
+	t.Parallel()
+	for name, priv := range generateTestKeys(t) {
+		name, priv := name, priv
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			sshPub, err := ssh.NewPublicKey(priv.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			authorizedKey := string(ssh.MarshalAuthorizedKey(sshPub))
+			pub, err := UnmarshalPublicKey([]byte(strings.TrimSpace(authorizedKey) + " user@example.com\n"))
+			if err != nil {
+				t.Fatalf("UnmarshalPublicKey returned error: %v", err)
+			}
+			if err := EqualKeys(pub, priv.Public()); err != nil {
+				t.Error(err)
+			}
+
+			block, err := ssh.MarshalPrivateKey(priv, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalPrivateKey(pem.EncodeToMemory(block), nil)
+			if err != nil {
+				t.Fatalf("UnmarshalPrivateKey returned error: %v", err)
+			}
+			assertSamePrivateKey(t, got, priv)
+
+			block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("hunter2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			encrypted := pem.EncodeToMemory(block)
+			got, err = UnmarshalPrivateKey(encrypted, StaticPasswordFunc([]byte("hunter2")))
+			if err != nil {
+				t.Fatalf("UnmarshalPrivateKey returned error: %v", err)
+			}
+			assertSamePrivateKey(t, got, priv)
+			if _, err := UnmarshalPrivateKey(encrypted, nil); err == nil {
+				t.Error("expected error without a passphrase")
+			}
+			if _, err := UnmarshalPrivateKey(encrypted, StaticPasswordFunc([]byte("wrong"))); err == nil {
+				t.Error("expected error with the wrong passphrase")
+			}
+		})
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	t.Parallel()
+	keys := generateTestKeys(t)
+	for name, priv := range keys {
+		name, priv := name, priv
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pubJWK, err := MarshalPublicKeyToJWK(priv.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, err := UnmarshalPublicKey(pubJWK)
+			if err != nil {
+				t.Fatalf("UnmarshalPublicKey returned error: %v", err)
+			}
+			if err := EqualKeys(pub, priv.Public()); err != nil {
+				t.Error(err)
+			}
+			if _, err := UnmarshalPrivateKey(pubJWK, nil); err == nil {
+				t.Error("expected error reading a private key from a public JWK")
+			}
+
+			privJWK, err := MarshalPrivateKeyToJWK(priv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalPrivateKey(privJWK, nil)
+			if err != nil {
+				t.Fatalf("UnmarshalPrivateKey returned error: %v", err)
+			}
+			assertSamePrivateKey(t, got, priv)
+			// a private JWK also contains the public key
+			if pub, err = UnmarshalPublicKey(privJWK); err != nil {
+				t.Fatal(err)
+			}
+			if err := EqualKeys(pub, priv.Public()); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	jwks, err := MarshalPublicKeysToJWKS(keys["ecdsa"].Public(), keys["ed25519"].Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubs, err := UnmarshalJWKSToPublicKeys(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pubs) != 2 || EqualKeys(pubs[0], keys["ecdsa"].Public()) != nil || EqualKeys(pubs[1], keys["ed25519"].Public()) != nil {
+		t.Errorf("unexpected keys from JWK set: %v", pubs)
+	}
+	if _, err := UnmarshalPublicKey(jwks); err == nil {
+		t.Error("expected error reading a single key from a JWK set with two keys")
+	}
+	single, err := MarshalPublicKeysToJWKS(keys["rsa"].Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := UnmarshalPublicKey(single); err != nil || EqualKeys(pub, keys["rsa"].Public()) != nil {
+		t.Errorf("unexpected key from JWK set with one key: %v, %v", pub, err)
+	}
+}
+
+func TestUnmarshalPublicKeyFormats(t *testing.T) {
+	t.Parallel()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBytes, err := MarshalPublicKeyToPEM(priv.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := UnmarshalPublicKey(pemBytes); err != nil || EqualKeys(pub, priv.Public()) != nil {
+		t.Errorf("unexpected key from PEM: %v, %v", pub, err)
+	}
+	privPEM, err := MarshalPrivateKeyToPEM(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := UnmarshalPrivateKey(privPEM, nil); err != nil {
+		t.Errorf("unexpected error reading PEM private key: %v", err)
+	} else {
+		assertSamePrivateKey(t, got, priv)
+	}
+
+	for _, invalid := range []string{"", "not a key", `{"kty":"oct","k":"c2VjcmV0"}`, "ssh-rsa !!!"} {
+		if _, err := UnmarshalPublicKey([]byte(invalid)); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestUnmarshalOpenSSHSecurityKeys(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPoint, err := ecPriv.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wire encodings from PROTOCOL.u2f in the OpenSSH sources
+	keys := map[string][]byte{
+		"sk-ssh-ed25519@openssh.com": ssh.Marshal(struct {
+			Name        string
+			Key         []byte
+			Application string
+		}{"sk-ssh-ed25519@openssh.com", edPub, "ssh:"}),
+		"sk-ecdsa-sha2-nistp256@openssh.com": ssh.Marshal(struct {
+			Name        string
+			Curve       string
+			Key         []byte
+			Application string
+		}{"sk-ecdsa-sha2-nistp256@openssh.com", "nistp256", ecPoint.Bytes(), "ssh:"}),
+	}
+	for keyType, wire := range keys {
+		line := keyType + " " + base64.StdEncoding.EncodeToString(wire)
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line)); err != nil {
+			t.Fatalf("invalid test key %s: %v", keyType, err)
+		}
+		if _, err := UnmarshalPublicKey([]byte(line)); err == nil {
+			t.Errorf("expected error for security key type %s", keyType)
+		}
+	}
+}
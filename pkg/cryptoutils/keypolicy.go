@@ -0,0 +1,403 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/strictyaml"
+	"github.com/titanous/rocacheck"
+)
+
+// KeyPolicy configures the checks made by ValidatePubKeyWithPolicy. The zero value rejects every key;
+// DefaultKeyPolicy returns the policy enforced by ValidatePubKey, which can be relaxed or tightened.
+type KeyPolicy struct {
+	// AllowRSA allows RSA keys
+	AllowRSA bool
+	// AllowECDSA allows ECDSA keys
+	AllowECDSA bool
+	// AllowEd25519 allows Ed25519 keys
+	AllowEd25519 bool
+
+	// RSAKeySizes lists the allowed RSA modulus sizes in bits. If empty, any size that is a
+	// multiple of 8 is allowed. Sizes must also be within MinRSAKeySize and MaxRSAKeySize.
+	RSAKeySizes []int
+	// MinRSAKeySize is the smallest allowed RSA modulus size in bits
+	MinRSAKeySize int
+	// MaxRSAKeySize is the largest allowed RSA modulus size in bits, or 0 for no maximum
+	MaxRSAKeySize int
+	// DeniedRSAKeySizes lists RSA modulus sizes which are rejected even if otherwise allowed
+	DeniedRSAKeySizes []int
+	// FermatRounds is the number of rounds of Fermat's factorization method attempted on RSA moduli,
+	// to detect keys whose prime factors are too close together. 0 disables the check.
+	FermatRounds int
+
+	// ECDSACurves lists the allowed ECDSA curves. Only P-256, P-384 and P-521 are supported.
+	ECDSACurves []elliptic.Curve
+
+	blockedMu sync.RWMutex
+	blocked   map[[sha256.Size]byte]bool
+}
+
+// DefaultKeyPolicy returns the policy enforced by ValidatePubKey:
+// * RSA
+//   - Size of key: 2048, 3072 or 4096
+//   - Exponent E = 65537 (Default exponent for OpenSSL and Golang)
+//   - Small primes check for modulus
+//   - Weak keys generated by Infineon hardware (see https://crocs.fi.muni.cz/public/papers/rsa_ccs17)
+//   - Key is easily factored with 100 rounds of Fermat's factorization method
+//
+// * EC
+//   - Curve is P-256, P-384 or P-521
+//   - Public key Q is not the identity element (Ø)
+//   - Public key Q's x and y are within [0, p-1]
+//   - Public key Q is on the curve
+//   - Public key Q's order matches the subgroups (nQ = Ø)
+//
+// * ED25519
+//   - Public key is a canonical encoding of a point on the curve
+//   - Public key is not the identity or another point of small order
+func DefaultKeyPolicy() *KeyPolicy {
+	return &KeyPolicy{
+		AllowRSA:      true,
+		AllowECDSA:    true,
+		AllowEd25519:  true,
+		RSAKeySizes:   []int{2048, 3072, 4096},
+		MinRSAKeySize: 2048,
+		MaxRSAKeySize: 4096,
+		FermatRounds:  100,
+		ECDSACurves:   []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()},
+	}
+}
+
+// LoadBlockedKeysFile adds the keys listed in a YAML file to those rejected by the policy. The file
+// uses the format of Boulder's blocked key file, listing SHA256 hashes of DER encoded
+// SubjectPublicKeyInfo structures, either base64 encoded under "blocked" or hex encoded under
+// "blockedHashesHex".
+func (p *KeyPolicy) LoadBlockedKeysFile(path string) error {
+	b, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	var list struct {
+		BlockedHashes    []string `yaml:"blocked"`
+		BlockedHashesHex []string `yaml:"blockedHashesHex"`
+	}
+	if err := strictyaml.Unmarshal(b, &list); err != nil {
+		return fmt.Errorf("parsing blocked keys file: %w", err)
+	}
+	if len(list.BlockedHashes) == 0 && len(list.BlockedHashesHex) == 0 {
+		return errors.New("blocked keys file contains no hashes")
+	}
+
+	hashes := make([][sha256.Size]byte, 0, len(list.BlockedHashes)+len(list.BlockedHashesHex))
+	add := func(h []byte, err error, encoded string) error {
+		if err != nil || len(h) != sha256.Size {
+			return fmt.Errorf("invalid SHA256 hash in blocked keys file: %q", encoded)
+		}
+		hashes = append(hashes, [sha256.Size]byte(h))
+		return nil
+	}
+	for _, encoded := range list.BlockedHashes {
+		h, err := base64.StdEncoding.DecodeString(encoded)
+		if err := add(h, err, encoded); err != nil {
+			return err
+		}
+	}
+	for _, encoded := range list.BlockedHashesHex {
+		h, err := hex.DecodeString(encoded)
+		if err := add(h, err, encoded); err != nil {
+			return err
+		}
+	}
+
+	p.blockedMu.Lock()
+	defer p.blockedMu.Unlock()
+	if p.blocked == nil {
+		p.blocked = make(map[[sha256.Size]byte]bool, len(hashes))
+	}
+	for _, h := range hashes {
+		p.blocked[h] = true
+	}
+	return nil
+}
+
+// isBlocked reports whether the SHA256 hash of pub's SubjectPublicKeyInfo was loaded with LoadBlockedKeysFile
+func (p *KeyPolicy) isBlocked(pub crypto.PublicKey) (bool, error) {
+	p.blockedMu.RLock()
+	defer p.blockedMu.RUnlock()
+	if len(p.blocked) == 0 {
+		return false, nil
+	}
+	der, err := MarshalPublicKeyToDER(pub)
+	if err != nil {
+		return false, err
+	}
+	return p.blocked[sha256.Sum256(der)], nil
+}
+
+// ValidatePubKeyWithPolicy validates the parameters of an RSA, ECDSA, or ED25519 public key against
+// policy. If policy is nil, DefaultKeyPolicy is used. Errors describing a key rejected by the policy
+// wrap goodkey.ErrBadKey.
+func ValidatePubKeyWithPolicy(pub crypto.PublicKey, policy *KeyPolicy) error {
+	if policy == nil {
+		policy = DefaultKeyPolicy()
+	}
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		if !policy.AllowRSA {
+			return badKey("RSA keys are not allowed")
+		}
+	case *ecdsa.PublicKey:
+		if !policy.AllowECDSA {
+			return badKey("ECDSA keys are not allowed")
+		}
+	case ed25519.PublicKey:
+		if !policy.AllowEd25519 {
+			return badKey("ED25519 keys are not allowed")
+		}
+	default:
+		return errors.New("unsupported public key type")
+	}
+	if err := policy.checkBlocked(pub); err != nil {
+		return err
+	}
+
+	switch pk := pub.(type) {
+	case *rsa.PublicKey:
+		return policy.validateRSAKey(pk)
+	case *ecdsa.PublicKey:
+		return policy.validateECDSAKey(pk)
+	case ed25519.PublicKey:
+		return validateEd25519Key(pk)
+	}
+	return errors.New("unsupported public key type")
+}
+
+func badKey(format string, args ...interface{}) error {
+	return fmt.Errorf("%w%s", goodkey.ErrBadKey, fmt.Errorf(format, args...))
+}
+
+func (p *KeyPolicy) checkBlocked(pub crypto.PublicKey) error {
+	blocked, err := p.isBlocked(pub)
+	if err != nil {
+		return badKey("unable to marshal public key: %w", err)
+	}
+	if blocked {
+		return badKey("public key is forbidden")
+	}
+	return nil
+}
+
+func (p *KeyPolicy) allowsRSAKeySize(size int) bool {
+	if size < p.MinRSAKeySize || (p.MaxRSAKeySize != 0 && size > p.MaxRSAKeySize) {
+		return false
+	}
+	if slices.Contains(p.DeniedRSAKeySizes, size) {
+		return false
+	}
+	if len(p.RSAKeySizes) > 0 {
+		return slices.Contains(p.RSAKeySizes, size)
+	}
+	return size%8 == 0
+}
+
+func (p *KeyPolicy) validateRSAKey(pub *rsa.PublicKey) error {
+	if pub == nil || pub.N == nil {
+		return badKey("key modulus must not be nil")
+	}
+	if p.FermatRounds < 0 {
+		return errors.New("invalid key policy: Fermat rounds must not be negative")
+	}
+	if size := pub.N.BitLen(); !p.allowsRSAKeySize(size) {
+		return badKey("key size not supported: %d", size)
+	}
+	// Rather than support arbitrary exponents, which significantly increases
+	// the size of the key space we allow, we restrict E to the defaults that
+	// OpenSSL and Golang use
+	if pub.E != 65537 {
+		return badKey("key exponent must be 65537")
+	}
+	// The modulus SHOULD also have the following characteristics: an odd
+	// number, not the power of a prime, and have no factors smaller than 752.
+	// See NIST SP 800-89, section 5.3.3
+	if pub.N.Bit(0) == 0 || new(big.Int).GCD(nil, nil, pub.N, smallPrimesProduct()).Cmp(big.NewInt(1)) != 0 {
+		return badKey("key divisible by small prime")
+	}
+	if rocacheck.IsWeak(pub) {
+		return badKey("key generated by vulnerable Infineon-based hardware")
+	}
+	if err := checkPrimeFactorsTooClose(pub.N, p.FermatRounds); err != nil {
+		return badKey("key generated with factors too close together: %w", err)
+	}
+	return nil
+}
+
+// smallPrimesProduct returns the product of all primes below 752
+var smallPrimesProduct = sync.OnceValue(func() *big.Int {
+	const limit = 752
+	product := big.NewInt(1)
+	composite := make([]bool, limit)
+	for i := 2; i < limit; i++ {
+		if composite[i] {
+			continue
+		}
+		product.Mul(product, big.NewInt(int64(i)))
+		for j := i * i; j < limit; j += i {
+			composite[j] = true
+		}
+	}
+	return product
+})
+
+// checkPrimeFactorsTooClose attempts to factor n with the given number of rounds of Fermat's
+// factorization method, starting from a = ceil(sqrt(n)) and searching for a such that
+// a^2 - n = b^2, in which case n = (a+b)(a-b)
+func checkPrimeFactorsTooClose(n *big.Int, rounds int) error {
+	one := big.NewInt(1)
+	a := new(big.Int).Sqrt(n)
+	a.Add(a, one)
+	b2 := new(big.Int).Mul(a, a)
+	b2.Sub(b2, n)
+	b := new(big.Int)
+	bb := new(big.Int)
+	for i := 0; i < rounds; i++ {
+		b.Sqrt(b2)
+		if bb.Mul(b, b).Cmp(b2) == 0 {
+			p := new(big.Int).Add(a, b)
+			q := new(big.Int).Sub(a, b)
+			return fmt.Errorf("public modulus n = pq factored into p: %s; q: %s", p, q)
+		}
+		a.Add(a, one)
+		b2.Mul(a, a).Sub(b2, n)
+	}
+	return nil
+}
+
+func (p *KeyPolicy) validateECDSAKey(pub *ecdsa.PublicKey) error {
+	if pub == nil || pub.Curve == nil {
+		return badKey("ECDSA curve must not be nil")
+	}
+	name := pub.Curve.Params().Name
+	allowed := slices.ContainsFunc(p.ECDSACurves, func(c elliptic.Curve) bool {
+		return c != nil && c.Params().Name == name
+	})
+	if !allowed {
+		return badKey("ECDSA curve %s not allowed", name)
+	}
+
+	// goodkey only needs to allow the key's own curve, as the policy's curves were checked above
+	allowedKeys := &goodkey.AllowedKeys{
+		ECDSAP256: name == elliptic.P256().Params().Name,
+		ECDSAP384: name == elliptic.P384().Params().Name,
+		ECDSAP521: name == elliptic.P521().Params().Name,
+	}
+	gp, err := goodkey.NewPolicy(&goodkey.Config{AllowedKeys: allowedKeys}, nil)
+	if err != nil {
+		// Should not occur, only chances to return errors are if fermat rounds
+		// are <0 or when loading blocked/weak keys from disk (not used here)
+		return errors.New("unable to initialize key policy")
+	}
+	// ctx is unused
+	return gp.GoodKey(context.Background(), pub)
+}
+
+var (
+	// ed25519P is the order of the field of Curve25519, 2^255 - 19
+	ed25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	// ed25519D is the constant d of the curve -x^2 + y^2 = 1 + d*x^2*y^2, -121665/121666 mod p
+	ed25519D = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), ed25519P)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, ed25519P)
+	}()
+	// ed25519SmallOrderY lists the y-coordinates of the points of small order (dividing the
+	// cofactor 8). 1 is the identity, p-1 has order 2, 0 has order 4, and the remaining two
+	// values are the points of order 8.
+	ed25519SmallOrderY = func() []*big.Int {
+		p := ed25519P
+		y8, _ := new(big.Int).SetString("7a03ac9277fdc74ec6cc392cfa53202a0f67100d760b3cba4fd84d3d706a17c7", 16)
+		return []*big.Int{
+			big.NewInt(1),
+			new(big.Int).Sub(p, big.NewInt(1)),
+			big.NewInt(0),
+			y8,
+			new(big.Int).Sub(p, y8),
+		}
+	}()
+)
+
+// validateEd25519Key checks that pub is the canonical encoding of a point on the curve which is
+// neither the identity nor another point of small order, as signatures over such keys can be
+// forged or verify for many messages
+func validateEd25519Key(pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return badKey("ED25519 public key has invalid length %d", len(pub))
+	}
+	// the key is the little-endian y-coordinate, with the sign of x in the most significant bit
+	le := slices.Clone(pub)
+	xNegative := le[31]&0x80 != 0
+	le[31] &= 0x7f
+	slices.Reverse(le)
+	y := new(big.Int).SetBytes(le)
+	if y.Cmp(ed25519P) >= 0 {
+		return badKey("ED25519 public key is not canonically encoded")
+	}
+
+	for _, smallOrder := range ed25519SmallOrderY {
+		if y.Cmp(smallOrder) != 0 {
+			continue
+		}
+		if y.Cmp(big.NewInt(1)) == 0 {
+			return badKey("ED25519 public key is the identity point")
+		}
+		return badKey("ED25519 public key has small order")
+	}
+
+	// x^2 = (y^2 - 1) / (d*y^2 + 1) must have a square root for the point to be on the curve
+	y2 := new(big.Int).Mul(y, y)
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	v := new(big.Int).Mul(ed25519D, y2)
+	v.Add(v, big.NewInt(1)).Mod(v, ed25519P)
+	x2 := new(big.Int).ModInverse(v, ed25519P)
+	x2.Mul(x2, u).Mod(x2, ed25519P)
+	if x2.Sign() == 0 {
+		if xNegative {
+			return badKey("ED25519 public key is not canonically encoded")
+		}
+		return nil
+	}
+	if new(big.Int).ModSqrt(x2, ed25519P) == nil {
+		return badKey("ED25519 public key is not a point on the curve")
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/letsencrypt/boulder/goodkey"
+)
+
+func TestKeyPolicyAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey failed: %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey failed: %v", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pub     crypto.PublicKey
+		disable func(*KeyPolicy)
+	}{
+		{"rsa", rsaKey.Public(), func(p *KeyPolicy) { p.AllowRSA = false }},
+		{"ecdsa", ecdsaKey.Public(), func(p *KeyPolicy) { p.AllowECDSA = false }},
+		{"ed25519", edPub, func(p *KeyPolicy) { p.AllowEd25519 = false }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidatePubKeyWithPolicy(tc.pub, nil); err != nil {
+				t.Errorf("unexpected error with default policy: %v", err)
+			}
+			policy := DefaultKeyPolicy()
+			tc.disable(policy)
+			if err := ValidatePubKeyWithPolicy(tc.pub, policy); err == nil || !errors.Is(err, goodkey.ErrBadKey) {
+				t.Errorf("expected key to be rejected, got %v", err)
+			}
+			if err := ValidatePubKeyWithPolicy(tc.pub, &KeyPolicy{}); err == nil {
+				t.Error("expected zero policy to reject key")
+			}
+		})
+	}
+}
+
+func TestKeyPolicyRSAKeySizes(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey failed: %v", err)
+	}
+	pub := priv.Public()
+
+	policy := DefaultKeyPolicy()
+	if err := ValidatePubKeyWithPolicy(pub, policy); err == nil || err.Error() != "key size not supported: 1024" {
+		t.Errorf("expected rsa key size not supported, got %v", err)
+	}
+
+	// any multiple of 8 within the range is allowed without an explicit list of sizes
+	policy.RSAKeySizes = nil
+	policy.MinRSAKeySize = 1024
+	if err := ValidatePubKeyWithPolicy(pub, policy); err != nil {
+		t.Errorf("unexpected error with minimum size 1024: %v", err)
+	}
+	policy.DeniedRSAKeySizes = []int{1024}
+	if err := ValidatePubKeyWithPolicy(pub, policy); err == nil || err.Error() != "key size not supported: 1024" {
+		t.Errorf("expected denied rsa key size to be rejected, got %v", err)
+	}
+	policy.DeniedRSAKeySizes = nil
+	policy.MinRSAKeySize = 1032
+	if err := ValidatePubKeyWithPolicy(pub, policy); err == nil || err.Error() != "key size not supported: 1024" {
+		t.Errorf("expected rsa key size below minimum to be rejected, got %v", err)
+	}
+	policy.MinRSAKeySize = 0
+	policy.MaxRSAKeySize = 1016
+	if err := ValidatePubKeyWithPolicy(pub, policy); err == nil || err.Error() != "key size not supported: 1024" {
+		t.Errorf("expected rsa key size above maximum to be rejected, got %v", err)
+	}
+}
+
+// rsaPolicyForSize returns a policy accepting only RSA keys with a modulus of size bits
+func rsaPolicyForSize(size int) *KeyPolicy {
+	policy := DefaultKeyPolicy()
+	policy.RSAKeySizes = []int{size}
+	policy.MinRSAKeySize = 0
+	policy.MaxRSAKeySize = 0
+	return policy
+}
+
+func TestKeyPolicyRSAModulus(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey failed: %v", err)
+	}
+	policy := rsaPolicyForSize(1024)
+	if err := ValidatePubKeyWithPolicy(priv.Public(), policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	badExponent := &rsa.PublicKey{N: priv.N, E: 3}
+	if err := ValidatePubKeyWithPolicy(badExponent, policy); err == nil || err.Error() != "key exponent must be 65537" {
+		t.Errorf("expected bad exponent to be rejected, got %v", err)
+	}
+
+	// a modulus with a small factor
+	p, err := rand.Prime(rand.Reader, 1022)
+	if err != nil {
+		t.Fatalf("rand.Prime failed: %v", err)
+	}
+	n := new(big.Int).Mul(p, big.NewInt(751))
+	err = ValidatePubKeyWithPolicy(&rsa.PublicKey{N: n, E: 65537}, rsaPolicyForSize(n.BitLen()))
+	if err == nil || err.Error() != "key divisible by small prime" {
+		t.Errorf("expected small prime to be rejected, got %v", err)
+	}
+
+	// a modulus whose factors are too close together
+	q := new(big.Int).Add(p, big.NewInt(2))
+	for !q.ProbablyPrime(20) {
+		q.Add(q, big.NewInt(2))
+	}
+	n = new(big.Int).Mul(p, q)
+	policy = rsaPolicyForSize(n.BitLen())
+	err = ValidatePubKeyWithPolicy(&rsa.PublicKey{N: n, E: 65537}, policy)
+	if err == nil || !strings.HasPrefix(err.Error(), "key generated with factors too close together") {
+		t.Errorf("expected close factors to be rejected, got %v", err)
+	}
+	policy.FermatRounds = 0
+	if err := ValidatePubKeyWithPolicy(&rsa.PublicKey{N: n, E: 65537}, policy); err != nil {
+		t.Errorf("unexpected error with Fermat check disabled: %v", err)
+	}
+	policy.FermatRounds = -1
+	if err := ValidatePubKeyWithPolicy(&rsa.PublicKey{N: n, E: 65537}, policy); err == nil {
+		t.Error("expected error with negative Fermat rounds")
+	}
+}
+
+func TestKeyPolicyECDSACurves(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey failed: %v", err)
+	}
+	policy := DefaultKeyPolicy()
+	policy.ECDSACurves = []elliptic.Curve{elliptic.P256()}
+	if err := ValidatePubKeyWithPolicy(priv.Public(), policy); err == nil || err.Error() != "ECDSA curve P-384 not allowed" {
+		t.Errorf("expected curve to be rejected, got %v", err)
+	}
+	policy.ECDSACurves = append(policy.ECDSACurves, elliptic.P384())
+	if err := ValidatePubKeyWithPolicy(priv.Public(), policy); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// the point is still checked once the curve is allowed
+	priv.X.Neg(priv.X)
+	if err := ValidatePubKeyWithPolicy(priv.Public(), policy); err == nil {
+		t.Error("expected invalid point to be rejected")
+	}
+}
+
+func TestKeyPolicyEd25519(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("ed25519.GenerateKey failed: %v", err)
+		}
+		if err := ValidatePubKey(pub); err != nil {
+			t.Fatalf("unexpected error validating %x: %v", pub, err)
+		}
+	}
+
+	// encodeY returns the little-endian encoding of a y-coordinate given as big-endian hex
+	encodeY := func(hexY string, xNegative bool) ed25519.PublicKey {
+		b, err := hex.DecodeString(fmt.Sprintf("%064s", hexY))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := make(ed25519.PublicKey, ed25519.PublicKeySize)
+		for i := range b {
+			pub[i] = b[len(b)-1-i]
+		}
+		if xNegative {
+			pub[31] |= 0x80
+		}
+		return pub
+	}
+	p := "7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed"
+	order8 := "7a03ac9277fdc74ec6cc392cfa53202a0f67100d760b3cba4fd84d3d706a17c7"
+
+	tests := []struct {
+		name string
+		pub  ed25519.PublicKey
+		err  string
+	}{
+		{"identity", encodeY("1", false), "ED25519 public key is the identity point"},
+		{"order 2", encodeY("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffec", false), "ED25519 public key has small order"},
+		{"order 4", encodeY("0", false), "ED25519 public key has small order"},
+		{"order 4 negative", encodeY("0", true), "ED25519 public key has small order"},
+		{"order 8", encodeY(order8, false), "ED25519 public key has small order"},
+		{"order 8 negative", encodeY(order8, true), "ED25519 public key has small order"},
+		{"order 8 opposite", encodeY("05fc536d880238b13933c6d305acdfd5f098eff289f4c345b027b2c28f95e826", false), "ED25519 public key has small order"},
+		{"non-canonical identity", encodeY("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffee", false), "ED25519 public key is not canonically encoded"},
+		{"non-canonical zero", encodeY(p, false), "ED25519 public key is not canonically encoded"},
+		{"not on curve", encodeY("2", false), "ED25519 public key is not a point on the curve"},
+		{"short", ed25519.PublicKey{1, 2, 3}, "ED25519 public key has invalid length 3"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePubKey(tc.pub)
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("expected %q, got %v", tc.err, err)
+			}
+			if !errors.Is(err, goodkey.ErrBadKey) {
+				t.Errorf("expected error to wrap goodkey.ErrBadKey, got %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyPolicyBlockedKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey failed: %v", err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey failed: %v", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey failed: %v", err)
+	}
+	hash := func(pub crypto.PublicKey) []byte {
+		der, err := MarshalPublicKeyToDER(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha256.Sum256(der)
+		return h[:]
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "blocked.yaml")
+	contents := fmt.Sprintf("blocked:\n  - %s\nblockedHashesHex:\n  - %s\n",
+		base64.StdEncoding.EncodeToString(hash(rsaKey.Public())), hex.EncodeToString(hash(edPub)))
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := DefaultKeyPolicy()
+	if err := policy.LoadBlockedKeysFile(path); err != nil {
+		t.Fatalf("unexpected error loading blocked keys: %v", err)
+	}
+	for _, pub := range []crypto.PublicKey{rsaKey.Public(), edPub} {
+		if err := ValidatePubKeyWithPolicy(pub, policy); err == nil || err.Error() != "public key is forbidden" {
+			t.Errorf("expected blocked key to be rejected, got %v", err)
+		}
+	}
+	if err := ValidatePubKeyWithPolicy(ecdsaKey.Public(), policy); err != nil {
+		t.Errorf("unexpected error for key that is not blocked: %v", err)
+	}
+	if err := ValidatePubKey(rsaKey.Public()); err != nil {
+		t.Errorf("blocked keys must not affect the default policy: %v", err)
+	}
+
+	invalid := map[string]string{
+		"empty":      "blocked: []\n",
+		"unknown":    "blockedKeys:\n  - abc\n",
+		"bad base64": "blocked:\n  - '!!!'\n",
+		"short hex":  "blockedHashesHex:\n  - abcd\n",
+	}
+	for name, contents := range invalid {
+		path := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".yaml")
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := DefaultKeyPolicy().LoadBlockedKeysFile(path); err == nil {
+			t.Errorf("%s: expected error loading blocked keys", name)
+		}
+	}
+	if err := DefaultKeyPolicy().LoadBlockedKeysFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected error loading missing file")
+	}
+}
@@ -16,17 +16,39 @@
 package cryptoutils
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"strings"
 
 	"golang.org/x/term"
 )
 
-// PassFunc is a type of function that takes a boolean (representing whether confirmation is desired) and returns the password as read, along with an error if one occurred
+// PassFunc is a type of function that takes a boolean (representing whether confirmation is desired) and returns the password as read, along with an error if one occurred.
 type PassFunc func(bool) ([]byte, error)
 
+// ErrPasswordUnavailable is returned by a PassFunc whose source has no password, such as an unset environment
+// variable. ChainPasswordFuncs moves on to the next source when it is returned.
+var ErrPasswordUnavailable = errors.New("password unavailable")
+
+// ZeroPassword overwrites pw with zeros once it is no longer needed. The functions in this package only zero
+// their own copies of the passwords returned by a PassFunc, so a PassFunc can return the same slice on every call.
+func ZeroPassword(pw []byte) {
+	clear(pw)
+}
+
+// readPassword returns a copy of the password returned by pf, which the caller zeroes once it is no longer needed
+func readPassword(pf PassFunc, confirm bool) ([]byte, error) {
+	pw, err := pf(confirm)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(pw), nil
+}
+
 // Read is for fuzzing
 var Read = readPasswordFn
 
@@ -54,10 +76,10 @@ func readPasswordFn() func() ([]byte, error) {
 	}
 }
 
-// StaticPasswordFunc returns a PassFunc which returns the provided password.
+// StaticPasswordFunc returns a PassFunc which returns a copy of the provided password.
 func StaticPasswordFunc(pw []byte) PassFunc {
 	return func(bool) ([]byte, error) {
-		return pw, nil
+		return bytes.Clone(pw), nil
 	}
 }
 
@@ -83,12 +105,113 @@ func GetPasswordFromStdIn(confirm bool) ([]byte, error) {
 	fmt.Fprint(os.Stderr, "Enter again: ")
 	pw2, err := read()
 	fmt.Fprintln(os.Stderr)
+	defer ZeroPassword(pw2)
 	if err != nil {
+		ZeroPassword(pw1)
 		return nil, err
 	}
 
-	if string(pw1) != string(pw2) {
+	if !bytes.Equal(pw1, pw2) {
+		ZeroPassword(pw1)
 		return nil, errors.New("passwords do not match")
 	}
 	return pw1, nil
 }
+
+// PasswordFromEnv returns a PassFunc which reads the password from the environment variable name. It returns
+// an error wrapping ErrPasswordUnavailable if the variable is not set.
+func PasswordFromEnv(name string) PassFunc {
+	return func(bool) ([]byte, error) {
+		pw, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not set", ErrPasswordUnavailable, name)
+		}
+		return []byte(pw), nil
+	}
+}
+
+// PasswordFromFile returns a PassFunc which reads the password from the file at path, without a single
+// trailing newline. It returns an error wrapping ErrPasswordUnavailable if the file does not exist.
+func PasswordFromFile(path string) PassFunc {
+	return func(bool) ([]byte, error) {
+		pw, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %v", ErrPasswordUnavailable, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading password file: %w", err)
+		}
+		return trimNewline(pw), nil
+	}
+}
+
+// PasswordFromFD returns a PassFunc which reads the password from the open file descriptor fd until EOF,
+// without a single trailing newline, as with gpg's --passphrase-fd. fd is closed after it is read, so the
+// PassFunc can only be called once.
+func PasswordFromFD(fd uintptr) PassFunc {
+	return func(bool) ([]byte, error) {
+		f := os.NewFile(fd, fmt.Sprintf("fd%d", fd))
+		if f == nil {
+			return nil, fmt.Errorf("invalid file descriptor %d", fd)
+		}
+		defer f.Close()
+		pw, err := io.ReadAll(f)
+		if err != nil {
+			ZeroPassword(pw)
+			return nil, fmt.Errorf("reading password from file descriptor %d: %w", fd, err)
+		}
+		return trimNewline(pw), nil
+	}
+}
+
+// PasswordFromCommand returns a PassFunc which runs the command name with args and reads the password from
+// its standard output, without a single trailing newline. This supports password managers with a command
+// line interface, such as "pass show sigstore/key". The command's standard error is included in the error
+// if it fails.
+func PasswordFromCommand(name string, args ...string) PassFunc {
+	return func(bool) ([]byte, error) {
+		var stderr bytes.Buffer
+		cmd := exec.Command(name, args...)
+		cmd.Stderr = &stderr
+		pw, err := cmd.Output()
+		if err != nil {
+			ZeroPassword(pw)
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("running password command %s: %w: %s", name, err, msg)
+			}
+			return nil, fmt.Errorf("running password command %s: %w", name, err)
+		}
+		return trimNewline(pw), nil
+	}
+}
+
+// ChainPasswordFuncs returns a PassFunc which tries each of pfs in order, returning the first password found.
+// A PassFunc which returns an error wrapping ErrPasswordUnavailable is skipped; any other error is returned
+// immediately. If no PassFunc has a password, the returned error wraps ErrPasswordUnavailable.
+func ChainPasswordFuncs(pfs ...PassFunc) PassFunc {
+	return func(confirm bool) ([]byte, error) {
+		var errs []error
+		for _, pf := range pfs {
+			pw, err := pf(confirm)
+			if err == nil {
+				return pw, nil
+			}
+			if !errors.Is(err, ErrPasswordUnavailable) {
+				return nil, err
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("%w: no password sources", ErrPasswordUnavailable)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+// trimNewline removes a single trailing "\n" or "\r\n" from pw, as written by echo or a text editor
+func trimNewline(pw []byte) []byte {
+	if bytes.HasSuffix(pw, []byte("\r\n")) {
+		return pw[:len(pw)-2]
+	}
+	return bytes.TrimSuffix(pw, []byte("\n"))
+}
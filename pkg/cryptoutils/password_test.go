@@ -0,0 +1,161 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const passwordCommandEnv = "SIGSTORE_TEST_PASSWORD_COMMAND"
+
+// TestPasswordCommandHelper is run as the password command by TestPasswordFromCommand
+func TestPasswordCommandHelper(_ *testing.T) {
+	switch os.Getenv(passwordCommandEnv) {
+	case "print":
+		fmt.Print("hunter2\n")
+		os.Exit(0)
+	case "fail":
+		fmt.Fprint(os.Stderr, "vault is locked\n")
+		os.Exit(1)
+	}
+}
+
+func TestPasswordFromCommand(t *testing.T) {
+	pf := PasswordFromCommand(os.Args[0], "-test.run=^TestPasswordCommandHelper$")
+
+	t.Setenv(passwordCommandEnv, "print")
+	pw, err := pf(false)
+	if err != nil || string(pw) != "hunter2" {
+		t.Fatalf("expected password hunter2, got %q, %v", pw, err)
+	}
+
+	t.Setenv(passwordCommandEnv, "fail")
+	_, err = pf(false)
+	if err == nil || !strings.Contains(err.Error(), "vault is locked") {
+		t.Fatalf("expected error with command stderr, got %v", err)
+	}
+	if errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatal("a failing command should not be skipped by ChainPasswordFuncs")
+	}
+}
+
+func TestPasswordFromEnv(t *testing.T) {
+	const name = "SIGSTORE_TEST_PASSWORD"
+	pf := PasswordFromEnv(name)
+	if _, err := pf(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable, got %v", err)
+	}
+	// an empty password is still a password
+	t.Setenv(name, "")
+	if pw, err := pf(false); err != nil || len(pw) != 0 {
+		t.Fatalf("expected empty password, got %q, %v", pw, err)
+	}
+	t.Setenv(name, "hunter2")
+	if pw, err := pf(false); err != nil || string(pw) != "hunter2" {
+		t.Fatalf("expected password hunter2, got %q, %v", pw, err)
+	}
+}
+
+func TestPasswordFromFile(t *testing.T) {
+	dir := t.TempDir()
+	for content, expected := range map[string]string{
+		"hunter2":       "hunter2",
+		"hunter2\n":     "hunter2",
+		"hunter2\r\n":   "hunter2",
+		"hunter2\n\n":   "hunter2\n",
+		" hunter2 \n":   " hunter2 ",
+		"hunter2\rmore": "hunter2\rmore",
+	} {
+		path := filepath.Join(dir, "password")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		pw, err := PasswordFromFile(path)(false)
+		if err != nil || string(pw) != expected {
+			t.Errorf("%q: expected password %q, got %q, %v", content, expected, pw, err)
+		}
+	}
+	if _, err := PasswordFromFile(filepath.Join(dir, "missing"))(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable, got %v", err)
+	}
+	if _, err := PasswordFromFile(dir)(false); err == nil || errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected error reading a directory, got %v", err)
+	}
+}
+
+func TestPasswordFromFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString("hunter2\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	pw, err := PasswordFromFD(r.Fd())(false)
+	if err != nil || string(pw) != "hunter2" {
+		t.Fatalf("expected password hunter2, got %q, %v", pw, err)
+	}
+}
+
+func TestChainPasswordFuncs(t *testing.T) {
+	failing := func(bool) ([]byte, error) { return nil, errors.New("keyring is broken") }
+	unset := PasswordFromEnv("SIGSTORE_TEST_UNSET_PASSWORD")
+
+	pw, err := ChainPasswordFuncs(unset, StaticPasswordFunc([]byte("hunter2")), failing)(false)
+	if err != nil || string(pw) != "hunter2" {
+		t.Fatalf("expected password hunter2, got %q, %v", pw, err)
+	}
+	if _, err := ChainPasswordFuncs(unset, failing, StaticPasswordFunc([]byte("hunter2")))(false); err == nil || errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected error from failing source, got %v", err)
+	}
+	_, err = ChainPasswordFuncs(unset, PasswordFromFile(filepath.Join(t.TempDir(), "missing")))(false)
+	if !errors.Is(err, ErrPasswordUnavailable) || !strings.Contains(err.Error(), "SIGSTORE_TEST_UNSET_PASSWORD") {
+		t.Fatalf("expected ErrPasswordUnavailable naming each source, got %v", err)
+	}
+	if _, err := ChainPasswordFuncs()(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable, got %v", err)
+	}
+}
+
+func TestPassFuncReturningSameSlice(t *testing.T) {
+	// only copies of the returned password are zeroed, so a PassFunc can return a captured slice on every call
+	pw := []byte("hunter2")
+	pf := func(bool) ([]byte, error) {
+		return pw, nil
+	}
+	privPEM, _, err := GeneratePEMEncodedECDSAKeyPair(elliptic.P256(), pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := UnmarshalPEMToPrivateKey(privPEM, pf); err != nil {
+			t.Fatalf("unexpected error decrypting private key: %v", err)
+		}
+	}
+	if string(pw) != "hunter2" {
+		t.Fatalf("expected password to be unchanged, got %q", pw)
+	}
+	if _, err := UnmarshalPEMToPrivateKey(privPEM, StaticPasswordFunc([]byte("hunter2"))); err != nil {
+		t.Fatalf("expected the key to be encrypted with the password, got %v", err)
+	}
+}
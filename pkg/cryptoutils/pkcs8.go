@@ -0,0 +1,449 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptedPrivateKeyPEMType is the string "ENCRYPTED PRIVATE KEY" used for PKCS#8 private keys encrypted with PBES2
+const EncryptedPrivateKeyPEMType PEMType = "ENCRYPTED PRIVATE KEY"
+
+// PKCS8Cipher is the content encryption scheme of an encrypted PKCS#8 private key
+type PKCS8Cipher string
+
+// PKCS8KDF is the password-based key derivation function of an encrypted PKCS#8 private key
+type PKCS8KDF string
+
+// nolint:revive
+const (
+	AES128CBC PKCS8Cipher = "aes-128-cbc"
+	AES192CBC PKCS8Cipher = "aes-192-cbc"
+	AES256CBC PKCS8Cipher = "aes-256-cbc"
+	AES128GCM PKCS8Cipher = "aes-128-gcm"
+	AES192GCM PKCS8Cipher = "aes-192-gcm"
+	AES256GCM PKCS8Cipher = "aes-256-gcm"
+
+	PBKDF2WithHMACSHA256 PKCS8KDF = "pbkdf2-hmac-sha256"
+	PBKDF2WithHMACSHA512 PKCS8KDF = "pbkdf2-hmac-sha512"
+	Scrypt               PKCS8KDF = "scrypt"
+)
+
+// PKCS8EncryptionOptions configures MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts. Zero values select the defaults.
+type PKCS8EncryptionOptions struct {
+	// Cipher defaults to AES256CBC, which is also OpenSSL's default
+	Cipher PKCS8Cipher
+	// KDF defaults to PBKDF2WithHMACSHA256
+	KDF PKCS8KDF
+	// PBKDF2Iterations defaults to 600000
+	PBKDF2Iterations int
+	// ScryptN, ScryptR and ScryptP default to 32768, 8 and 1. N must be a power of two, r at most 32, p at most 16,
+	// and scrypt must need at most 256 MiB (128·N·r bytes), so that keys can be decrypted again.
+	ScryptN, ScryptR, ScryptP int
+}
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+)
+
+type pkcs8CipherInfo struct {
+	oid     asn1.ObjectIdentifier
+	keySize int
+	gcm     bool
+}
+
+var pkcs8Ciphers = map[PKCS8Cipher]pkcs8CipherInfo{
+	AES128CBC: {oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}, keySize: 16},
+	AES192CBC: {oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}, keySize: 24},
+	AES256CBC: {oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}, keySize: 32},
+	AES128GCM: {oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}, keySize: 16, gcm: true},
+	AES192GCM: {oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 26}, keySize: 24, gcm: true},
+	AES256GCM: {oid: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}, keySize: 32, gcm: true},
+}
+
+// encryptedPrivateKeyInfo is defined in RFC 5958, section 3
+type encryptedPrivateKeyInfo struct {
+	EncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+// pbes2Params is defined in RFC 8018, appendix A.4
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params is defined in RFC 8018, appendix A.2
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// scryptParams is defined in RFC 7914, section 7.1
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// gcmParams is defined in RFC 5084, section 3.2
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12,optional"`
+}
+
+// maxPBKDF2Iterations and the scrypt limits bound the work an untrusted key file can demand
+const (
+	maxPBKDF2Iterations = 10_000_000
+	maxScryptBlockSize  = 32
+	maxScryptParallel   = 16
+	// maxScryptMemory bounds the 128·N·r bytes scrypt allocates
+	maxScryptMemory = 256 << 20
+)
+
+// validateScryptParams checks that the scrypt parameters are valid and within the limits
+func validateScryptParams(n, r, p int) error {
+	if n <= 1 || n&(n-1) != 0 {
+		return fmt.Errorf("scrypt cost parameter %d must be a power of two greater than 1", n)
+	}
+	if r <= 0 || r > maxScryptBlockSize {
+		return fmt.Errorf("invalid scrypt block size %d", r)
+	}
+	if p <= 0 || p > maxScryptParallel {
+		return fmt.Errorf("invalid scrypt parallelization parameter %d", p)
+	}
+	if int64(128)*int64(n)*int64(r) > maxScryptMemory {
+		return fmt.Errorf("scrypt parameters N=%d, r=%d, p=%d require too much memory", n, r, p)
+	}
+	return nil
+}
+
+// decryptPKCS8 decrypts the DER encoded EncryptedPrivateKeyInfo in der and returns the PKCS#8 PrivateKeyInfo
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("parsing encrypted private key: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after encrypted private key")
+	}
+	if !info.EncryptionAlgorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption algorithm %v, only PBES2 is supported", info.EncryptionAlgorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.EncryptionAlgorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("parsing PBES2 parameters: %w", err)
+	}
+
+	var cipherInfo *pkcs8CipherInfo
+	for _, c := range pkcs8Ciphers {
+		if c.oid.Equal(params.EncryptionScheme.Algorithm) {
+			c := c
+			cipherInfo = &c
+			break
+		}
+	}
+	if cipherInfo == nil {
+		return nil, fmt.Errorf("unsupported PBES2 encryption scheme %v", params.EncryptionScheme.Algorithm)
+	}
+
+	key, err := deriveKey(params.KeyDerivationFunc, password, cipherInfo.keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if cipherInfo.gcm {
+		var gp gcmParams
+		if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &gp); err != nil {
+			return nil, fmt.Errorf("parsing GCM parameters: %w", err)
+		}
+		if len(gp.Nonce) == 0 {
+			return nil, errors.New("GCM nonce must not be empty")
+		}
+		aead, err := newGCM(block, len(gp.Nonce), gp.ICVLen)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := aead.Open(nil, gp.Nonce, info.EncryptedData, nil)
+		if err != nil {
+			return nil, errors.New("decryption failed")
+		}
+		return plaintext, nil
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("parsing CBC parameters: %w", err)
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("invalid CBC IV length %d", len(iv))
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted data length")
+	}
+	plaintext := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, data)
+	// a wrong password almost always results in invalid padding
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > block.BlockSize() || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("decryption failed")
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// newGCM returns an AEAD for the nonce and tag sizes of the GCM parameters. The standard
+// library cannot combine a non-standard nonce size with a non-standard tag size.
+func newGCM(block cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	switch {
+	case nonceSize == 12:
+		return cipher.NewGCMWithTagSize(block, tagSize)
+	case tagSize == 16:
+		return cipher.NewGCMWithNonceSize(block, nonceSize)
+	}
+	return nil, fmt.Errorf("unsupported GCM parameters: nonce size %d with tag size %d", nonceSize, tagSize)
+}
+
+// deriveKey derives a key of keySize bytes from password with the PBKDF2 or scrypt parameters in kdf
+func deriveKey(kdf pkix.AlgorithmIdentifier, password []byte, keySize int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("parsing PBKDF2 parameters: %w", err)
+		}
+		if params.KeyLength != 0 && params.KeyLength != keySize {
+			return nil, fmt.Errorf("PBKDF2 key length %d does not match cipher key size %d", params.KeyLength, keySize)
+		}
+		if params.IterationCount <= 0 || params.IterationCount > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("invalid PBKDF2 iteration count %d", params.IterationCount)
+		}
+		h, err := prfHash(params.PRF.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(password, params.Salt, params.IterationCount, keySize, h), nil
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("parsing scrypt parameters: %w", err)
+		}
+		if params.KeyLength != 0 && params.KeyLength != keySize {
+			return nil, fmt.Errorf("scrypt key length %d does not match cipher key size %d", params.KeyLength, keySize)
+		}
+		if err := validateScryptParams(params.CostParameter, params.BlockSize, params.ParallelizationParameter); err != nil {
+			return nil, err
+		}
+		return scrypt.Key(password, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keySize)
+	}
+	return nil, fmt.Errorf("unsupported PBES2 key derivation function %v", kdf.Algorithm)
+}
+
+// prfHash returns the hash function of a PBKDF2 HMAC pseudorandom function, which defaults to HMAC-SHA1
+func prfHash(oid asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(oid) == 0, oid.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case oid.Equal(oidHMACWithSHA224):
+		return sha256.New224, nil
+	case oid.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case oid.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case oid.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function %v", oid)
+}
+
+// encryptPKCS8 encrypts the PKCS#8 PrivateKeyInfo in der and returns a DER encoded EncryptedPrivateKeyInfo
+func encryptPKCS8(der, password []byte, opts *PKCS8EncryptionOptions) ([]byte, error) {
+	o := PKCS8EncryptionOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Cipher == "" {
+		o.Cipher = AES256CBC
+	}
+	if o.KDF == "" {
+		o.KDF = PBKDF2WithHMACSHA256
+	}
+	cipherInfo, ok := pkcs8Ciphers[o.Cipher]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cipher %q", o.Cipher)
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	var kdf pkix.AlgorithmIdentifier
+	var key []byte
+	switch o.KDF {
+	case PBKDF2WithHMACSHA256, PBKDF2WithHMACSHA512:
+		if o.PBKDF2Iterations == 0 {
+			o.PBKDF2Iterations = 600000
+		}
+		prf, h := oidHMACWithSHA256, sha256.New
+		if o.KDF == PBKDF2WithHMACSHA512 {
+			prf, h = oidHMACWithSHA512, sha512.New
+		}
+		params, err := asn1.Marshal(pbkdf2Params{
+			Salt:           salt,
+			IterationCount: o.PBKDF2Iterations,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: prf, Parameters: asn1.NullRawValue},
+		})
+		if err != nil {
+			return nil, err
+		}
+		kdf = pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: params}}
+		key = pbkdf2.Key(password, salt, o.PBKDF2Iterations, cipherInfo.keySize, h)
+	case Scrypt:
+		if o.ScryptN == 0 {
+			o.ScryptN = 32768
+		}
+		if o.ScryptR == 0 {
+			o.ScryptR = 8
+		}
+		if o.ScryptP == 0 {
+			o.ScryptP = 1
+		}
+		// keys which could not be decrypted again are not written
+		if err := validateScryptParams(o.ScryptN, o.ScryptR, o.ScryptP); err != nil {
+			return nil, err
+		}
+		params, err := asn1.Marshal(scryptParams{
+			Salt:                     salt,
+			CostParameter:            o.ScryptN,
+			BlockSize:                o.ScryptR,
+			ParallelizationParameter: o.ScryptP,
+		})
+		if err != nil {
+			return nil, err
+		}
+		kdf = pkix.AlgorithmIdentifier{Algorithm: oidScrypt, Parameters: asn1.RawValue{FullBytes: params}}
+		if key, err = scrypt.Key(password, salt, o.ScryptN, o.ScryptR, o.ScryptP, cipherInfo.keySize); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key derivation function %q", o.KDF)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var encryptedData, schemeParams []byte
+	if cipherInfo.gcm {
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		if schemeParams, err = asn1.Marshal(gcmParams{Nonce: nonce, ICVLen: aead.Overhead()}); err != nil {
+			return nil, err
+		}
+		encryptedData = aead.Seal(nil, nonce, der, nil)
+	} else {
+		iv := make([]byte, block.BlockSize())
+		if _, err := rand.Read(iv); err != nil {
+			return nil, err
+		}
+		if schemeParams, err = asn1.Marshal(iv); err != nil {
+			return nil, err
+		}
+		padding := block.BlockSize() - len(der)%block.BlockSize()
+		encryptedData = append(append([]byte{}, der...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(encryptedData, encryptedData)
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: kdf,
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: cipherInfo.oid, Parameters: asn1.RawValue{FullBytes: schemeParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		EncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData:       encryptedData,
+	})
+}
+
+// MarshalPrivateKeyToEncryptedPKCS8PEM converts a crypto.PrivateKey into a PEM-encoded PKCS#8 "ENCRYPTED PRIVATE KEY",
+// encrypted with a password from the specified password function using PBES2 with PBKDF2-HMAC-SHA256 and AES-256-CBC.
+// The result can be read by OpenSSL and by UnmarshalPEMToPrivateKey.
+func MarshalPrivateKeyToEncryptedPKCS8PEM(priv crypto.PrivateKey, pf PassFunc) ([]byte, error) {
+	return MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts(priv, pf, nil)
+}
+
+// MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts is like MarshalPrivateKeyToEncryptedPKCS8PEM, but allows the cipher
+// and key derivation function to be chosen. Note that OpenSSL does not read keys encrypted with AES-GCM.
+func MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts(priv crypto.PrivateKey, pf PassFunc, opts *PKCS8EncryptionOptions) ([]byte, error) {
+	derKey, err := MarshalPrivateKeyToDER(priv)
+	if err != nil {
+		return nil, err
+	}
+	if pf == nil {
+		return nil, errors.New("password function was nil")
+	}
+	password, err := readPassword(pf, true)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroPassword(password)
+	if password == nil {
+		return nil, errors.New("password was nil")
+	}
+	encrypted, err := encryptPKCS8(derKey, password, opts)
+	if err != nil {
+		return nil, err
+	}
+	return PEMEncode(EncryptedPrivateKeyPEMType, encrypted), nil
+}
@@ -0,0 +1,208 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var pkcs8Password = []byte("sigstore")
+
+func readPrivateKeyFixture(t *testing.T, name string, pf PassFunc) crypto.PrivateKey {
+	t.Helper()
+	pemBytes, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := UnmarshalPEMToPrivateKey(pemBytes, pf)
+	if err != nil {
+		t.Fatalf("UnmarshalPEMToPrivateKey(%s) returned error: %v", name, err)
+	}
+	return priv
+}
+
+func assertSamePrivateKey(t *testing.T, got, want crypto.PrivateKey) {
+	t.Helper()
+	gotDER, err := MarshalPrivateKeyToDER(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDER, err := MarshalPrivateKeyToDER(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotDER, wantDER) {
+		t.Error("decrypted private key does not match")
+	}
+}
+
+// The encrypted fixtures were generated from the plaintext ones with OpenSSL 3, e.g.
+//
+//	openssl pkcs8 -topk8 -in pkcs8_ec.pem -v2 aes-256-cbc -v2prf hmacWithSHA256 -passout pass:sigstore
+//	openssl pkcs8 -topk8 -in pkcs8_rsa.pem -v2 aes-128-cbc -v2prf hmacWithSHA1 -iter 1000 -passout pass:sigstore
+//	openssl pkcs8 -topk8 -in pkcs8_ed25519.pem -v2 aes-192-cbc -scrypt -passout pass:sigstore
+func TestUnmarshalOpenSSLEncryptedPKCS8(t *testing.T) {
+	t.Parallel()
+	for encrypted, plain := range map[string]string{
+		"pkcs8_ec_pbkdf2_sha256_aes256cbc.pem": "pkcs8_ec.pem",
+		"pkcs8_rsa_pbkdf2_sha1_aes128cbc.pem":  "pkcs8_rsa.pem",
+		"pkcs8_ed25519_scrypt_aes192cbc.pem":   "pkcs8_ed25519.pem",
+	} {
+		encrypted, plain := encrypted, plain
+		t.Run(encrypted, func(t *testing.T) {
+			t.Parallel()
+			want := readPrivateKeyFixture(t, plain, nil)
+			got := readPrivateKeyFixture(t, encrypted, StaticPasswordFunc(pkcs8Password))
+			assertSamePrivateKey(t, got, want)
+
+			pemBytes, err := os.ReadFile(filepath.Join("testdata", encrypted))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := UnmarshalPEMToPrivateKey(pemBytes, StaticPasswordFunc([]byte("wrong"))); err == nil {
+				t.Error("expected error decrypting with the wrong password")
+			}
+			if _, err := UnmarshalPEMToPrivateKey(pemBytes, SkipPassword); err == nil {
+				t.Error("expected error decrypting without a password")
+			}
+			if _, err := UnmarshalPEMToPrivateKey(pemBytes, nil); err == nil {
+				t.Error("expected error decrypting without a password function")
+			}
+		})
+	}
+}
+
+// The oversized fixtures are pkcs8_ed25519_scrypt_aes192cbc.pem with scrypt r or p set to 2^20
+func TestUnmarshalEncryptedPKCS8ScryptLimits(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"pkcs8_ed25519_scrypt_oversized_r.pem", "pkcs8_ed25519_scrypt_oversized_p.pem"} {
+		pemBytes, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		if _, err := UnmarshalPEMToPrivateKey(pemBytes, StaticPasswordFunc(pkcs8Password)); err == nil {
+			t.Errorf("expected error decrypting %s", name)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("decrypting %s took %v, expected it to be rejected before deriving the key", name, elapsed)
+		}
+	}
+
+	for _, tt := range []struct {
+		n, r, p int
+		valid   bool
+	}{
+		{n: 1 << 15, r: 8, p: 1, valid: true},
+		{n: 1 << 18, r: 8, p: 1, valid: true},
+		{n: 1 << 19, r: 8, p: 1},
+		{n: 1 << 22, r: 8, p: 1},
+		{n: 1, r: 8, p: 1},
+		{n: 0, r: 8, p: 1},
+		{n: 1000, r: 8, p: 1},
+		{n: 1 << 10, r: 0, p: 1},
+		{n: 1 << 10, r: 64, p: 1},
+		{n: 1 << 10, r: 8, p: 0},
+		{n: 1 << 10, r: 8, p: 32},
+	} {
+		if err := validateScryptParams(tt.n, tt.r, tt.p); (err == nil) != tt.valid {
+			t.Errorf("validateScryptParams(%d, %d, %d) = %v, want valid %v", tt.n, tt.r, tt.p, err, tt.valid)
+		}
+	}
+}
+
+func TestEncryptedPKCS8RoundTrip(t *testing.T) {
+	t.Parallel()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string]*PKCS8EncryptionOptions{
+		"default":            nil,
+		"aes-128-gcm pbkdf2": {Cipher: AES128GCM, PBKDF2Iterations: 1000},
+		"aes-256-gcm scrypt": {Cipher: AES256GCM, KDF: Scrypt, ScryptN: 1024},
+		"aes-192-cbc sha512": {Cipher: AES192CBC, KDF: PBKDF2WithHMACSHA512, PBKDF2Iterations: 1000},
+		"aes-128-cbc scrypt": {Cipher: AES128CBC, KDF: Scrypt, ScryptN: 1024},
+	} {
+		name, opts := name, opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pemBytes, err := MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts(priv, StaticPasswordFunc(pkcs8Password), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalPEMToPrivateKey(pemBytes, StaticPasswordFunc(pkcs8Password))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSamePrivateKey(t, got, priv)
+			if _, err := UnmarshalPEMToPrivateKey(pemBytes, StaticPasswordFunc([]byte("wrong"))); err == nil {
+				t.Error("expected error decrypting with the wrong password")
+			}
+		})
+	}
+
+	if _, err := MarshalPrivateKeyToEncryptedPKCS8PEM(priv, SkipPassword); err == nil {
+		t.Error("expected error encrypting without a password")
+	}
+	if _, err := MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts(priv, StaticPasswordFunc(pkcs8Password), &PKCS8EncryptionOptions{Cipher: "des"}); err == nil {
+		t.Error("expected error for unsupported cipher")
+	}
+	if _, err := MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts(priv, StaticPasswordFunc(pkcs8Password), &PKCS8EncryptionOptions{KDF: Scrypt, ScryptN: 1000}); err == nil {
+		t.Error("expected error for invalid scrypt parameters")
+	}
+}
+
+func TestEncryptedPKCS8OpenSSLInterop(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found in PATH")
+	}
+	want := readPrivateKeyFixture(t, "pkcs8_ec.pem", nil)
+	for name, opts := range map[string]*PKCS8EncryptionOptions{
+		"pbkdf2": {PBKDF2Iterations: 1000},
+		"scrypt": {Cipher: AES128CBC, KDF: Scrypt, ScryptN: 1024},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pemBytes, err := MarshalPrivateKeyToEncryptedPKCS8PEMWithOpts(want, StaticPasswordFunc(pkcs8Password), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := filepath.Join(t.TempDir(), "key.pem")
+			if err := os.WriteFile(in, pemBytes, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			out, err := exec.Command(openssl, "pkcs8", "-in", in, "-passin", "pass:"+string(pkcs8Password)).Output() // nolint:gosec
+			if err != nil {
+				t.Fatalf("openssl could not decrypt key: %v", err)
+			}
+			got, err := UnmarshalPEMToPrivateKey(out, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSamePrivateKey(t, got, want)
+		})
+	}
+}
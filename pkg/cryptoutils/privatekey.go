@@ -18,10 +18,12 @@ package cryptoutils
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -54,10 +56,11 @@ func pemEncodeKeyPair(priv crypto.PrivateKey, pub crypto.PublicKey, pf PassFunc)
 	if pf == nil {
 		return PEMEncode(PrivateKeyPEMType, derBytes), pubPEM, nil
 	}
-	password, err := pf(true)
+	password, err := readPassword(pf, true)
 	if err != nil {
 		return nil, nil, err
 	}
+	defer ZeroPassword(password)
 	if password == nil {
 		return PEMEncode(PrivateKeyPEMType, derBytes), pubPEM, nil
 	}
@@ -85,16 +88,26 @@ func GeneratePEMEncodedRSAKeyPair(keyLengthBits int, pf PassFunc) (privPEM, pubP
 	return pemEncodeKeyPair(priv, priv.Public(), pf)
 }
 
+// GeneratePEMEncodedED25519KeyPair generates an Ed25519 keypair, optionally password encrypted using a provided PassFunc, and PEM encoded.
+func GeneratePEMEncodedED25519KeyPair(pf PassFunc) (privPEM, pubPEM []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pemEncodeKeyPair(priv, pub, pf)
+}
+
 // MarshalPrivateKeyToEncryptedDER marshals the private key and encrypts the DER-encoded value using the specified password function
 func MarshalPrivateKeyToEncryptedDER(priv crypto.PrivateKey, pf PassFunc) ([]byte, error) {
 	derKey, err := MarshalPrivateKeyToDER(priv)
 	if err != nil {
 		return nil, err
 	}
-	password, err := pf(true)
+	password, err := readPassword(pf, true)
 	if err != nil {
 		return nil, err
 	}
+	defer ZeroPassword(password)
 	if password == nil {
 		return nil, errors.New("password was nil")
 	}
@@ -117,10 +130,11 @@ func UnmarshalPEMToPrivateKey(pemBytes []byte, pf PassFunc) (crypto.PrivateKey,
 	case string(EncryptedSigstorePrivateKeyPEMType), string(encryptedCosignPrivateKeyPEMType):
 		derBytes := derBlock.Bytes
 		if pf != nil {
-			password, err := pf(false)
+			password, err := readPassword(pf, false)
 			if err != nil {
 				return nil, err
 			}
+			defer ZeroPassword(password)
 			if password != nil {
 				derBytes, err = encrypted.Decrypt(derBytes, password)
 				if err != nil {
@@ -129,6 +143,23 @@ func UnmarshalPEMToPrivateKey(pemBytes []byte, pf PassFunc) (crypto.PrivateKey,
 			}
 		}
 
+		return x509.ParsePKCS8PrivateKey(derBytes)
+	case string(EncryptedPrivateKeyPEMType):
+		if pf == nil {
+			return nil, errors.New("a password is required to decrypt an encrypted PKCS#8 private key")
+		}
+		password, err := readPassword(pf, false)
+		if err != nil {
+			return nil, err
+		}
+		defer ZeroPassword(password)
+		if password == nil {
+			return nil, errors.New("a password is required to decrypt an encrypted PKCS#8 private key")
+		}
+		derBytes, err := decryptPKCS8(derBlock.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
 		return x509.ParsePKCS8PrivateKey(derBytes)
 	}
 	return nil, fmt.Errorf("unknown private key PEM file type: %v", derBlock.Type)
@@ -150,3 +181,117 @@ func MarshalPrivateKeyToPEM(priv crypto.PrivateKey) ([]byte, error) {
 	}
 	return PEMEncode(PrivateKeyPEMType, derBytes), nil
 }
+
+// ScryptKDFParams are the scrypt parameters used to derive the key encrypting an ENCRYPTED SIGSTORE PRIVATE KEY
+type ScryptKDFParams struct {
+	N int `json:"N"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// Scrypt parameters accepted when decrypting an ENCRYPTED SIGSTORE PRIVATE KEY. Keys encrypted with any other
+// parameters are rejected to prevent denial of service through tampered parameters.
+var (
+	// ScryptKDFParamsLegacy are the parameters used by older versions of cosign
+	ScryptKDFParamsLegacy = ScryptKDFParams{N: 32768, R: 8, P: 1}
+	// ScryptKDFParamsStandard are the parameters used when this package encrypts a key
+	ScryptKDFParamsStandard = ScryptKDFParams{N: 65536, R: 8, P: 1}
+	// ScryptKDFParamsOWASP are the parameters recommended by OWASP
+	ScryptKDFParamsOWASP = ScryptKDFParams{N: 131072, R: 8, P: 1}
+)
+
+var scryptKDFStrengths = map[ScryptKDFParams]encrypted.KDFParameterStrength{
+	ScryptKDFParamsLegacy:   encrypted.Legacy,
+	ScryptKDFParamsStandard: encrypted.Standard,
+	ScryptKDFParamsOWASP:    encrypted.OWASP,
+}
+
+// ReencryptOptions configures ReencryptPEMEncodedPrivateKey
+type ReencryptOptions struct {
+	// KDFParams are the scrypt parameters of the re-encrypted key, one of ScryptKDFParamsLegacy,
+	// ScryptKDFParamsStandard and ScryptKDFParamsOWASP. The zero value selects ScryptKDFParamsStandard.
+	KDFParams ScryptKDFParams
+}
+
+// ReencryptedPrivateKey is the result of ReencryptPEMEncodedPrivateKey
+type ReencryptedPrivateKey struct {
+	// PEM is the re-encrypted private key, with the PEM type of the original key
+	PEM []byte
+	// OldKDFParams are the scrypt parameters of the original key
+	OldKDFParams ScryptKDFParams
+	// NewKDFParams are the scrypt parameters of the re-encrypted key
+	NewKDFParams ScryptKDFParams
+}
+
+// ReencryptPEMEncodedPrivateKey decrypts a PEM-encoded ENCRYPTED SIGSTORE PRIVATE KEY or ENCRYPTED COSIGN PRIVATE KEY
+// with the password returned by oldPF and encrypts it again with the password returned by newPF and the scrypt
+// parameters in opts, which may be nil. newPF is called with confirm set to true. The decrypted key is zeroed
+// before returning.
+func ReencryptPEMEncodedPrivateKey(pemBytes []byte, oldPF, newPF PassFunc, opts *ReencryptOptions) (*ReencryptedPrivateKey, error) {
+	if oldPF == nil || newPF == nil {
+		return nil, errors.New("passwords are required to re-encrypt a private key")
+	}
+	newParams := ScryptKDFParamsStandard
+	if opts != nil && opts.KDFParams != (ScryptKDFParams{}) {
+		newParams = opts.KDFParams
+	}
+	strength, ok := scryptKDFStrengths[newParams]
+	if !ok {
+		return nil, fmt.Errorf("unsupported scrypt parameters %+v", newParams)
+	}
+
+	derBlock, _ := pem.Decode(pemBytes)
+	if derBlock == nil {
+		return nil, errors.New("PEM decoding failed")
+	}
+	if derBlock.Type != string(EncryptedSigstorePrivateKeyPEMType) && derBlock.Type != string(encryptedCosignPrivateKeyPEMType) {
+		return nil, fmt.Errorf("cannot re-encrypt private key PEM file type: %v", derBlock.Type)
+	}
+	var envelope struct {
+		KDF struct {
+			Name   string          `json:"name"`
+			Params ScryptKDFParams `json:"params"`
+		} `json:"kdf"`
+	}
+	if err := json.Unmarshal(derBlock.Bytes, &envelope); err != nil {
+		return nil, fmt.Errorf("parsing encrypted private key: %w", err)
+	}
+	if envelope.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function %q", envelope.KDF.Name)
+	}
+
+	oldPassword, err := readPassword(oldPF, false)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroPassword(oldPassword)
+	if oldPassword == nil {
+		return nil, errors.New("password was nil")
+	}
+	derBytes, err := encrypted.Decrypt(derBlock.Bytes, oldPassword)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting private key: %w", err)
+	}
+	defer clear(derBytes)
+	if _, err := x509.ParsePKCS8PrivateKey(derBytes); err != nil {
+		return nil, fmt.Errorf("parsing decrypted private key: %w", err)
+	}
+
+	newPassword, err := readPassword(newPF, true)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroPassword(newPassword)
+	if newPassword == nil {
+		return nil, errors.New("password was nil")
+	}
+	encBytes, err := encrypted.EncryptWithCustomKDFParameters(derBytes, newPassword, strength)
+	if err != nil {
+		return nil, err
+	}
+	return &ReencryptedPrivateKey{
+		PEM:          PEMEncode(PEMType(derBlock.Type), encBytes),
+		OldKDFParams: envelope.KDF.Params,
+		NewKDFParams: newParams,
+	}, nil
+}
@@ -27,6 +27,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
 )
 
 func verifyRSAKeyPEMs(t *testing.T, privPEM, pubPEM []byte, expectedKeyLengthBits int, testPassFunc PassFunc) {
@@ -294,3 +295,88 @@ func TestUnmarshalPEMToPrivateKey(t *testing.T) {
 		t.Fatalf("expected error unmarshalling invalid PEM block, got: %v", err)
 	}
 }
+
+func TestReencryptPEMEncodedPrivateKey(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey failed: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("x509.MarshalPKCS8PrivateKey failed: %v", err)
+	}
+	legacyBytes, err := encrypted.EncryptWithCustomKDFParameters(der, []byte("old"), encrypted.Legacy)
+	if err != nil {
+		t.Fatalf("encrypting key failed: %v", err)
+	}
+	cosignPEM := PEMEncode(encryptedCosignPrivateKeyPEMType, legacyBytes)
+	sigstoreBytes, err := MarshalPrivateKeyToEncryptedDER(priv, StaticPasswordFunc([]byte("old")))
+	if err != nil {
+		t.Fatalf("MarshalPrivateKeyToEncryptedDER failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		pemBytes      []byte
+		opts          *ReencryptOptions
+		wantOldParams ScryptKDFParams
+		wantNewParams ScryptKDFParams
+	}{
+		{
+			name:          "legacy cosign key",
+			pemBytes:      cosignPEM,
+			wantOldParams: ScryptKDFParamsLegacy,
+			wantNewParams: ScryptKDFParamsStandard,
+		},
+		{
+			name:          "sigstore key with OWASP parameters",
+			pemBytes:      PEMEncode(EncryptedSigstorePrivateKeyPEMType, sigstoreBytes),
+			opts:          &ReencryptOptions{KDFParams: ScryptKDFParamsOWASP},
+			wantOldParams: ScryptKDFParamsStandard,
+			wantNewParams: ScryptKDFParamsOWASP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReencryptPEMEncodedPrivateKey(tt.pemBytes, StaticPasswordFunc([]byte("old")), StaticPasswordFunc([]byte("new")), tt.opts)
+			if err != nil {
+				t.Fatalf("ReencryptPEMEncodedPrivateKey failed: %v", err)
+			}
+			if got.OldKDFParams != tt.wantOldParams || got.NewKDFParams != tt.wantNewParams {
+				t.Fatalf("got KDF parameters %+v -> %+v, want %+v -> %+v", got.OldKDFParams, got.NewKDFParams, tt.wantOldParams, tt.wantNewParams)
+			}
+			oldBlock, _ := pem.Decode(tt.pemBytes)
+			newBlock, _ := pem.Decode(got.PEM)
+			if newBlock.Type != oldBlock.Type {
+				t.Fatalf("expected PEM type %s, got %s", oldBlock.Type, newBlock.Type)
+			}
+			if _, err := UnmarshalPEMToPrivateKey(got.PEM, StaticPasswordFunc([]byte("old"))); err == nil {
+				t.Fatal("expected error decrypting with the old password")
+			}
+			k, err := UnmarshalPEMToPrivateKey(got.PEM, StaticPasswordFunc([]byte("new")))
+			if err != nil {
+				t.Fatalf("UnmarshalPEMToPrivateKey with the new password failed: %v", err)
+			}
+			if !priv.Equal(k) {
+				t.Fatal("re-encrypted private key is not equal to the original")
+			}
+		})
+	}
+
+	if _, err := ReencryptPEMEncodedPrivateKey(cosignPEM, StaticPasswordFunc([]byte("wrong")), StaticPasswordFunc([]byte("new")), nil); err == nil {
+		t.Fatal("expected error with the wrong password")
+	}
+	if _, err := ReencryptPEMEncodedPrivateKey(cosignPEM, StaticPasswordFunc([]byte("old")), StaticPasswordFunc([]byte("new")), &ReencryptOptions{KDFParams: ScryptKDFParams{N: 1 << 20, R: 8, P: 1}}); err == nil {
+		t.Fatal("expected error with unsupported scrypt parameters")
+	}
+	if _, err := ReencryptPEMEncodedPrivateKey(cosignPEM, StaticPasswordFunc([]byte("old")), nil, nil); err == nil {
+		t.Fatal("expected error without a new password")
+	}
+	plainPEM, err := MarshalPrivateKeyToPEM(priv)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKeyToPEM failed: %v", err)
+	}
+	if _, err := ReencryptPEMEncodedPrivateKey(plainPEM, StaticPasswordFunc([]byte("old")), StaticPasswordFunc([]byte("new")), nil); err == nil {
+		t.Fatal("expected error re-encrypting an unencrypted key")
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"encoding/asn1"
 	"errors"
 	"fmt"
+	"net"
+	"unicode/utf8"
 )
 
 var (
@@ -129,7 +131,8 @@ func UnmarshalOtherNameSAN(exts []pkix.Extension) (string, error) {
 
 // GetSubjectAlternateNames extracts all subject alternative names from
 // the certificate, including email addresses, DNS, IP addresses, URIs,
-// and OtherName SANs
+// and OtherName SANs. Use GetTypedSubjectAlternateNames to tell the kinds of
+// names apart.
 func GetSubjectAlternateNames(cert *x509.Certificate) []string {
 	sans := []string{}
 	sans = append(sans, cert.DNSNames...)
@@ -147,3 +150,323 @@ func GetSubjectAlternateNames(cert *x509.Certificate) []string {
 	}
 	return sans
 }
+
+// SANType identifies the kind of a GeneralName in a Subject Alternative Name extension.
+// The values are the context-specific tags of the GeneralName CHOICE in RFC 5280, 4.2.1.6.
+type SANType int
+
+const (
+	// SANTypeOtherName is an OtherName, with any type-id
+	SANTypeOtherName SANType = 0
+	// SANTypeEmail is an rfc822Name
+	SANTypeEmail SANType = 1
+	// SANTypeDNS is a dNSName
+	SANTypeDNS SANType = 2
+	// SANTypeDirectoryName is a directoryName
+	SANTypeDirectoryName SANType = 4
+	// SANTypeURI is a uniformResourceIdentifier
+	SANTypeURI SANType = 6
+	// SANTypeIP is an iPAddress
+	SANTypeIP SANType = 7
+	// SANTypeRegisteredID is a registeredID
+	SANTypeRegisteredID SANType = 8
+)
+
+func (t SANType) String() string {
+	switch t {
+	case SANTypeOtherName:
+		return "OtherName"
+	case SANTypeEmail:
+		return "Email"
+	case SANTypeDNS:
+		return "DNS"
+	case SANTypeDirectoryName:
+		return "DirectoryName"
+	case SANTypeURI:
+		return "URI"
+	case SANTypeIP:
+		return "IP"
+	case SANTypeRegisteredID:
+		return "RegisteredID"
+	}
+	return fmt.Sprintf("SANType(%d)", int(t))
+}
+
+// SubjectAlternativeName is a single typed GeneralName from a Subject Alternative Name extension
+type SubjectAlternativeName struct {
+	Type SANType
+	// Value is the name as a string: the DNS name, email address or URI; the textual form of an
+	// IP address; the value of an OtherName whose value is a UTF8String; the RFC 2253 form of
+	// a DirectoryName; or the dotted form of a RegisteredID
+	Value string
+	// ID is the type-id of an OtherName, or the OID of a RegisteredID
+	ID asn1.ObjectIdentifier
+	// Raw is the DER encoding of a DirectoryName's RDNSequence, or of an OtherName value which is
+	// not a UTF8String. When set, it is marshaled instead of Value.
+	Raw []byte
+}
+
+// DNSSAN returns a dNSName SAN
+func DNSSAN(name string) SubjectAlternativeName {
+	return SubjectAlternativeName{Type: SANTypeDNS, Value: name}
+}
+
+// EmailSAN returns an rfc822Name SAN
+func EmailSAN(email string) SubjectAlternativeName {
+	return SubjectAlternativeName{Type: SANTypeEmail, Value: email}
+}
+
+// URISAN returns a uniformResourceIdentifier SAN
+func URISAN(uri string) SubjectAlternativeName {
+	return SubjectAlternativeName{Type: SANTypeURI, Value: uri}
+}
+
+// IPSAN returns an iPAddress SAN
+func IPSAN(ip net.IP) SubjectAlternativeName {
+	return SubjectAlternativeName{Type: SANTypeIP, Value: ip.String()}
+}
+
+// OtherNameSAN returns an OtherName SAN with type-id id and a UTF8String value
+func OtherNameSAN(id asn1.ObjectIdentifier, value string) SubjectAlternativeName {
+	return SubjectAlternativeName{Type: SANTypeOtherName, ID: id, Value: value}
+}
+
+// DirectoryNameSAN returns a directoryName SAN
+func DirectoryNameSAN(name pkix.Name) (SubjectAlternativeName, error) {
+	raw, err := asn1.Marshal(name.ToRDNSequence())
+	if err != nil {
+		return SubjectAlternativeName{}, err
+	}
+	return SubjectAlternativeName{Type: SANTypeDirectoryName, Value: name.String(), Raw: raw}, nil
+}
+
+// RegisteredIDSAN returns a registeredID SAN
+func RegisteredIDSAN(id asn1.ObjectIdentifier) SubjectAlternativeName {
+	return SubjectAlternativeName{Type: SANTypeRegisteredID, Value: id.String(), ID: id}
+}
+
+// anyOtherName is an OtherName whose value may be of any type. Value holds the explicit
+// [0] tag, as encoding/asn1 does not apply explicit tags to RawValue fields.
+type anyOtherName struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// MarshalSANs creates a Subject Alternative Name extension containing sans, in order.
+// UnmarshalSANs returns the same names from the extension.
+func MarshalSANs(sans []SubjectAlternativeName, critical bool) (*pkix.Extension, error) {
+	if len(sans) == 0 {
+		return nil, errors.New("at least one SAN is required")
+	}
+	names := make([]asn1.RawValue, 0, len(sans))
+	for i, san := range sans {
+		name, err := marshalGeneralName(san)
+		if err != nil {
+			return nil, fmt.Errorf("SAN %d: %w", i, err)
+		}
+		names = append(names, name)
+	}
+	value, err := asn1.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{
+		Id:       SANOID,
+		Critical: critical,
+		Value:    value,
+	}, nil
+}
+
+func marshalGeneralName(san SubjectAlternativeName) (asn1.RawValue, error) {
+	switch san.Type {
+	case SANTypeEmail, SANTypeDNS, SANTypeURI:
+		if !isIA5String(san.Value) {
+			return asn1.RawValue{}, fmt.Errorf("%s SAN %q contains non-ASCII characters", san.Type, san.Value)
+		}
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: int(san.Type), Bytes: []byte(san.Value)}, nil
+	case SANTypeIP:
+		ip := net.ParseIP(san.Value)
+		if ip == nil {
+			return asn1.RawValue{}, fmt.Errorf("invalid IP SAN %q", san.Value)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: int(san.Type), Bytes: ip}, nil
+	case SANTypeOtherName:
+		if len(san.ID) == 0 {
+			return asn1.RawValue{}, errors.New("OtherName SAN has no type-id")
+		}
+		value := san.Raw
+		if value == nil {
+			if !utf8.ValidString(san.Value) {
+				return asn1.RawValue{}, errors.New("OtherName SAN value is not valid UTF-8")
+			}
+			var err error
+			if value, err = asn1.MarshalWithParams(san.Value, "utf8"); err != nil {
+				return asn1.RawValue{}, err
+			}
+		}
+		other := anyOtherName{
+			ID:    san.ID,
+			Value: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: value},
+		}
+		b, err := asn1.MarshalWithParams(other, "tag:0")
+		if err != nil {
+			return asn1.RawValue{}, err
+		}
+		return asn1.RawValue{FullBytes: b}, nil
+	case SANTypeDirectoryName:
+		if san.Raw == nil {
+			return asn1.RawValue{}, errors.New("DirectoryName SAN has no DER encoded name, use DirectoryNameSAN to create it")
+		}
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: int(san.Type), IsCompound: true, Bytes: san.Raw}, nil
+	case SANTypeRegisteredID:
+		b, err := asn1.MarshalWithParams(san.ID, "tag:8")
+		if err != nil {
+			return asn1.RawValue{}, err
+		}
+		return asn1.RawValue{FullBytes: b}, nil
+	}
+	return asn1.RawValue{}, fmt.Errorf("unsupported SAN type %s", san.Type)
+}
+
+// UnmarshalSANs returns the typed names of all Subject Alternative Name extensions in exts, in the
+// order they are encoded. x400Address and ediPartyName names are not supported and return an error.
+func UnmarshalSANs(exts []pkix.Extension) ([]SubjectAlternativeName, error) {
+	sans := []SubjectAlternativeName{}
+	for _, e := range exts {
+		if !e.Id.Equal(SANOID) {
+			continue
+		}
+
+		var seq asn1.RawValue
+		rest, err := asn1.Unmarshal(e.Value, &seq)
+		if err != nil {
+			return nil, err
+		} else if len(rest) != 0 {
+			return nil, fmt.Errorf("trailing data after X.509 extension")
+		}
+		if !seq.IsCompound || seq.Tag != asn1.TagSequence || seq.Class != asn1.ClassUniversal {
+			return nil, asn1.StructuralError{Msg: "bad SAN sequence"}
+		}
+
+		rest = seq.Bytes
+		for len(rest) > 0 {
+			var v asn1.RawValue
+			rest, err = asn1.Unmarshal(rest, &v)
+			if err != nil {
+				return nil, err
+			}
+			san, err := unmarshalGeneralName(v)
+			if err != nil {
+				return nil, err
+			}
+			sans = append(sans, san)
+		}
+	}
+	return sans, nil
+}
+
+func unmarshalGeneralName(v asn1.RawValue) (SubjectAlternativeName, error) {
+	if v.Class != asn1.ClassContextSpecific {
+		return SubjectAlternativeName{}, asn1.StructuralError{Msg: "bad GeneralName class"}
+	}
+	san := SubjectAlternativeName{Type: SANType(v.Tag)}
+	switch san.Type {
+	case SANTypeEmail, SANTypeDNS, SANTypeURI:
+		if v.IsCompound || !isIA5String(string(v.Bytes)) {
+			return SubjectAlternativeName{}, fmt.Errorf("%s SAN is not a valid IA5String", san.Type)
+		}
+		san.Value = string(v.Bytes)
+	case SANTypeIP:
+		if v.IsCompound || (len(v.Bytes) != net.IPv4len && len(v.Bytes) != net.IPv6len) {
+			return SubjectAlternativeName{}, fmt.Errorf("IP SAN has invalid length %d", len(v.Bytes))
+		}
+		san.Value = net.IP(v.Bytes).String()
+	case SANTypeOtherName:
+		var other anyOtherName
+		if rest, err := asn1.UnmarshalWithParams(v.FullBytes, &other, "tag:0"); err != nil {
+			return SubjectAlternativeName{}, fmt.Errorf("could not parse OtherName SAN: %w", err)
+		} else if len(rest) != 0 {
+			return SubjectAlternativeName{}, errors.New("trailing data after OtherName SAN")
+		}
+		if other.Value.Class != asn1.ClassContextSpecific || other.Value.Tag != 0 || !other.Value.IsCompound {
+			return SubjectAlternativeName{}, asn1.StructuralError{Msg: "bad OtherName value tag"}
+		}
+		var value asn1.RawValue
+		if rest, err := asn1.Unmarshal(other.Value.Bytes, &value); err != nil {
+			return SubjectAlternativeName{}, fmt.Errorf("could not parse OtherName SAN value: %w", err)
+		} else if len(rest) != 0 {
+			return SubjectAlternativeName{}, errors.New("trailing data after OtherName SAN value")
+		}
+		san.ID = other.ID
+		if value.Class == asn1.ClassUniversal && value.Tag == asn1.TagUTF8String && !value.IsCompound {
+			if !utf8.Valid(value.Bytes) {
+				return SubjectAlternativeName{}, errors.New("OtherName SAN value is not valid UTF-8")
+			}
+			san.Value = string(value.Bytes)
+		} else {
+			san.Raw = value.FullBytes
+		}
+	case SANTypeDirectoryName:
+		var rdns pkix.RDNSequence
+		if rest, err := asn1.Unmarshal(v.Bytes, &rdns); err != nil {
+			return SubjectAlternativeName{}, fmt.Errorf("could not parse DirectoryName SAN: %w", err)
+		} else if len(rest) != 0 {
+			return SubjectAlternativeName{}, errors.New("trailing data after DirectoryName SAN")
+		}
+		var name pkix.Name
+		name.FillFromRDNSequence(&rdns)
+		san.Value = name.String()
+		san.Raw = v.Bytes
+	case SANTypeRegisteredID:
+		var id asn1.ObjectIdentifier
+		if _, err := asn1.UnmarshalWithParams(v.FullBytes, &id, "tag:8"); err != nil {
+			return SubjectAlternativeName{}, fmt.Errorf("could not parse RegisteredID SAN: %w", err)
+		}
+		san.ID = id
+		san.Value = id.String()
+	default:
+		return SubjectAlternativeName{}, fmt.Errorf("unsupported GeneralName with tag %d", v.Tag)
+	}
+	return san, nil
+}
+
+func isIA5String(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTypedSubjectAlternateNames returns the typed subject alternative names of the certificate,
+// in the order they are encoded in its Subject Alternative Name extension. Unlike
+// GetSubjectAlternateNames, it returns OtherName SANs of any type-id, DirectoryName and
+// RegisteredID SANs, and allows the kind of each name to be told apart.
+//
+// If the certificate was not parsed and so has no SAN extension, the names are taken from its
+// DNSNames, EmailAddresses, IPAddresses and URIs fields.
+func GetTypedSubjectAlternateNames(cert *x509.Certificate) ([]SubjectAlternativeName, error) {
+	for _, e := range cert.Extensions {
+		if e.Id.Equal(SANOID) {
+			return UnmarshalSANs(cert.Extensions)
+		}
+	}
+	sans := []SubjectAlternativeName{}
+	for _, name := range cert.DNSNames {
+		sans = append(sans, DNSSAN(name))
+	}
+	for _, email := range cert.EmailAddresses {
+		sans = append(sans, EmailSAN(email))
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, IPSAN(ip))
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, URISAN(uri.String()))
+	}
+	return sans, nil
+}
@@ -15,11 +15,14 @@
 package cryptoutils
 
 import (
+	"bytes"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/hex"
 	"net"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -228,3 +231,162 @@ func TestGetSubjectAltnernativeNames(t *testing.T) {
 		t.Fatalf("unexpected URL SAN value")
 	}
 }
+
+func TestMarshalAndUnmarshalSANs(t *testing.T) {
+	dirName, err := DirectoryNameSAN(pkix.Name{CommonName: "sigstore", Organization: []string{"Linux Foundation"}})
+	if err != nil {
+		t.Fatalf("unexpected error creating DirectoryName SAN: %v", err)
+	}
+	// an OtherName whose value is an INTEGER rather than a UTF8String
+	rawValue, _ := asn1.Marshal(42)
+	sans := []SubjectAlternativeName{
+		URISAN("https://github.com/sigstore/sigstore/.github/workflows/release.yml@refs/heads/main"),
+		EmailSAN("user@example.com"),
+		DNSSAN("example.com"),
+		IPSAN(net.IP{1, 2, 3, 4}),
+		IPSAN(net.ParseIP("2001:db8::1")),
+		OtherNameSAN(OIDOtherName, "foo!example.com"),
+		OtherNameSAN(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}, "upn@example.com"),
+		{Type: SANTypeOtherName, ID: asn1.ObjectIdentifier{1, 2, 3}, Raw: rawValue},
+		dirName,
+		RegisteredIDSAN(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264}),
+	}
+	ext, err := MarshalSANs(sans, true)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling SANs: %v", err)
+	}
+	if !ext.Id.Equal(SANOID) || !ext.Critical {
+		t.Fatalf("unexpected extension %v", ext)
+	}
+	got, err := UnmarshalSANs([]pkix.Extension{*ext})
+	if err != nil {
+		t.Fatalf("unexpected error unmarshalling SANs: %v", err)
+	}
+	if !reflect.DeepEqual(got, sans) {
+		t.Fatalf("SANs did not round trip, expected %+v, got %+v", sans, got)
+	}
+	if got[8].Value != "CN=sigstore,O=Linux Foundation" {
+		t.Errorf("unexpected DirectoryName value %q", got[8].Value)
+	}
+	if got[9].Value != "1.3.6.1.4.1.57264" {
+		t.Errorf("unexpected RegisteredID value %q", got[9].Value)
+	}
+
+	// the encoding of an OtherName matches MarshalOtherNameSAN
+	ext, err = MarshalSANs([]SubjectAlternativeName{OtherNameSAN(OIDOtherName, "foo!example.com")}, false)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling SANs: %v", err)
+	}
+	expected, err := MarshalOtherNameSAN("foo!example.com", false)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling OtherName: %v", err)
+	}
+	if !bytes.Equal(ext.Value, expected.Value) {
+		t.Errorf("expected %x, got %x", expected.Value, ext.Value)
+	}
+}
+
+func TestMarshalSANsFailures(t *testing.T) {
+	tests := map[string][]SubjectAlternativeName{
+		"no SANs":               nil,
+		"non-ASCII DNS":         {DNSSAN("ex\u00e4mple.com")},
+		"invalid IP":            {{Type: SANTypeIP, Value: "1.2.3"}},
+		"OtherName without OID": {{Type: SANTypeOtherName, Value: "foo"}},
+		"invalid UTF-8":         {OtherNameSAN(OIDOtherName, "\xff")},
+		"DirectoryName string":  {{Type: SANTypeDirectoryName, Value: "CN=sigstore"}},
+		"unsupported type":      {{Type: 3, Value: "x400"}},
+	}
+	for name, sans := range tests {
+		if _, err := MarshalSANs(sans, false); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestUnmarshalSANsFailures(t *testing.T) {
+	tests := map[string]string{
+		// ediPartyName [5]
+		"unsupported type": "3004a5020500",
+		// iPAddress of 3 bytes
+		"invalid IP": "30058703010203",
+		// dNSName with a non-ASCII byte
+		"non-ASCII DNS": "30038201ff",
+		// GeneralName with universal class
+		"universal class": "30030c0161",
+		// OtherName with trailing data in the value
+		"bad OtherName": "3009a00706022a03a0010c",
+	}
+	for name, value := range tests {
+		b, _ := hex.DecodeString(value)
+		if _, err := UnmarshalSANs([]pkix.Extension{{Id: SANOID, Value: b}}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	// trailing data after the SAN sequence
+	ext, _ := MarshalSANs([]SubjectAlternativeName{DNSSAN("example.com")}, false)
+	ext.Value = append(ext.Value, 0x30)
+	if _, err := UnmarshalSANs([]pkix.Extension{*ext}); err == nil || !strings.Contains(err.Error(), "trailing data") {
+		t.Errorf("expected error with extra data, got %v", err)
+	}
+}
+
+func TestGetTypedSubjectAlternateNames(t *testing.T) {
+	rootCert, rootKey, _ := test.GenerateRootCa()
+	subCert, subKey, _ := test.GenerateSubordinateCa(rootCert, rootKey)
+
+	ext, err := MarshalSANs([]SubjectAlternativeName{
+		URISAN("https://example.com/workflow"),
+		OtherNameSAN(asn1.ObjectIdentifier{1, 2, 3, 4}, "custom-othername"),
+		EmailSAN("user@example.com"),
+	}, true)
+	if err != nil {
+		t.Fatalf("error marshalling SANs: %v", err)
+	}
+	leafCert, _, err := test.GenerateLeafCert("unused", "oidc-issuer", subCert, subKey, *ext)
+	if err != nil {
+		t.Fatalf("error generating leaf certificate: %v", err)
+	}
+	// the standard library parses the same names
+	if len(leafCert.URIs) != 1 || leafCert.URIs[0].String() != "https://example.com/workflow" {
+		t.Errorf("unexpected URIs %v", leafCert.URIs)
+	}
+	if len(leafCert.EmailAddresses) != 1 || leafCert.EmailAddresses[0] != "user@example.com" {
+		t.Errorf("unexpected email addresses %v", leafCert.EmailAddresses)
+	}
+
+	sans, err := GetTypedSubjectAlternateNames(leafCert)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []SubjectAlternativeName{
+		URISAN("https://example.com/workflow"),
+		OtherNameSAN(asn1.ObjectIdentifier{1, 2, 3, 4}, "custom-othername"),
+		EmailSAN("user@example.com"),
+	}
+	if !reflect.DeepEqual(sans, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, sans)
+	}
+	if sans[0].Type == sans[2].Type {
+		t.Fatalf("URI and email SANs must have different types")
+	}
+
+	// unparsed certificates fall back to the decoded fields
+	sans, err = GetTypedSubjectAlternateNames(&x509.Certificate{
+		DNSNames:       []string{"example.com"},
+		EmailAddresses: []string{"user@example.com"},
+		IPAddresses:    []net.IP{{1, 2, 3, 4}},
+		URIs:           []*url.URL{{Scheme: "https", Host: "example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []SubjectAlternativeName{
+		DNSSAN("example.com"),
+		EmailSAN("user@example.com"),
+		IPSAN(net.IP{1, 2, 3, 4}),
+		URISAN("https://example.com"),
+	}
+	if !reflect.DeepEqual(sans, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, sans)
+	}
+}
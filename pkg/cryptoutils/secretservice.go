@@ -0,0 +1,158 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// names of the freedesktop Secret Service D-Bus API, see
+// https://specifications.freedesktop.org/secret-service-spec/latest/
+const (
+	secretServiceName      = "org.freedesktop.secrets"
+	secretServicePath      = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretServiceInterface = "org.freedesktop.Secret.Service"
+	secretItemInterface    = "org.freedesktop.Secret.Item"
+	secretSessionInterface = "org.freedesktop.Secret.Session"
+	secretPromptInterface  = "org.freedesktop.Secret.Prompt"
+	// secretNoPrompt is returned instead of a prompt when none is needed
+	secretNoPrompt = dbus.ObjectPath("/")
+)
+
+// secretServicePromptTimeout is how long the user has to answer a prompt to unlock an item, so that a prompt
+// nobody sees, as in a headless session, does not block forever
+var secretServicePromptTimeout = 2 * time.Minute
+
+// secretServiceSecret is the Secret struct of the Secret Service API
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// PasswordFromSecretService returns a PassFunc which reads the password from the OS keyring through the
+// freedesktop Secret Service D-Bus API on the session bus, as implemented by GNOME Keyring and KWallet. The
+// password is the secret of the first item whose attributes include attributes, for example one stored with
+// "secret-tool store --label=sigstore service sigstore key cosign.key". A locked item is unlocked, which
+// may prompt the user.
+//
+// It returns an error wrapping ErrPasswordUnavailable if there is no session bus, no Secret Service, no
+// matching item, or if the user does not answer the prompt within two minutes.
+func PasswordFromSecretService(attributes map[string]string) PassFunc {
+	return func(bool) ([]byte, error) {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return nil, fmt.Errorf("%w: connecting to the session bus: %v", ErrPasswordUnavailable, err)
+		}
+		defer conn.Close()
+		return readSecretServicePassword(conn, attributes)
+	}
+}
+
+func readSecretServicePassword(conn *dbus.Conn, attributes map[string]string) ([]byte, error) {
+	service := conn.Object(secretServiceName, secretServicePath)
+	// secrets are transferred in plain text over the session bus, which only the user can connect to
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := service.Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		return nil, fmt.Errorf("%w: opening Secret Service session: %v", ErrPasswordUnavailable, err)
+	}
+	defer conn.Object(secretServiceName, session).Call(secretSessionInterface+".Close", 0)
+
+	var unlocked, locked []dbus.ObjectPath
+	if err := service.Call(secretServiceInterface+".SearchItems", 0, attributes).Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("searching Secret Service items: %w", err)
+	}
+	if len(unlocked) == 0 && len(locked) > 0 {
+		var err error
+		if unlocked, err = unlockSecretServiceItems(conn, locked[:1]); err != nil {
+			return nil, err
+		}
+	}
+	if len(unlocked) == 0 {
+		return nil, fmt.Errorf("%w: no Secret Service item matches %v", ErrPasswordUnavailable, attributes)
+	}
+
+	var secret secretServiceSecret
+	if err := conn.Object(secretServiceName, unlocked[0]).Call(secretItemInterface+".GetSecret", 0, session).Store(&secret); err != nil {
+		return nil, fmt.Errorf("getting Secret Service secret: %w", err)
+	}
+	ZeroPassword(secret.Parameters)
+	return secret.Value, nil
+}
+
+// unlockSecretServiceItems unlocks items, waiting for the user to complete a prompt if the service requires one
+func unlockSecretServiceItems(conn *dbus.Conn, items []dbus.ObjectPath) ([]dbus.ObjectPath, error) {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".Unlock", 0, items).Store(&unlocked, &prompt); err != nil {
+		return nil, fmt.Errorf("unlocking Secret Service item: %w", err)
+	}
+	if prompt == secretNoPrompt {
+		return unlocked, nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := conn.AddMatchSignal(match...); err != nil {
+		return nil, fmt.Errorf("watching Secret Service prompt: %w", err)
+	}
+	defer conn.RemoveMatchSignal(match...) //nolint:errcheck
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	if err := conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return nil, fmt.Errorf("prompting to unlock Secret Service item: %w", err)
+	}
+	timeout := time.NewTimer(secretServicePromptTimeout)
+	defer timeout.Stop()
+	for {
+		var signal *dbus.Signal
+		select {
+		case signal = <-signals:
+		case <-timeout.C:
+			_ = conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Dismiss", 0).Err
+			return nil, fmt.Errorf("%w: timed out waiting for the Secret Service prompt", ErrPasswordUnavailable)
+		}
+		if signal == nil {
+			return nil, errors.New("connection closed while waiting for Secret Service prompt")
+		}
+		if signal.Path != prompt || signal.Name != secretPromptInterface+".Completed" {
+			continue
+		}
+		var dismissed bool
+		var result dbus.Variant
+		if err := dbus.Store(signal.Body, &dismissed, &result); err != nil {
+			return nil, fmt.Errorf("parsing Secret Service prompt result: %w", err)
+		}
+		if dismissed {
+			return nil, errors.New("unlocking Secret Service item was dismissed")
+		}
+		if err := result.Store(&unlocked); err != nil {
+			return nil, fmt.Errorf("parsing Secret Service prompt result: %w", err)
+		}
+		return unlocked, nil
+	}
+}
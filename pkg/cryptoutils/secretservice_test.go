@@ -0,0 +1,262 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cryptoutils
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const testBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:tmpdir=%s</listen>
+  <policy context="default">
+    <allow send_destination="*"/>
+    <allow receive_sender="*"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startTestBus starts a private D-Bus daemon and sets it as the session bus
+func startTestBus(t *testing.T) {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+	dir := t.TempDir()
+	config := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(config, []byte(strings.ReplaceAll(testBusConfig, "%s", dir)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(daemon, "--config-file="+config, "--print-address", "--nofork")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Skipf("cannot read dbus-daemon address: %v", err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+}
+
+// fakeSecretService implements the parts of the Secret Service API used by PasswordFromSecretService
+type fakeSecretService struct {
+	conn    *dbus.Conn
+	secrets map[dbus.ObjectPath][]byte
+	locked  map[dbus.ObjectPath]bool
+
+	mu sync.Mutex
+	// dismiss makes the unlock prompt report that the user dismissed it
+	dismiss bool
+	// unanswered makes the unlock prompt never complete, until it is dismissed
+	unanswered bool
+	dismissed  bool
+	closed     bool
+}
+
+const (
+	unlockedItem = dbus.ObjectPath("/org/freedesktop/secrets/collection/login/1")
+	lockedItem   = dbus.ObjectPath("/org/freedesktop/secrets/collection/login/2")
+	testSession  = dbus.ObjectPath("/org/freedesktop/secrets/session/1")
+	testPrompt   = dbus.ObjectPath("/org/freedesktop/secrets/prompt/1")
+)
+
+func newFakeSecretService(t *testing.T) *fakeSecretService {
+	t.Helper()
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s := &fakeSecretService{
+		conn: conn,
+		secrets: map[dbus.ObjectPath][]byte{
+			unlockedItem: []byte("hunter2"),
+			lockedItem:   []byte("correct horse battery staple"),
+		},
+		locked: map[dbus.ObjectPath]bool{lockedItem: true},
+	}
+	exports := []struct {
+		path    dbus.ObjectPath
+		iface   string
+		methods map[string]interface{}
+	}{
+		{secretServicePath, secretServiceInterface, map[string]interface{}{
+			"OpenSession": s.openSession,
+			"SearchItems": s.searchItems,
+			"Unlock":      s.unlock,
+		}},
+		{testSession, secretSessionInterface, map[string]interface{}{"Close": s.closeSession}},
+		{testPrompt, secretPromptInterface, map[string]interface{}{"Prompt": s.prompt, "Dismiss": s.dismissPrompt}},
+		{unlockedItem, secretItemInterface, map[string]interface{}{"GetSecret": s.getSecret(unlockedItem)}},
+		{lockedItem, secretItemInterface, map[string]interface{}{"GetSecret": s.getSecret(lockedItem)}},
+	}
+	for _, e := range exports {
+		if err := conn.ExportMethodTable(e.methods, e.path, e.iface); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reply, err := conn.RequestName(secretServiceName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("cannot own %s: %v, %v", secretServiceName, reply, err)
+	}
+	return s
+}
+
+func (s *fakeSecretService) openSession(algorithm string, _ dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	if algorithm != "plain" {
+		return dbus.Variant{}, "", dbus.MakeFailedError(errors.New("unsupported algorithm"))
+	}
+	return dbus.MakeVariant(""), testSession, nil
+}
+
+func (s *fakeSecretService) closeSession() *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *fakeSecretService) searchItems(attributes map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	var item dbus.ObjectPath
+	switch attributes["key"] {
+	case "unlocked":
+		item = unlockedItem
+	case "locked":
+		item = lockedItem
+	default:
+		return []dbus.ObjectPath{}, []dbus.ObjectPath{}, nil
+	}
+	if s.locked[item] {
+		return []dbus.ObjectPath{}, []dbus.ObjectPath{item}, nil
+	}
+	return []dbus.ObjectPath{item}, []dbus.ObjectPath{}, nil
+}
+
+func (s *fakeSecretService) unlock(_ []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	return []dbus.ObjectPath{}, testPrompt, nil
+}
+
+func (s *fakeSecretService) prompt(_ string) *dbus.Error {
+	s.mu.Lock()
+	dismiss, unanswered := s.dismiss, s.unanswered
+	s.mu.Unlock()
+	if unanswered {
+		return nil
+	}
+	go func() {
+		result := []dbus.ObjectPath{}
+		if !dismiss {
+			result = append(result, lockedItem)
+		}
+		_ = s.conn.Emit(testPrompt, secretPromptInterface+".Completed", dismiss, dbus.MakeVariant(result))
+	}()
+	return nil
+}
+
+func (s *fakeSecretService) dismissPrompt() *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dismissed = true
+	return nil
+}
+
+func (s *fakeSecretService) getSecret(item dbus.ObjectPath) func(dbus.ObjectPath) (secretServiceSecret, *dbus.Error) {
+	return func(session dbus.ObjectPath) (secretServiceSecret, *dbus.Error) {
+		if session != testSession {
+			return secretServiceSecret{}, dbus.MakeFailedError(errors.New("no such session"))
+		}
+		return secretServiceSecret{Session: session, Parameters: []byte{}, Value: s.secrets[item], ContentType: "text/plain"}, nil
+	}
+}
+
+func TestPasswordFromSecretService(t *testing.T) {
+	startTestBus(t)
+
+	// no Secret Service on the bus
+	if _, err := PasswordFromSecretService(map[string]string{"key": "unlocked"})(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable, got %v", err)
+	}
+
+	s := newFakeSecretService(t)
+	pw, err := PasswordFromSecretService(map[string]string{"key": "unlocked"})(false)
+	if err != nil || string(pw) != "hunter2" {
+		t.Fatalf("expected password hunter2, got %q, %v", pw, err)
+	}
+	s.mu.Lock()
+	if !s.closed {
+		t.Error("expected session to be closed")
+	}
+	s.mu.Unlock()
+
+	pw, err = PasswordFromSecretService(map[string]string{"key": "locked"})(false)
+	if err != nil || string(pw) != "correct horse battery staple" {
+		t.Fatalf("expected password from unlocked item, got %q, %v", pw, err)
+	}
+
+	s.mu.Lock()
+	s.dismiss = true
+	s.mu.Unlock()
+	if _, err := PasswordFromSecretService(map[string]string{"key": "locked"})(false); err == nil || errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected error when the prompt is dismissed, got %v", err)
+	}
+
+	if _, err := PasswordFromSecretService(map[string]string{"key": "missing"})(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable, got %v", err)
+	}
+
+	// a prompt nobody answers times out, so that the next password source can be tried
+	defer func(timeout time.Duration) { secretServicePromptTimeout = timeout }(secretServicePromptTimeout)
+	secretServicePromptTimeout = 100 * time.Millisecond
+	s.mu.Lock()
+	s.dismiss, s.unanswered = false, true
+	s.mu.Unlock()
+	if _, err := PasswordFromSecretService(map[string]string{"key": "locked"})(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable when the prompt times out, got %v", err)
+	}
+	s.mu.Lock()
+	if !s.dismissed {
+		t.Error("expected the prompt to be dismissed")
+	}
+	s.mu.Unlock()
+}
+
+func TestPasswordFromSecretServiceNoBus(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+filepath.Join(t.TempDir(), "missing"))
+	if _, err := PasswordFromSecretService(map[string]string{"key": "unlocked"})(false); !errors.Is(err, ErrPasswordUnavailable) {
+		t.Fatalf("expected ErrPasswordUnavailable, got %v", err)
+	}
+}
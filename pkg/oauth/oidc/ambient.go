@@ -0,0 +1,416 @@
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	coreoidc "github.com/coreos/go-oidc/v3/oidc"
+)
+
+// ErrNoAmbientProvider is returned by an ambient `IDTokenSource` when the process does not run in the
+// environment of any of its providers.
+var ErrNoAmbientProvider = errors.New("no ambient ID token provider detected")
+
+// AmbientProvider fetches ID tokens from the CI or cloud environment the process runs in.
+type AmbientProvider interface {
+	// Name identifies the environment in errors.
+	Name() string
+	// Enabled reports whether the process runs in the provider's environment.
+	Enabled(ctx context.Context) bool
+	// Token fetches an encoded ID token for the audience.
+	Token(ctx context.Context, audience string) (string, error)
+}
+
+// DefaultAmbientProviders returns the providers used by `AmbientIDTokenSource` when none are given, in the order
+// they are detected.
+func DefaultAmbientProviders() []AmbientProvider {
+	return []AmbientProvider{
+		&GitHubActionsProvider{},
+		&GitLabProvider{},
+		&BuildkiteProvider{},
+		&CircleCIProvider{},
+		&KubernetesProvider{},
+		&GCEProvider{},
+	}
+}
+
+type ambientIDTokenSource struct {
+	audience  string
+	providers []AmbientProvider
+}
+
+func (s *ambientIDTokenSource) IDToken(ctx context.Context) (*IDToken, error) {
+	for _, p := range s.providers {
+		if !p.Enabled(ctx) {
+			continue
+		}
+		rawToken, err := p.Token(ctx, s.audience)
+		if err != nil {
+			return nil, fmt.Errorf("fetching ID token from %s: %w", p.Name(), err)
+		}
+		idToken, err := parseUnverifiedIDToken(ctx, rawToken, s.audience)
+		if err != nil {
+			return nil, fmt.Errorf("parsing ID token from %s: %w", p.Name(), err)
+		}
+		return idToken, nil
+	}
+	return nil, ErrNoAmbientProvider
+}
+
+// AmbientIDTokenSource returns an `IDTokenSource` which fetches an ID token for the audience from the first of the
+// providers whose environment is detected, or of `DefaultAmbientProviders` if none are given.
+//
+// The signature of the token is not verified, as the token comes directly from the environment, but the token must
+// not be expired and, if audience is not empty, must be issued for the audience.
+func AmbientIDTokenSource(audience string, providers ...AmbientProvider) IDTokenSource {
+	if len(providers) == 0 {
+		providers = DefaultAmbientProviders()
+	}
+	return &ambientIDTokenSource{audience: audience, providers: providers}
+}
+
+func parseUnverifiedIDToken(ctx context.Context, rawToken, audience string) (*IDToken, error) {
+	verifier := coreoidc.NewVerifier("", nil, &coreoidc.Config{
+		ClientID:                   audience,
+		SkipClientIDCheck:          audience == "",
+		SkipIssuerCheck:            true,
+		InsecureSkipSignatureCheck: true,
+	})
+	idToken, err := verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, err
+	}
+	return &IDToken{IDToken: *idToken, RawToken: rawToken}, nil
+}
+
+// maxAmbientResponseSize limits the size of responses read from token endpoints
+const maxAmbientResponseSize = 1 << 20
+
+// doAmbientRequest sends the request and returns the body of a successful response
+func doAmbientRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAmbientResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL.Redacted(), resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
+
+// Environment variables read by the ambient providers
+const (
+	// GitHubActionsRequestURLEnv is the URL of the GitHub Actions ID token endpoint
+	GitHubActionsRequestURLEnv = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	// GitHubActionsRequestTokenEnv is the bearer token for the GitHub Actions ID token endpoint
+	GitHubActionsRequestTokenEnv = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+	// SigstoreIDTokenEnv holds an ID token provided by the environment, such as a GitLab CI/CD id_tokens entry
+	SigstoreIDTokenEnv = "SIGSTORE_ID_TOKEN"
+	// BuildkiteAgentAccessTokenEnv is the access token of the Buildkite agent running the job
+	BuildkiteAgentAccessTokenEnv = "BUILDKITE_AGENT_ACCESS_TOKEN"
+	// BuildkiteAgentEndpointEnv is the URL of the Buildkite agent API
+	BuildkiteAgentEndpointEnv = "BUILDKITE_AGENT_ENDPOINT"
+	// BuildkiteJobIDEnv is the ID of the Buildkite job
+	BuildkiteJobIDEnv = "BUILDKITE_JOB_ID"
+	// CircleCIEnv is set to "true" in CircleCI jobs
+	CircleCIEnv = "CIRCLECI"
+	// CircleCIOIDCTokenEnv holds the ID token CircleCI issues for the job, for the organization's audience
+	CircleCIOIDCTokenEnv = "CIRCLE_OIDC_TOKEN_V2"
+	// GCEMetadataHostEnv overrides the host of the GCE metadata server
+	GCEMetadataHostEnv = "GCE_METADATA_HOST"
+)
+
+// GitHubActionsProvider fetches ID tokens from the GitHub Actions ID token endpoint. It is enabled in workflows with
+// the id-token: write permission.
+type GitHubActionsProvider struct {
+	// HTTPClient defaults to `http.DefaultClient`
+	HTTPClient *http.Client
+}
+
+// Name implements `AmbientProvider`
+func (*GitHubActionsProvider) Name() string {
+	return "GitHub Actions"
+}
+
+// Enabled implements `AmbientProvider`
+func (*GitHubActionsProvider) Enabled(context.Context) bool {
+	return os.Getenv(GitHubActionsRequestURLEnv) != "" && os.Getenv(GitHubActionsRequestTokenEnv) != ""
+}
+
+// Token implements `AmbientProvider`
+func (p *GitHubActionsProvider) Token(ctx context.Context, audience string) (string, error) {
+	u, err := url.Parse(os.Getenv(GitHubActionsRequestURLEnv))
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", GitHubActionsRequestURLEnv, err)
+	}
+	if audience != "" {
+		q := u.Query()
+		q.Set("audience", audience)
+		u.RawQuery = q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv(GitHubActionsRequestTokenEnv))
+	body, err := doAmbientRequest(p.HTTPClient, req)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+	return resp.Value, nil
+}
+
+// GitLabProvider reads the ID token from the SIGSTORE_ID_TOKEN environment variable, which GitLab CI/CD sets for jobs
+// declaring it in id_tokens. The audience is chosen in the job definition.
+type GitLabProvider struct{}
+
+// Name implements `AmbientProvider`
+func (*GitLabProvider) Name() string {
+	return "GitLab"
+}
+
+// Enabled implements `AmbientProvider`
+func (*GitLabProvider) Enabled(context.Context) bool {
+	return os.Getenv(SigstoreIDTokenEnv) != ""
+}
+
+// Token implements `AmbientProvider`
+func (*GitLabProvider) Token(context.Context, string) (string, error) {
+	return os.Getenv(SigstoreIDTokenEnv), nil
+}
+
+// BuildkiteProvider fetches ID tokens for the job from the Buildkite agent API, as `buildkite-agent oidc request-token` does.
+type BuildkiteProvider struct {
+	// HTTPClient defaults to `http.DefaultClient`
+	HTTPClient *http.Client
+}
+
+// defaultBuildkiteAgentEndpoint is used when BUILDKITE_AGENT_ENDPOINT is not set
+const defaultBuildkiteAgentEndpoint = "https://agent.buildkite.com/v3"
+
+// Name implements `AmbientProvider`
+func (*BuildkiteProvider) Name() string {
+	return "Buildkite"
+}
+
+// Enabled implements `AmbientProvider`
+func (*BuildkiteProvider) Enabled(context.Context) bool {
+	return os.Getenv(BuildkiteAgentAccessTokenEnv) != "" && os.Getenv(BuildkiteJobIDEnv) != ""
+}
+
+// Token implements `AmbientProvider`
+func (p *BuildkiteProvider) Token(ctx context.Context, audience string) (string, error) {
+	endpoint := os.Getenv(BuildkiteAgentEndpointEnv)
+	if endpoint == "" {
+		endpoint = defaultBuildkiteAgentEndpoint
+	}
+	reqBody, err := json.Marshal(struct {
+		Audience string `json:"audience,omitempty"`
+	}{Audience: audience})
+	if err != nil {
+		return "", err
+	}
+	tokenURL := strings.TrimSuffix(endpoint, "/") + "/jobs/" + url.PathEscape(os.Getenv(BuildkiteJobIDEnv)) + "/oidc/tokens"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Token "+os.Getenv(BuildkiteAgentAccessTokenEnv))
+	req.Header.Set("Content-Type", "application/json")
+	body, err := doAmbientRequest(p.HTTPClient, req)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+	return resp.Token, nil
+}
+
+// CircleCIProvider fetches ID tokens for an audience with `circleci run oidc get`. Without an audience, it returns the
+// token CircleCI issues for the job in CIRCLE_OIDC_TOKEN_V2.
+type CircleCIProvider struct{}
+
+// Name implements `AmbientProvider`
+func (*CircleCIProvider) Name() string {
+	return "CircleCI"
+}
+
+// Enabled implements `AmbientProvider`
+func (*CircleCIProvider) Enabled(context.Context) bool {
+	return os.Getenv(CircleCIEnv) == "true"
+}
+
+// Token implements `AmbientProvider`
+func (*CircleCIProvider) Token(ctx context.Context, audience string) (string, error) {
+	if audience == "" {
+		return os.Getenv(CircleCIOIDCTokenEnv), nil
+	}
+	claims, err := json.Marshal(map[string]string{"aud": audience})
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "circleci", "run", "oidc", "get", "--claims", string(claims))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("running circleci run oidc get: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// DefaultKubernetesTokenPath is where `KubernetesProvider` reads the token from by default. A projected service account
+// token volume with the audience should be mounted at this path.
+const DefaultKubernetesTokenPath = "/var/run/sigstore/cosign/oidc-token"
+
+// KubernetesProvider reads the ID token from a projected service account token file. The audience is chosen in the
+// volume definition, and the kubelet rotates the token before it expires.
+type KubernetesProvider struct {
+	// TokenPath defaults to `DefaultKubernetesTokenPath`
+	TokenPath string
+}
+
+func (p *KubernetesProvider) tokenPath() string {
+	if p.TokenPath == "" {
+		return DefaultKubernetesTokenPath
+	}
+	return p.TokenPath
+}
+
+// Name implements `AmbientProvider`
+func (*KubernetesProvider) Name() string {
+	return "Kubernetes"
+}
+
+// Enabled implements `AmbientProvider`
+func (p *KubernetesProvider) Enabled(context.Context) bool {
+	info, err := os.Stat(p.tokenPath())
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Token implements `AmbientProvider`
+func (p *KubernetesProvider) Token(context.Context, string) (string, error) {
+	token, err := os.ReadFile(p.tokenPath())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// GCEProvider fetches ID tokens for the default service account of the instance from the GCE metadata server, which is
+// also served on GKE with workload identity and on Cloud Run.
+type GCEProvider struct {
+	// HTTPClient defaults to `http.DefaultClient`
+	HTTPClient *http.Client
+	// Host defaults to GCE_METADATA_HOST, or metadata.google.internal if it is not set
+	Host string
+}
+
+// gceDetectionTimeout bounds the request detecting the metadata server, which does not answer outside of GCE
+const gceDetectionTimeout = time.Second
+
+func (p *GCEProvider) host() string {
+	if p.Host != "" {
+		return p.Host
+	}
+	if host := os.Getenv(GCEMetadataHostEnv); host != "" {
+		return host
+	}
+	return "metadata.google.internal"
+}
+
+func (p *GCEProvider) newRequest(ctx context.Context, path string, query url.Values) (*http.Request, error) {
+	u := url.URL{Scheme: "http", Host: p.host(), Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return req, nil
+}
+
+// Name implements `AmbientProvider`
+func (*GCEProvider) Name() string {
+	return "GCE metadata server"
+}
+
+// Enabled implements `AmbientProvider`
+func (p *GCEProvider) Enabled(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, gceDetectionTimeout)
+	defer cancel()
+	req, err := p.newRequest(ctx, "/", nil)
+	if err != nil {
+		return false
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Metadata-Flavor") == "Google"
+}
+
+// Token implements `AmbientProvider`
+func (p *GCEProvider) Token(ctx context.Context, audience string) (string, error) {
+	if audience == "" {
+		return "", errors.New("the metadata server requires an audience")
+	}
+	req, err := p.newRequest(ctx, "/computeMetadata/v1/instance/service-accounts/default/identity", url.Values{
+		"audience": {audience},
+		"format":   {"full"},
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := doAmbientRequest(p.HTTPClient, req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,280 @@
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIDToken returns an unsigned JWT for the audience, which is all ambient providers need to parse
+func testIDToken(t *testing.T, audience string, expiry time.Time) string {
+	t.Helper()
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"sub": "repo:sigstore/sigstore",
+		"aud": audience,
+		"exp": expiry.Unix(),
+		"iat": time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"ES256"}`)) + "." + enc.EncodeToString(claims) + "." + enc.EncodeToString([]byte("signature"))
+}
+
+// clearAmbientEnv unsets the environment variables of every provider
+func clearAmbientEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		GitHubActionsRequestURLEnv, GitHubActionsRequestTokenEnv, SigstoreIDTokenEnv, BuildkiteAgentAccessTokenEnv,
+		BuildkiteAgentEndpointEnv, BuildkiteJobIDEnv, CircleCIEnv, CircleCIOIDCTokenEnv, GCEMetadataHostEnv,
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func checkAmbientIDToken(t *testing.T, p AmbientProvider, audience, expected string) {
+	t.Helper()
+	if !p.Enabled(context.Background()) {
+		t.Fatalf("expected %s to be enabled", p.Name())
+	}
+	idToken, err := AmbientIDTokenSource(audience, p).IDToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error getting ID token: %v", err)
+	}
+	if idToken.RawToken != expected {
+		t.Fatalf("expected raw token %q, got %q", expected, idToken.RawToken)
+	}
+	if idToken.Subject != "repo:sigstore/sigstore" || len(idToken.Audience) != 1 || idToken.Audience[0] != audience {
+		t.Fatalf("unexpected claims: subject %q, audience %v", idToken.Subject, idToken.Audience)
+	}
+}
+
+func TestGitHubActionsProvider(t *testing.T) {
+	clearAmbientEnv(t)
+	p := &GitHubActionsProvider{}
+	if p.Enabled(context.Background()) {
+		t.Fatal("expected provider to be disabled")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer request-token" || r.URL.Query().Get("api-version") != "2.0" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"value": testIDToken(t, r.URL.Query().Get("audience"), time.Now().Add(time.Hour))})
+	}))
+	defer server.Close()
+	t.Setenv(GitHubActionsRequestURLEnv, server.URL+"?api-version=2.0")
+	t.Setenv(GitHubActionsRequestTokenEnv, "request-token")
+	p.HTTPClient = server.Client()
+
+	raw, err := p.Token(context.Background(), "sigstore")
+	if err != nil {
+		t.Fatalf("unexpected error fetching token: %v", err)
+	}
+	checkAmbientIDToken(t, p, "sigstore", raw)
+
+	t.Setenv(GitHubActionsRequestTokenEnv, "wrong")
+	if _, err := AmbientIDTokenSource("sigstore", p).IDToken(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected error from the token endpoint, got %v", err)
+	}
+}
+
+func TestGitLabProvider(t *testing.T) {
+	clearAmbientEnv(t)
+	p := &GitLabProvider{}
+	if p.Enabled(context.Background()) {
+		t.Fatal("expected provider to be disabled")
+	}
+	token := testIDToken(t, "sigstore", time.Now().Add(time.Hour))
+	t.Setenv(SigstoreIDTokenEnv, token)
+	checkAmbientIDToken(t, p, "sigstore", token)
+
+	// the audience is chosen in the job definition, so a mismatch is an error
+	if _, err := AmbientIDTokenSource("other", p).IDToken(context.Background()); err == nil {
+		t.Fatal("expected error for a token issued for another audience")
+	}
+}
+
+func TestBuildkiteProvider(t *testing.T) {
+	clearAmbientEnv(t)
+	p := &BuildkiteProvider{}
+	if p.Enabled(context.Background()) {
+		t.Fatal("expected provider to be disabled")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v3/jobs/job-1/oidc/tokens" || r.Header.Get("Authorization") != "Token agent-token" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Audience string `json:"audience"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": testIDToken(t, body.Audience, time.Now().Add(time.Hour))})
+	}))
+	defer server.Close()
+	t.Setenv(BuildkiteAgentEndpointEnv, server.URL+"/v3")
+	t.Setenv(BuildkiteAgentAccessTokenEnv, "agent-token")
+	t.Setenv(BuildkiteJobIDEnv, "job-1")
+	p.HTTPClient = server.Client()
+
+	raw, err := p.Token(context.Background(), "sigstore")
+	if err != nil {
+		t.Fatalf("unexpected error fetching token: %v", err)
+	}
+	checkAmbientIDToken(t, p, "sigstore", raw)
+}
+
+func TestCircleCIProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of the circleci CLI")
+	}
+	clearAmbientEnv(t)
+	p := &CircleCIProvider{}
+	if p.Enabled(context.Background()) {
+		t.Fatal("expected provider to be disabled")
+	}
+	t.Setenv(CircleCIEnv, "true")
+
+	// stand in for the circleci CLI, printing a token only when asked for the expected audience
+	token := testIDToken(t, "sigstore", time.Now().Add(time.Hour))
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		`[ "$*" = 'run oidc get --claims {"aud":"sigstore"}' ] || { echo "unexpected arguments: $*" >&2; exit 1; }` + "\n" +
+		"echo " + token + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "circleci"), []byte(script), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	checkAmbientIDToken(t, p, "sigstore", token)
+
+	if _, err := p.Token(context.Background(), "other"); err == nil || !strings.Contains(err.Error(), "unexpected arguments") {
+		t.Fatalf("expected error with the CLI output, got %v", err)
+	}
+
+	// without an audience, the job's token is used
+	jobToken := testIDToken(t, "org-id", time.Now().Add(time.Hour))
+	t.Setenv(CircleCIOIDCTokenEnv, jobToken)
+	if raw, err := p.Token(context.Background(), ""); err != nil || raw != jobToken {
+		t.Fatalf("expected job token, got %q, %v", raw, err)
+	}
+}
+
+func TestKubernetesProvider(t *testing.T) {
+	p := &KubernetesProvider{TokenPath: filepath.Join(t.TempDir(), "oidc-token")}
+	if p.Enabled(context.Background()) {
+		t.Fatal("expected provider to be disabled")
+	}
+	token := testIDToken(t, "sigstore", time.Now().Add(time.Hour))
+	if err := os.WriteFile(p.TokenPath, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	checkAmbientIDToken(t, p, "sigstore", token)
+}
+
+func TestGCEProvider(t *testing.T) {
+	clearAmbientEnv(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor header", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+		switch r.URL.Path {
+		case "/":
+		case "/computeMetadata/v1/instance/service-accounts/default/identity":
+			if r.URL.Query().Get("format") != "full" {
+				http.Error(w, "unexpected format", http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(testIDToken(t, r.URL.Query().Get("audience"), time.Now().Add(time.Hour))))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	// not a metadata server
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+	if (&GCEProvider{Host: strings.TrimPrefix(other.URL, "http://")}).Enabled(context.Background()) {
+		t.Fatal("expected provider to be disabled")
+	}
+
+	p := &GCEProvider{Host: host}
+	raw, err := p.Token(context.Background(), "sigstore")
+	if err != nil {
+		t.Fatalf("unexpected error fetching token: %v", err)
+	}
+	checkAmbientIDToken(t, p, "sigstore", raw)
+	if _, err := p.Token(context.Background(), ""); err == nil {
+		t.Fatal("expected error without an audience")
+	}
+
+	// the host can be set in the environment
+	t.Setenv(GCEMetadataHostEnv, host)
+	if !(&GCEProvider{}).Enabled(context.Background()) {
+		t.Fatalf("expected provider to be enabled with %s", GCEMetadataHostEnv)
+	}
+}
+
+func TestAmbientIDTokenSource(t *testing.T) {
+	clearAmbientEnv(t)
+	kubernetes := &KubernetesProvider{TokenPath: filepath.Join(t.TempDir(), "oidc-token")}
+	if _, err := AmbientIDTokenSource("sigstore", &GitLabProvider{}, kubernetes).IDToken(context.Background()); !errors.Is(err, ErrNoAmbientProvider) {
+		t.Fatalf("expected ErrNoAmbientProvider, got %v", err)
+	}
+
+	// the first enabled provider is used
+	token := testIDToken(t, "sigstore", time.Now().Add(time.Hour))
+	if err := os.WriteFile(kubernetes.TokenPath, []byte(token), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SigstoreIDTokenEnv, testIDToken(t, "sigstore", time.Now().Add(time.Hour)))
+	idToken, err := AmbientIDTokenSource("sigstore", kubernetes, &GitLabProvider{}).IDToken(context.Background())
+	if err != nil || idToken.RawToken != token {
+		t.Fatalf("expected token from the first provider, got %v", err)
+	}
+
+	expired := testIDToken(t, "sigstore", time.Now().Add(-time.Minute))
+	t.Setenv(SigstoreIDTokenEnv, expired)
+	if _, err := AmbientIDTokenSource("sigstore", &GitLabProvider{}).IDToken(context.Background()); err == nil || !strings.Contains(err.Error(), "GitLab") {
+		t.Fatalf("expected error for an expired token naming the provider, got %v", err)
+	}
+
+	// without an audience, any audience is accepted
+	if _, err := AmbientIDTokenSource("", kubernetes).IDToken(context.Background()); err != nil {
+		t.Fatalf("unexpected error without an audience: %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	coreoidc "github.com/coreos/go-oidc/v3/oidc"
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// DefaultExpiryMargin is how long before its expiry a cached `IDToken` is replaced by default
+const DefaultExpiryMargin = time.Minute
+
+// IDTokenRefresher is implemented by `IDTokenSource`s which can obtain a new `IDToken` with the refresh token of a
+// previous one, such as the one returned by `InteractiveIDTokenSource`.
+type IDTokenRefresher interface {
+	// RefreshIDToken returns a new ID token using the refresh token of idToken, or an error.
+	RefreshIDToken(ctx context.Context, idToken *IDToken) (*IDToken, error)
+}
+
+// CachingOptions configures `CachingIDTokenSource`. Zero values select the defaults.
+type CachingOptions struct {
+	// ExpiryMargin is how long before its expiry a cached `IDToken` is replaced, `DefaultExpiryMargin` by default
+	ExpiryMargin time.Duration
+	// CacheKey identifies the source, for example by its issuer and client ID. If set, `IDToken`s are also persisted,
+	// encrypted with the password returned by PassFunc, so that they can be reused by later processes.
+	CacheKey string
+	// PassFunc returns the password encrypting persisted `IDToken`s. It is required if CacheKey is set. It is called
+	// at most once, when a persisted `IDToken` is first read or written, and the password is kept in memory for later
+	// reads and writes, so that an interactive PassFunc prompts only once.
+	PassFunc cryptoutils.PassFunc
+	// CacheDir is where `IDToken`s are persisted, the sigstore/oidc directory under `os.UserCacheDir` by default
+	CacheDir string
+	// OnPersistError, if set, is called with the error when a persisted `IDToken` cannot be read or written. Such
+	// errors are otherwise ignored, as the `IDToken` can still be obtained from the source.
+	OnPersistError func(error)
+}
+
+// idTokenCall is a flow in progress, which concurrent callers wait for
+type idTokenCall struct {
+	done    chan struct{}
+	idToken *IDToken
+	err     error
+}
+
+type cachingIDTokenSource struct {
+	src  IDTokenSource
+	opts CachingOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	idToken  *IDToken
+	inflight *idTokenCall
+
+	// password encrypts persisted `IDToken`s once obtained from opts.PassFunc. It is only used by the fetch in
+	// progress, of which there is at most one.
+	password []byte
+}
+
+// CachingIDTokenSource returns an `IDTokenSource` which returns the `IDToken` obtained from src until shortly before
+// it expires. A new `IDToken` is then obtained with the refresh token of the cached one if src implements
+// `IDTokenRefresher`, or from src otherwise. Concurrent calls wait for a single call to src, which is not cancelled
+// when the context of the call that started it is done, so src must bound how long it takes.
+func CachingIDTokenSource(src IDTokenSource, opts *CachingOptions) (IDTokenSource, error) {
+	s := &cachingIDTokenSource{src: src, now: time.Now}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.ExpiryMargin == 0 {
+		s.opts.ExpiryMargin = DefaultExpiryMargin
+	}
+	if s.opts.CacheKey != "" {
+		if s.opts.PassFunc == nil {
+			return nil, errors.New("a PassFunc is required to persist ID tokens")
+		}
+		if s.opts.CacheDir == "" {
+			dir, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("finding the user cache directory: %w", err)
+			}
+			s.opts.CacheDir = filepath.Join(dir, "sigstore", "oidc")
+		}
+	}
+	return s, nil
+}
+
+func (s *cachingIDTokenSource) valid(idToken *IDToken) bool {
+	return idToken != nil && s.now().Add(s.opts.ExpiryMargin).Before(idToken.Expiry)
+}
+
+func (s *cachingIDTokenSource) IDToken(ctx context.Context) (*IDToken, error) {
+	s.mu.Lock()
+	if s.valid(s.idToken) {
+		idToken := s.idToken
+		s.mu.Unlock()
+		return idToken, nil
+	}
+	call := s.inflight
+	if call == nil {
+		call = &idTokenCall{done: make(chan struct{})}
+		s.inflight = call
+		// the call is shared, so it must not fail because the context of this caller is done
+		go s.run(context.WithoutCancel(ctx), call, s.idToken)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.idToken, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run performs call, caching its `IDToken` on success
+func (s *cachingIDTokenSource) run(ctx context.Context, call *idTokenCall, previous *IDToken) {
+	call.idToken, call.err = s.fetch(ctx, previous)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if call.err == nil {
+		s.idToken = call.idToken
+	}
+	s.inflight = nil
+	close(call.done)
+}
+
+// fetch returns a persisted, refreshed or new `IDToken`, in that order of preference
+func (s *cachingIDTokenSource) fetch(ctx context.Context, previous *IDToken) (*IDToken, error) {
+	if previous == nil && s.opts.CacheKey != "" {
+		// a missing or unreadable cache entry only means the token has to be obtained again
+		var err error
+		if previous, err = s.load(ctx); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			s.persistError(fmt.Errorf("reading cached ID token: %w", err))
+		}
+		if s.valid(previous) {
+			return previous, nil
+		}
+	}
+
+	var idToken *IDToken
+	if refresher, ok := s.src.(IDTokenRefresher); ok && previous != nil && previous.RefreshToken != "" {
+		idToken, _ = refresher.RefreshIDToken(ctx, previous)
+	}
+	if idToken == nil {
+		var err error
+		if idToken, err = s.src.IDToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.opts.CacheKey != "" {
+		if err := s.save(idToken); err != nil {
+			s.persistError(fmt.Errorf("caching ID token: %w", err))
+		}
+	}
+	return idToken, nil
+}
+
+// cachedIDToken is the persisted form of an `IDToken`
+type cachedIDToken struct {
+	RawToken     string `json:"raw_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func (s *cachingIDTokenSource) persistError(err error) {
+	if s.opts.OnPersistError != nil {
+		s.opts.OnPersistError(err)
+	}
+}
+
+// getPassword returns the password encrypting persisted `IDToken`s, calling opts.PassFunc the first time only
+func (s *cachingIDTokenSource) getPassword(confirm bool) ([]byte, error) {
+	if s.password != nil {
+		return s.password, nil
+	}
+	password, err := s.opts.PassFunc(confirm)
+	if err != nil {
+		return nil, err
+	}
+	if password == nil {
+		return nil, errors.New("password was nil")
+	}
+	// keep a copy, as the PassFunc may reuse the slice it returned
+	s.password = bytes.Clone(password)
+	return s.password, nil
+}
+
+func (s *cachingIDTokenSource) path() string {
+	key := sha256.Sum256([]byte(s.opts.CacheKey))
+	return filepath.Join(s.opts.CacheDir, hex.EncodeToString(key[:]))
+}
+
+func (s *cachingIDTokenSource) load(ctx context.Context) (*IDToken, error) {
+	ciphertext, err := os.ReadFile(s.path())
+	if err != nil {
+		return nil, err
+	}
+	password, err := s.getPassword(false)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := encrypted.Decrypt(ciphertext, password)
+	if err != nil {
+		return nil, err
+	}
+	var cached cachedIDToken
+	if err := json.Unmarshal(plaintext, &cached); err != nil {
+		return nil, err
+	}
+
+	// the token was verified before it was persisted, and the encryption authenticates it
+	verifier := coreoidc.NewVerifier("", nil, &coreoidc.Config{
+		SkipClientIDCheck:          true,
+		SkipIssuerCheck:            true,
+		SkipExpiryCheck:            true,
+		InsecureSkipSignatureCheck: true,
+	})
+	idToken, err := verifier.Verify(ctx, cached.RawToken)
+	if err != nil {
+		return nil, err
+	}
+	return &IDToken{IDToken: *idToken, RawToken: cached.RawToken, RefreshToken: cached.RefreshToken}, nil
+}
+
+func (s *cachingIDTokenSource) save(idToken *IDToken) error {
+	plaintext, err := json.Marshal(cachedIDToken{RawToken: idToken.RawToken, RefreshToken: idToken.RefreshToken})
+	if err != nil {
+		return err
+	}
+	password, err := s.getPassword(true)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := encrypted.Encrypt(plaintext, password)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.opts.CacheDir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.opts.CacheDir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(ciphertext); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path())
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+// countingSource returns a new ID token valid for an hour on each call
+type countingSource struct {
+	t            *testing.T
+	refreshToken string
+	// release, if set, blocks calls until it is closed
+	release chan struct{}
+
+	mu        sync.Mutex
+	calls     int
+	refreshes int
+}
+
+func (s *countingSource) newIDToken() *IDToken {
+	idToken, err := parseUnverifiedIDToken(context.Background(), testIDToken(s.t, "sigstore", time.Now().Add(time.Hour)), "")
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	idToken.RefreshToken = s.refreshToken
+	return idToken
+}
+
+func (s *countingSource) IDToken(ctx context.Context) (*IDToken, error) {
+	if s.release != nil {
+		select {
+		case <-s.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	return s.newIDToken(), nil
+}
+
+func (s *countingSource) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, s.refreshes
+}
+
+// refreshingSource also implements IDTokenRefresher
+type refreshingSource struct {
+	*countingSource
+}
+
+func (s refreshingSource) RefreshIDToken(_ context.Context, idToken *IDToken) (*IDToken, error) {
+	if idToken.RefreshToken != s.refreshToken {
+		return nil, errors.New("invalid refresh token")
+	}
+	s.mu.Lock()
+	s.refreshes++
+	s.mu.Unlock()
+	return s.newIDToken(), nil
+}
+
+func newTestCachingSource(t *testing.T, src IDTokenSource, opts *CachingOptions) *cachingIDTokenSource {
+	t.Helper()
+	s, err := CachingIDTokenSource(src, opts)
+	if err != nil {
+		t.Fatalf("unexpected error creating caching source: %v", err)
+	}
+	return s.(*cachingIDTokenSource)
+}
+
+func TestCachingIDTokenSource(t *testing.T) {
+	ctx := context.Background()
+	src := &countingSource{t: t}
+	s := newTestCachingSource(t, src, nil)
+
+	first, err := s.IDToken(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := s.IDToken(ctx)
+	if err != nil || second != first {
+		t.Fatalf("expected the cached token, got %v", err)
+	}
+	if calls, _ := src.counts(); calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+
+	// shortly before the token expires, a new one is obtained
+	s.now = func() time.Time { return first.Expiry.Add(-DefaultExpiryMargin / 2) }
+	third, err := s.IDToken(ctx)
+	if err != nil || third == first {
+		t.Fatalf("expected a new token, got %v", err)
+	}
+	if calls, _ := src.counts(); calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestCachingIDTokenSourceConcurrent(t *testing.T) {
+	src := &countingSource{t: t, release: make(chan struct{})}
+	s := newTestCachingSource(t, src, nil)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]*IDToken, callers)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			idToken, err := s.IDToken(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = idToken
+		}(i)
+	}
+	// a waiting caller can give up without affecting the others
+	for inflight := false; !inflight; {
+		s.mu.Lock()
+		inflight = s.inflight != nil
+		s.mu.Unlock()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.IDToken(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	close(src.release)
+	wg.Wait()
+
+	if calls, _ := src.counts(); calls != 1 {
+		t.Fatalf("expected concurrent callers to share 1 call, got %d", calls)
+	}
+	for _, idToken := range results {
+		if idToken != results[0] {
+			t.Fatal("expected every caller to get the same token")
+		}
+	}
+}
+
+func TestCachingIDTokenSourceLeaderCancelled(t *testing.T) {
+	src := &countingSource{t: t, release: make(chan struct{})}
+	s := newTestCachingSource(t, src, nil)
+
+	// the first caller starts the call, then gives up
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := s.IDToken(leaderCtx)
+		leaderErr <- err
+	}()
+	for inflight := false; !inflight; {
+		s.mu.Lock()
+		inflight = s.inflight != nil
+		s.mu.Unlock()
+	}
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := s.IDToken(context.Background())
+		waiterErr <- err
+	}()
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled for the leader, got %v", err)
+	}
+
+	// the waiter still gets the token
+	close(src.release)
+	if err := <-waiterErr; err != nil {
+		t.Fatalf("unexpected error for the waiter: %v", err)
+	}
+	if calls, _ := src.counts(); calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestCachingIDTokenSourceRefresh(t *testing.T) {
+	ctx := context.Background()
+	src := refreshingSource{&countingSource{t: t, refreshToken: "refresh-token"}}
+	s := newTestCachingSource(t, src, &CachingOptions{ExpiryMargin: 5 * time.Minute})
+
+	first, err := s.IDToken(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return first.Expiry.Add(-time.Minute) }
+	if _, err := s.IDToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls, refreshes := src.counts(); calls != 1 || refreshes != 1 {
+		t.Fatalf("expected 1 call and 1 refresh, got %d and %d", calls, refreshes)
+	}
+
+	// a failed refresh falls back to the source
+	src.refreshToken = "rotated"
+	s.now = func() time.Time { return first.Expiry.Add(time.Hour) }
+	if _, err := s.IDToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls, refreshes := src.counts(); calls != 2 || refreshes != 1 {
+		t.Fatalf("expected 2 calls and 1 refresh, got %d and %d", calls, refreshes)
+	}
+}
+
+func TestCachingIDTokenSourcePersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var prompts int
+	opts := &CachingOptions{
+		CacheKey: "https://oauth2.sigstore.dev/auth sigstore",
+		PassFunc: func(bool) ([]byte, error) {
+			prompts++
+			return []byte("hunter2"), nil
+		},
+		CacheDir: dir,
+	}
+	src := &countingSource{t: t, refreshToken: "refresh-token"}
+	idToken, err := newTestCachingSource(t, src, opts).IDToken(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 cache entry, got %v, %v", entries, err)
+	}
+	contents, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(contents, []byte(idToken.RawToken)) || bytes.Contains(contents, []byte("refresh-token")) {
+		t.Fatal("expected the cache entry to be encrypted")
+	}
+
+	// another process reuses the persisted token
+	persisted, err := newTestCachingSource(t, src, opts).IDToken(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if persisted.RawToken != idToken.RawToken || persisted.RefreshToken != "refresh-token" || !persisted.Expiry.Equal(idToken.Expiry) {
+		t.Fatal("expected the persisted token")
+	}
+	if calls, _ := src.counts(); calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+
+	// an expired persisted token is refreshed
+	refreshing := refreshingSource{src}
+	s := newTestCachingSource(t, refreshing, opts)
+	s.now = func() time.Time { return idToken.Expiry.Add(time.Hour) }
+	if _, err := s.IDToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls, refreshes := src.counts(); calls != 1 || refreshes != 1 {
+		t.Fatalf("expected 1 call and 1 refresh, got %d and %d", calls, refreshes)
+	}
+
+	// the password is obtained once per source, however often tokens are read and written
+	prompts = 0
+	s.now = func() time.Time { return idToken.Expiry.Add(2 * time.Hour) }
+	if _, err := s.IDToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prompts != 0 {
+		t.Fatalf("expected the password to be reused, got %d prompts", prompts)
+	}
+
+	// a cache entry which cannot be decrypted is ignored and reported
+	var persistErrs []error
+	wrongPassword := *opts
+	wrongPassword.PassFunc = cryptoutils.StaticPasswordFunc([]byte("wrong"))
+	wrongPassword.OnPersistError = func(err error) { persistErrs = append(persistErrs, err) }
+	if _, err := newTestCachingSource(t, src, &wrongPassword).IDToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls, _ := src.counts(); calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+	if len(persistErrs) != 1 {
+		t.Fatalf("expected 1 persistence error, got %v", persistErrs)
+	}
+
+	if _, err := CachingIDTokenSource(src, &CachingOptions{CacheKey: "key"}); err == nil {
+		t.Fatal("expected error without a PassFunc")
+	}
+}
@@ -214,6 +214,27 @@ func (idts *interactiveIDTokenSource) IDToken(ctx context.Context) (*IDToken, er
 	return extractAndVerifyIDToken(ctx, token, verifier, nonce)
 }
 
+// RefreshIDToken implements `IDTokenRefresher` by redeeming the refresh token of idToken at the token endpoint.
+func (idts *interactiveIDTokenSource) RefreshIDToken(ctx context.Context, idToken *IDToken) (*IDToken, error) {
+	if idToken.RefreshToken == "" {
+		return nil, errors.New("no refresh token")
+	}
+	token, err := idts.cfg.TokenSource(ctx, &oauth2.Token{RefreshToken: idToken.RefreshToken}).Token()
+	if err != nil {
+		return nil, err
+	}
+	verifier := idts.oidp.Verifier(&coreoidc.Config{ClientID: idts.cfg.ClientID})
+	refreshed, err := extractAndVerifyIDTokenWithoutNonce(ctx, token, verifier)
+	if err != nil {
+		return nil, err
+	}
+	// a refreshed ID token should not have a nonce, but if it has one it must be the original one
+	if refreshed.Nonce != "" && refreshed.Nonce != idToken.Nonce {
+		return nil, errors.New("nonce does not match value sent")
+	}
+	return refreshed, nil
+}
+
 // InteractiveIDTokenSource returns an `IDTokenSource` which performs an interactive Oauth token flow in order to retrieve an `IDToken`.
 func InteractiveIDTokenSource(cfg oauth2.Config, oidp *coreoidc.Provider, extraAuthCodeOpts []oauth2.AuthCodeOption, allowBrowser, autoclose bool, autocloseTimeout int) IDTokenSource {
 	ts := &interactiveIDTokenSource{cfg: cfg, oidp: oidp, extraAuthCodeOpts: extraAuthCodeOpts, browser: failBrowser, autoclose: autoclose, autocloseTimeout: autocloseTimeout}
@@ -25,6 +25,10 @@ import (
 // IDToken is a structured representation of an OIDC IDToken.
 type IDToken struct {
 	coreoidc.IDToken
+	// RawToken is the encoded JWT, as presented to services such as Fulcio
+	RawToken string
+	// RefreshToken is the refresh token returned with the ID token, if any
+	RefreshToken string
 }
 
 // IDTokenSource provides `IDTokens`.
@@ -48,24 +52,34 @@ func StaticIDTokenSource(idt *IDToken) IDTokenSource {
 
 // extractAndVerifyIDToken extracts the ID token from the given `oauth2.Token`, then verifies it against the given verifier and nonce.
 func extractAndVerifyIDToken(ctx context.Context, t *oauth2.Token, v *coreoidc.IDTokenVerifier, nonce string) (*IDToken, error) {
+	idToken, err := extractAndVerifyIDTokenWithoutNonce(ctx, t, v)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("nonce does not match value sent")
+	}
+	return idToken, nil
+}
+
+// extractAndVerifyIDTokenWithoutNonce extracts the ID token from the given `oauth2.Token`, then verifies it against the given
+// verifier, leaving the nonce to the caller.
+func extractAndVerifyIDTokenWithoutNonce(ctx context.Context, t *oauth2.Token, v *coreoidc.IDTokenVerifier) (*IDToken, error) {
 	// requesting 'openid' scope should ensure an id_token is given when exchanging the code for an access token
 	unverifiedIDToken, ok := t.Extra("id_token").(string)
 	if !ok {
 		return nil, errors.New("id_token not present")
 	}
 
-	// verify nonce, client ID, access token hash before using it
+	// verify client ID, access token hash before using it
 	idToken, err := v.Verify(ctx, unverifiedIDToken)
 	if err != nil {
 		return nil, err
 	}
-	if idToken.Nonce != nonce {
-		return nil, errors.New("nonce does not match value sent")
-	}
 	if idToken.AccessTokenHash != "" {
 		if err := idToken.VerifyAccessToken(t.AccessToken); err != nil {
 			return nil, err
 		}
 	}
-	return &IDToken{*idToken}, nil
+	return &IDToken{IDToken: *idToken, RawToken: unverifiedIDToken, RefreshToken: t.RefreshToken}, nil
 }
@@ -0,0 +1,155 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauthflow
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// ClientAssertionType is the client_assertion_type of a JWT client assertion, as specified in RFC 7523 section 2.2
+const ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// clientAssertionLifetime is how long a client assertion is valid for. It is only used for a single request.
+const clientAssertionLifetime = 5 * time.Minute
+
+// jwsAlgorithm returns the JWS algorithm and hash function to sign with signer
+func jwsAlgorithm(signer signature.Signer, pub crypto.PublicKey) (string, crypto.Hash, error) {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return "ES256", crypto.SHA256, nil
+		case elliptic.P384():
+			return "ES384", crypto.SHA384, nil
+		case elliptic.P521():
+			return "ES512", crypto.SHA512, nil
+		}
+		return "", 0, fmt.Errorf("unsupported elliptic curve %s", pub.Curve.Params().Name)
+	case *rsa.PublicKey:
+		switch signer.(type) {
+		case *signature.RSAPSSSigner, *signature.RSAPSSSignerVerifier:
+			// PS256 requires a salt as long as the hash, which these signers do not use by default
+			return "", 0, errors.New("RSA-PSS signers are not supported, use an RSA PKCS#1 v1.5 signer")
+		}
+		return "RS256", crypto.SHA256, nil
+	case ed25519.PublicKey:
+		switch signer.(type) {
+		case *signature.ED25519phSigner, *signature.ED25519phSignerVerifier:
+			return "", 0, errors.New("pre-hashed Ed25519ph signers are not supported, use an Ed25519 signer")
+		}
+		return "EdDSA", crypto.Hash(0), nil
+	}
+	return "", 0, fmt.Errorf("unsupported public key type %T", pub)
+}
+
+// ecdsaJWSSignature converts an ASN.1 ECDSA signature to the fixed size form used by JWS, as specified in RFC 7518
+// section 3.4
+func ecdsaJWSSignature(sig []byte, curve elliptic.Curve) ([]byte, error) {
+	var parsed struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(sig, &parsed); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data after ECDSA signature")
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	if parsed.R.Sign() <= 0 || parsed.S.Sign() <= 0 || parsed.R.BitLen() > size*8 || parsed.S.BitLen() > size*8 {
+		return nil, errors.New("invalid ECDSA signature")
+	}
+	out := make([]byte, 2*size)
+	parsed.R.FillBytes(out[:size])
+	parsed.S.FillBytes(out[size:])
+	return out, nil
+}
+
+// signClientAssertion returns a JWT authenticating clientID to the token endpoint, as specified in RFC 7523 section 3
+// and OpenID Connect Core 1.0 section 9
+func signClientAssertion(ctx context.Context, signer signature.Signer, clientID, tokenEndpoint string) (string, error) {
+	pub, err := signer.PublicKey(options.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("getting public key of client assertion signer: %w", err)
+	}
+	alg, hash, err := jwsAlgorithm(signer, pub)
+	if err != nil {
+		return "", fmt.Errorf("client assertion signer: %w", err)
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": clientID,
+		"sub": clientID,
+		"aud": tokenEndpoint,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	signOpts := []signature.SignOption{options.WithContext(ctx)}
+	if hash != crypto.Hash(0) {
+		signOpts = append(signOpts, options.WithCryptoSignerOpts(hash))
+	}
+	sig, err := signer.SignMessage(bytes.NewReader([]byte(signingInput)), signOpts...)
+	if err != nil {
+		return "", fmt.Errorf("signing client assertion: %w", err)
+	}
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		if sig, err = ecdsaJWSSignature(sig, pub.Curve); err != nil {
+			return "", fmt.Errorf("signing client assertion: %w", err)
+		}
+	case *rsa.PublicKey:
+		// signers such as KMS keys may use RSA-PSS without being one of the RSA-PSS signer types, which RS256 does
+		// not describe
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return "", errors.New("client assertion signer did not produce an RSA PKCS#1 v1.5 signature, RSA-PSS signers are not supported")
+		}
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
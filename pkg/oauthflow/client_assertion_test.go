@@ -0,0 +1,106 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauthflow
+
+import (
+	"context"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// verifyClientAssertion checks the signature and claims of a client assertion, returning its JWS algorithm
+func verifyClientAssertion(t *testing.T, assertion string, pub crypto.PublicKey, clientID, tokenEndpoint string) string {
+	t.Helper()
+	jws, err := jose.ParseSigned(assertion)
+	if err != nil {
+		t.Fatalf("unexpected error parsing client assertion: %v", err)
+	}
+	payload, err := jws.Verify(pub)
+	if err != nil {
+		t.Fatalf("unexpected error verifying client assertion: %v", err)
+	}
+	var claims struct {
+		Issuer   string `json:"iss"`
+		Subject  string `json:"sub"`
+		Audience string `json:"aud"`
+		ID       string `json:"jti"`
+		IssuedAt int64  `json:"iat"`
+		Expiry   int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != clientID || claims.Subject != clientID || claims.Audience != tokenEndpoint || claims.ID == "" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if expiry := time.Unix(claims.Expiry, 0); expiry.Before(time.Now()) || expiry.After(time.Now().Add(clientAssertionLifetime+time.Minute)) {
+		t.Fatalf("unexpected expiry %v", expiry)
+	}
+	return jws.Signatures[0].Header.Algorithm
+}
+
+func TestSignClientAssertion(t *testing.T) {
+	p384, _, _ := signature.NewECDSASignerVerifier(elliptic.P384(), rand.Reader, crypto.SHA384)
+	p521, _, _ := signature.NewECDSASignerVerifier(elliptic.P521(), rand.Reader, crypto.SHA512)
+	p256, _, _ := signature.NewDefaultECDSASignerVerifier()
+	rsaPKCS1v15, _, _ := signature.NewDefaultRSAPKCS1v15SignerVerifier()
+	ed25519, _, _ := signature.NewDefaultED25519SignerVerifier()
+
+	tests := []struct {
+		signer   signature.SignerVerifier
+		expected string
+	}{
+		{signer: p256, expected: "ES256"},
+		{signer: p384, expected: "ES384"},
+		{signer: p521, expected: "ES512"},
+		{signer: rsaPKCS1v15, expected: "RS256"},
+		{signer: ed25519, expected: "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assertion, err := signClientAssertion(context.Background(), tt.signer, "sigstore", "https://issuer.example.com/token")
+			if err != nil {
+				t.Fatalf("unexpected error signing client assertion: %v", err)
+			}
+			pub, _ := tt.signer.PublicKey()
+			if alg := verifyClientAssertion(t, assertion, pub, "sigstore", "https://issuer.example.com/token"); alg != tt.expected {
+				t.Fatalf("expected algorithm %s, got %s", tt.expected, alg)
+			}
+		})
+	}
+
+	rsaPSS, _, _ := signature.NewDefaultRSAPSSSignerVerifier()
+	if _, err := signClientAssertion(context.Background(), rsaPSS, "sigstore", "https://issuer.example.com/token"); err == nil {
+		t.Fatal("expected error for an RSA-PSS signer")
+	}
+	// a signer using RSA-PSS which is not one of the RSA-PSS signer types, such as a KMS key
+	if _, err := signClientAssertion(context.Background(), opaqueSigner{rsaPSS}, "sigstore", "https://issuer.example.com/token"); err == nil || !strings.Contains(err.Error(), "PKCS#1 v1.5") {
+		t.Fatalf("expected PKCS#1 v1.5 error for an opaque RSA-PSS signer, got %v", err)
+	}
+}
+
+// opaqueSigner hides the concrete type of a signer
+type opaqueSigner struct {
+	signature.Signer
+}
@@ -18,6 +18,7 @@ package oauthflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,21 +27,31 @@ import (
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/sigstore/pkg/oauthflow/internal"
+	"github.com/sigstore/sigstore/pkg/signature"
 	"golang.org/x/oauth2"
 )
 
+var _ ContextTokenGetter = (*DefaultFlowClientCredentials)(nil)
+
 // CodeURL fetches the client credentials token authorization endpoint URL from the provider's well-known configuration endpoint
 func (d *DefaultFlowClientCredentials) CodeURL() (string, error) {
+	return d.codeURLContext(context.Background())
+}
+
+func (d *DefaultFlowClientCredentials) codeURLContext(ctx context.Context) (string, error) {
 	if d.codeURL != "" {
 		return d.codeURL, nil
 	}
 
 	wellKnown := strings.TrimSuffix(d.Issuer, "/") + "/.well-known/openid-configuration"
-	/* #nosec */
-	httpClient := &http.Client{
-		Timeout: 3 * time.Second,
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return "", err
 	}
-	resp, err := httpClient.Get(wellKnown)
+	resp, err := d.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -56,8 +67,11 @@ func (d *DefaultFlowClientCredentials) CodeURL() (string, error) {
 	}
 
 	providerConfig := struct {
-		Issuer        string `json:"issuer"`
-		TokenEndpoint string `json:"token_endpoint"`
+		Issuer              string `json:"issuer"`
+		TokenEndpoint       string `json:"token_endpoint"`
+		MTLSEndpointAliases struct {
+			TokenEndpoint string `json:"token_endpoint"`
+		} `json:"mtls_endpoint_aliases"`
 	}{}
 	if err = json.Unmarshal(body, &providerConfig); err != nil {
 		return "", fmt.Errorf("oidc: failed to decode provider discovery object: %w", err)
@@ -67,6 +81,12 @@ func (d *DefaultFlowClientCredentials) CodeURL() (string, error) {
 		return "", fmt.Errorf("oidc: issuer did not match the issuer returned by provider, expected %q got %q", d.Issuer, providerConfig.Issuer)
 	}
 
+	if d.TLSClientAuth && providerConfig.MTLSEndpointAliases.TokenEndpoint != "" {
+		// RFC 8705 section 5: mutual-TLS clients use the alias if the provider publishes one
+		d.codeURL = providerConfig.MTLSEndpointAliases.TokenEndpoint
+		return d.codeURL, nil
+	}
+
 	if providerConfig.TokenEndpoint == "" {
 		return "", fmt.Errorf("oidc: client credentials token authorization endpoint not returned by provider")
 	}
@@ -76,9 +96,20 @@ func (d *DefaultFlowClientCredentials) CodeURL() (string, error) {
 }
 
 // DefaultFlowClientCredentials fetches an OIDC Identity token using the Client Credentials Grant flow as specified in RFC8628
+//
+// The client authenticates with its client secret by default. If AssertionSigner is set, it instead authenticates with
+// a signed JWT (private_key_jwt), and if TLSClientAuth is set, with the TLS client certificate of HTTPClient.
 type DefaultFlowClientCredentials struct {
-	Issuer  string
-	codeURL string
+	Issuer string
+	// HTTPClient sends every request of the flow. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// AssertionSigner, if set, signs the JWT which authenticates the client as specified in RFC 7523, in place of the
+	// client secret. Any signature.Signer can be used, including KMS keys.
+	AssertionSigner signature.Signer
+	// TLSClientAuth authenticates the client with the TLS client certificate configured in HTTPClient, as specified in
+	// RFC 8705, in place of the client secret.
+	TLSClientAuth bool
+	codeURL       string
 }
 
 // NewClientCredentialsFlow creates a new DefaultFlowClientCredentials that retrieves an OIDC Identity Token using a Client Credentials Grant
@@ -88,58 +119,82 @@ func NewClientCredentialsFlow(issuer string) *DefaultFlowClientCredentials {
 	}
 }
 
-func (d *DefaultFlowClientCredentials) clientCredentialsFlow(_ *oidc.Provider, clientID, clientSecret, redirectURL string) (string, error) {
+func (d *DefaultFlowClientCredentials) httpClient() *http.Client {
+	if d.HTTPClient != nil {
+		return d.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (d *DefaultFlowClientCredentials) clientCredentialsFlow(ctx context.Context, _ *oidc.Provider, clientID, clientSecret, redirectURL string) (string, error) {
+	if d.AssertionSigner != nil && d.TLSClientAuth {
+		return "", errors.New("only one of AssertionSigner and TLSClientAuth can be set")
+	}
 	data := url.Values{
-		"client_id":     []string{clientID},
-		"client_secret": []string{clientSecret},
-		"scope":         []string{"openid email"},
-		"grant_type":    []string{"client_credentials"},
+		"client_id":  []string{clientID},
+		"scope":      []string{"openid email"},
+		"grant_type": []string{"client_credentials"},
 	}
 	if redirectURL != "" {
 		// If a redirect uri is provided then use it
 		data["redirect_uri"] = []string{redirectURL}
 	}
 
-	codeURL, err := d.CodeURL()
+	codeURL, err := d.codeURLContext(ctx)
 	if err != nil {
 		return "", err
 	}
-	/* #nosec */
-	resp, err := http.PostForm(codeURL, data)
+	switch {
+	case d.AssertionSigner != nil:
+		assertion, err := signClientAssertion(ctx, d.AssertionSigner, clientID, codeURL)
+		if err != nil {
+			return "", err
+		}
+		data["client_assertion_type"] = []string{ClientAssertionType}
+		data["client_assertion"] = []string{assertion}
+	case d.TLSClientAuth:
+		// the client is authenticated during the TLS handshake
+	default:
+		data["client_secret"] = []string{clientSecret}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, codeURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	b, err := io.ReadAll(resp.Body)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := d.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%s: %s", resp.Status, b)
-	}
+	defer resp.Body.Close()
 
-	tr := tokenResp{}
-	if err := json.Unmarshal(b, &tr); err != nil {
-		return "", err
+	token, err := internal.ParseAccessTokenResponse(resp)
+	if err != nil {
+		return "", fmt.Errorf("error obtaining token: %w", err)
 	}
-
-	if tr.IDToken != "" {
-		fmt.Println("Token received!")
-		return tr.IDToken, nil
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		return "", errors.New("token response did not contain an id_token")
 	}
-
-	return "", fmt.Errorf("unexpected error in client flow: %s", tr.Error)
+	fmt.Println("Token received!")
+	return idToken, nil
 }
 
 // GetIDToken gets an OIDC ID Token from the specified provider using the Client Credentials Grant flow
 func (d *DefaultFlowClientCredentials) GetIDToken(p *oidc.Provider, cfg oauth2.Config) (*OIDCIDToken, error) {
-	idToken, err := d.clientCredentialsFlow(p, cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL)
+	return d.GetIDTokenContext(context.Background(), p, cfg)
+}
+
+// GetIDTokenContext gets an OIDC ID Token from the specified provider using the Client Credentials Grant flow, stopping
+// when ctx is done
+func (d *DefaultFlowClientCredentials) GetIDTokenContext(ctx context.Context, p *oidc.Provider, cfg oauth2.Config) (*OIDCIDToken, error) {
+	idToken, err := d.clientCredentialsFlow(ctx, p, cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL)
 	if err != nil {
 		return nil, err
 	}
 	verifier := p.Verifier(&oidc.Config{ClientID: cfg.ClientID})
-	parsedIDToken, err := verifier.Verify(context.Background(), idToken)
+	parsedIDToken, err := verifier.Verify(ctx, idToken)
 	if err != nil {
 		return nil, err
 	}
@@ -17,14 +17,25 @@ package oauthflow
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/sigstore/sigstore/pkg/oauthflow/internal"
+	"github.com/sigstore/sigstore/pkg/signature"
 )
 
 type testccDriver struct {
@@ -73,12 +84,12 @@ func TestClientCredentialsFlowTokenGetter_ccFlow(t *testing.T) {
 
 	tokenCh, errCh := make(chan string), make(chan error)
 	go func() {
-		token, err := dtg.clientCredentialsFlow(p, "sigstore", "", "")
+		token, err := dtg.clientCredentialsFlow(context.Background(), p, "sigstore", "", "")
 		tokenCh <- token
 		errCh <- err
 	}()
 
-	td.respCh <- tokenResponse("mytoken", "")
+	td.respCh <- map[string]string{"access_token": "myaccesstoken", "token_type": "Bearer", "id_token": "mytoken"}
 
 	token := <-tokenCh
 	err := <-errCh
@@ -89,3 +100,157 @@ func TestClientCredentialsFlowTokenGetter_ccFlow(t *testing.T) {
 		t.Fatal("expected mytoken")
 	}
 }
+
+// ccHandler serves a discovery document, including an RFC 8705 mutual-TLS alias of the token endpoint, and handles
+// requests to either token endpoint with token
+func ccHandler(token http.HandlerFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		issuer := scheme + "://" + r.Host
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                issuer,
+			"token_endpoint":        issuer + "/token",
+			"mtls_endpoint_aliases": map[string]string{"token_endpoint": issuer + "/mtls/token"},
+		})
+	})
+	mux.HandleFunc("/token", token)
+	mux.HandleFunc("/mtls/token", token)
+	return mux
+}
+
+func writeTokenResponse(w http.ResponseWriter, idToken string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "myaccesstoken", "token_type": "Bearer", "id_token": idToken})
+}
+
+func TestClientCredentialsFlowTokenGetter_privateKeyJWT(t *testing.T) {
+	sv, _, err := signature.NewDefaultECDSASignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertionCh := make(chan string, 1)
+	ts := httptest.NewServer(ccHandler(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("client_assertion_type") != ClientAssertionType || r.PostForm.Has("client_secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		assertionCh <- r.PostForm.Get("client_assertion")
+		writeTokenResponse(w, "mytoken")
+	}))
+	defer ts.Close()
+
+	dtg := DefaultFlowClientCredentials{Issuer: ts.URL, AssertionSigner: sv}
+	token, err := dtg.clientCredentialsFlow(context.Background(), nil, "sigstore", "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "mytoken" {
+		t.Fatal("expected mytoken")
+	}
+	pub, _ := sv.PublicKey()
+	verifyClientAssertion(t, <-assertionCh, pub, "sigstore", ts.URL+"/token")
+
+	dtg = DefaultFlowClientCredentials{Issuer: ts.URL, AssertionSigner: sv, TLSClientAuth: true}
+	if _, err := dtg.clientCredentialsFlow(context.Background(), nil, "sigstore", "", ""); err == nil {
+		t.Fatal("expected error with more than one client authentication method")
+	}
+}
+
+// testClientCertificate returns a self-signed TLS client certificate
+func testClientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sigstore"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv, Leaf: leaf}
+}
+
+func TestClientCredentialsFlowTokenGetter_mTLS(t *testing.T) {
+	cert := testClientCertificate(t)
+	ts := httptest.NewUnstartedServer(ccHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mtls/token" || r.FormValue("client_id") != "sigstore" || r.PostForm.Has("client_secret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		writeTokenResponse(w, "mytoken")
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(cert.Leaf)
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs, MinVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	dtg := DefaultFlowClientCredentials{
+		Issuer:        ts.URL,
+		HTTPClient:    &http.Client{Transport: transport},
+		TLSClientAuth: true,
+	}
+	token, err := dtg.clientCredentialsFlow(context.Background(), nil, "sigstore", "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "mytoken" {
+		t.Fatal("expected mytoken")
+	}
+
+	// without the client certificate, the TLS handshake fails
+	dtg.HTTPClient = ts.Client()
+	if _, err := dtg.clientCredentialsFlow(context.Background(), nil, "sigstore", "", ""); err == nil {
+		t.Fatal("expected error without a client certificate")
+	}
+}
+
+func TestClientCredentialsFlowTokenGetter_errorResponse(t *testing.T) {
+	ts := httptest.NewServer(ccHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_secret") != "secret" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"unknown client secret"}`))
+			return
+		}
+		// an access token response without an ID token
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		_, _ = w.Write([]byte("access_token=myaccesstoken&token_type=Bearer"))
+	}))
+	defer ts.Close()
+
+	dtg := DefaultFlowClientCredentials{Issuer: ts.URL}
+	_, err := dtg.clientCredentialsFlow(context.Background(), nil, "sigstore", "wrong", "")
+	var respErr *internal.ErrorTokenResponse
+	if !errors.As(err, &respErr) || respErr.Code != "invalid_client" || respErr.Description != "unknown client secret" {
+		t.Fatalf("expected invalid_client error, got %v", err)
+	}
+
+	if _, err := dtg.clientCredentialsFlow(context.Background(), nil, "sigstore", "secret", ""); err == nil || !strings.Contains(err.Error(), "id_token") {
+		t.Fatalf("expected error for a response without an ID token, got %v", err)
+	}
+}
@@ -19,6 +19,7 @@ package oauthflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -51,16 +52,44 @@ type deviceResp struct {
 }
 
 type tokenResp struct {
-	IDToken string `json:"id_token"`
-	Error   string `json:"error"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
 }
 
+// defaultDeviceInterval is the polling interval used when the device authorization response does not include one,
+// as specified in RFC 8628 section 3.2
+const defaultDeviceInterval = 5 * time.Second
+
+// slowDownIncrement is added to the polling interval on each slow_down error, as specified in RFC 8628 section 3.5
+const slowDownIncrement = 5 * time.Second
+
+// DeviceAuthorization holds what the user needs to complete a device flow on another device
+type DeviceAuthorization struct {
+	// UserCode is the code the user enters at VerificationURI
+	UserCode string
+	// VerificationURI is where the user enters UserCode
+	VerificationURI string
+	// VerificationURIComplete includes the user code, so that the user does not have to enter it. It may be empty.
+	VerificationURIComplete string
+	// ExpiresAt is when the user code expires. It is zero if the provider did not set an expiry.
+	ExpiresAt time.Time
+}
+
+var _ ContextTokenGetter = (*DeviceFlowTokenGetter)(nil)
+
 // DeviceFlowTokenGetter fetches an OIDC Identity token using the Device Code Grant flow as specified in RFC8628
 type DeviceFlowTokenGetter struct {
 	MessagePrinter func(string)
-	Sleeper        func(time.Duration)
-	Issuer         string
-	codeURL        string
+	// Sleeper waits between polls of the token endpoint. If nil, the wait ends early when the context is done.
+	Sleeper func(time.Duration)
+	Issuer  string
+	// HTTPClient sends every request of the flow. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// OnDeviceAuthorization, if set, is called to show the user code and verification URI to the user instead of
+	// printing them with MessagePrinter.
+	OnDeviceAuthorization func(DeviceAuthorization)
+	codeURL               string
 }
 
 // NewDeviceFlowTokenGetter creates a new DeviceFlowTokenGetter that retrieves an OIDC Identity Token using a Device Code Grant
@@ -68,7 +97,6 @@ type DeviceFlowTokenGetter struct {
 func NewDeviceFlowTokenGetter(issuer, codeURL, _ string) *DeviceFlowTokenGetter {
 	return &DeviceFlowTokenGetter{
 		MessagePrinter: func(s string) { fmt.Println(s) },
-		Sleeper:        time.Sleep,
 		Issuer:         issuer,
 		codeURL:        codeURL,
 	}
@@ -78,12 +106,59 @@ func NewDeviceFlowTokenGetter(issuer, codeURL, _ string) *DeviceFlowTokenGetter
 func NewDeviceFlowTokenGetterForIssuer(issuer string) *DeviceFlowTokenGetter {
 	return &DeviceFlowTokenGetter{
 		MessagePrinter: func(s string) { fmt.Println(s) },
-		Sleeper:        time.Sleep,
 		Issuer:         issuer,
 	}
 }
 
-func (d *DeviceFlowTokenGetter) deviceFlow(p *oidc.Provider, clientID, redirectURL string) (string, error) {
+func (d *DeviceFlowTokenGetter) httpClient() *http.Client {
+	if d.HTTPClient != nil {
+		return d.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// postForm posts data to endpoint and returns the response status and body
+func (d *DeviceFlowTokenGetter) postForm(ctx context.Context, endpoint string, data url.Values) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := d.httpClient().Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, b, nil
+}
+
+// wait waits for the polling interval, returning early with an error if ctx is done
+func (d *DeviceFlowTokenGetter) wait(ctx context.Context, interval time.Duration) error {
+	if d.Sleeper != nil {
+		d.Sleeper(interval)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *DeviceFlowTokenGetter) printMessage(s string) {
+	if d.MessagePrinter != nil {
+		d.MessagePrinter(s)
+	}
+}
+
+func (d *DeviceFlowTokenGetter) deviceFlow(ctx context.Context, p *oidc.Provider, clientID, redirectURL string) (string, error) {
 	// require that OIDC provider support PKCE to provide sufficient security for the CLI
 	pkce, err := NewPKCE(p)
 	if err != nil {
@@ -101,36 +176,48 @@ func (d *DeviceFlowTokenGetter) deviceFlow(p *oidc.Provider, clientID, redirectU
 		data["redirect_uri"] = []string{redirectURL}
 	}
 
-	codeURL, err := d.CodeURL()
+	codeURL, err := d.codeURLContext(ctx)
 	if err != nil {
 		return "", err
 	}
-	/* #nosec */
-	resp, err := http.PostForm(codeURL, data)
+	status, b, err := d.postForm(ctx, codeURL, data)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%s: %s", resp.Status, b)
+	if status != http.StatusOK {
+		return "", fmt.Errorf("%d %s: %s", status, http.StatusText(status), b)
 	}
 
 	parsed := deviceResp{}
 	if err := json.Unmarshal(b, &parsed); err != nil {
 		return "", err
 	}
-	uri := parsed.VerificationURIComplete
-	if uri == "" {
-		uri = parsed.VerificationURI
+	auth := DeviceAuthorization{
+		UserCode:                parsed.UserCode,
+		VerificationURI:         parsed.VerificationURI,
+		VerificationURIComplete: parsed.VerificationURIComplete,
+	}
+	if parsed.ExpiresIn > 0 {
+		auth.ExpiresAt = time.Now().Add(time.Duration(parsed.ExpiresIn) * time.Second)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, auth.ExpiresAt)
+		defer cancel()
+	}
+	if d.OnDeviceAuthorization != nil {
+		d.OnDeviceAuthorization(auth)
+	} else {
+		uri := parsed.VerificationURIComplete
+		if uri == "" {
+			uri = parsed.VerificationURI
+		}
+		d.printMessage(fmt.Sprintf("Enter the verification code %s in your browser at: %s", parsed.UserCode, uri))
+		d.printMessage(fmt.Sprintf("Code will be valid for %d seconds", parsed.ExpiresIn))
 	}
-	d.MessagePrinter(fmt.Sprintf("Enter the verification code %s in your browser at: %s", parsed.UserCode, uri))
-	d.MessagePrinter(fmt.Sprintf("Code will be valid for %d seconds", parsed.ExpiresIn))
 
+	interval := time.Duration(parsed.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeviceInterval
+	}
 	for {
 		// Some providers use a secret here, we don't need for sigstore oauth one so leave it off.
 		data := url.Values{
@@ -141,48 +228,60 @@ func (d *DeviceFlowTokenGetter) deviceFlow(p *oidc.Provider, clientID, redirectU
 			"code_verifier": []string{pkce.Value},
 		}
 
-		/* #nosec */
-		resp, err := http.PostForm(p.Endpoint().TokenURL, data)
+		status, b, err := d.postForm(ctx, p.Endpoint().TokenURL, data)
 		if err != nil {
-			return "", err
-		}
-		defer resp.Body.Close()
-
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
+			return "", deviceFlowError(ctx, err)
 		}
 		tr := tokenResp{}
 		if err := json.Unmarshal(b, &tr); err != nil {
-			return "", err
+			return "", fmt.Errorf("%d %s: %w", status, http.StatusText(status), err)
 		}
 
 		if tr.IDToken != "" {
-			d.MessagePrinter("Token received!")
+			d.printMessage("Token received!")
 			return tr.IDToken, nil
 		}
 		switch tr.Error {
 		case "access_denied", "expired_token":
 			return "", fmt.Errorf("error obtaining token: %s", tr.Error)
 		case "authorization_pending":
-			d.Sleeper(time.Duration(parsed.Interval) * time.Second)
 		case "slow_down":
-			// Add ten seconds if we got told to slow down
-			d.Sleeper(time.Duration(parsed.Interval)*time.Second + 10*time.Second)
+			// the increase applies to all subsequent requests
+			interval += slowDownIncrement
 		default:
+			if tr.ErrorDescription != "" {
+				return "", fmt.Errorf("unexpected error in device flow: %s: %s", tr.Error, tr.ErrorDescription)
+			}
 			return "", fmt.Errorf("unexpected error in device flow: %s", tr.Error)
 		}
+		if err := d.wait(ctx, interval); err != nil {
+			return "", deviceFlowError(ctx, err)
+		}
+	}
+}
+
+// deviceFlowError reports that the user code expired if the deadline set from expires_in passed
+func deviceFlowError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("error obtaining token: the device code expired: %w", err)
 	}
+	return err
 }
 
 // GetIDToken gets an OIDC ID Token from the specified provider using the device code grant flow
 func (d *DeviceFlowTokenGetter) GetIDToken(p *oidc.Provider, cfg oauth2.Config) (*OIDCIDToken, error) {
-	idToken, err := d.deviceFlow(p, cfg.ClientID, cfg.RedirectURL)
+	return d.GetIDTokenContext(context.Background(), p, cfg)
+}
+
+// GetIDTokenContext gets an OIDC ID Token from the specified provider using the device code grant flow. The flow stops
+// when ctx is done or the user code expires.
+func (d *DeviceFlowTokenGetter) GetIDTokenContext(ctx context.Context, p *oidc.Provider, cfg oauth2.Config) (*OIDCIDToken, error) {
+	idToken, err := d.deviceFlow(ctx, p, cfg.ClientID, cfg.RedirectURL)
 	if err != nil {
 		return nil, err
 	}
 	verifier := p.Verifier(&oidc.Config{ClientID: cfg.ClientID})
-	parsedIDToken, err := verifier.Verify(context.Background(), idToken)
+	parsedIDToken, err := verifier.Verify(ctx, idToken)
 	if err != nil {
 		return nil, err
 	}
@@ -200,16 +299,22 @@ func (d *DeviceFlowTokenGetter) GetIDToken(p *oidc.Provider, cfg oauth2.Config)
 
 // CodeURL fetches the device authorization endpoint URL from the provider's well-known configuration endpoint
 func (d *DeviceFlowTokenGetter) CodeURL() (string, error) {
+	return d.codeURLContext(context.Background())
+}
+
+func (d *DeviceFlowTokenGetter) codeURLContext(ctx context.Context) (string, error) {
 	if d.codeURL != "" {
 		return d.codeURL, nil
 	}
 
 	wellKnown := strings.TrimSuffix(d.Issuer, "/") + "/.well-known/openid-configuration"
-	/* #nosec */
-	httpClient := &http.Client{
-		Timeout: 3 * time.Second,
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return "", err
 	}
-	resp, err := httpClient.Get(wellKnown)
+	resp, err := d.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
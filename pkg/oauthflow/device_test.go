@@ -18,6 +18,7 @@ package oauthflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -133,7 +134,7 @@ func TestDeviceFlowTokenGetter_deviceFlow(t *testing.T) {
 
 	tokenCh, errCh := make(chan string), make(chan error)
 	go func() {
-		token, err := dtg.deviceFlow(p, "sigstore", "")
+		token, err := dtg.deviceFlow(context.Background(), p, "sigstore", "")
 		tokenCh <- token
 		errCh <- err
 	}()
@@ -170,3 +171,149 @@ func tokenResponse(idToken, err string) tokenResp {
 		Error:   err,
 	}
 }
+
+// startDeviceFlow runs the device flow of dtg in the background against the test driver
+func startDeviceFlow(ctx context.Context, t *testing.T, dtg *DeviceFlowTokenGetter, issuer string) (chan string, chan error) {
+	t.Helper()
+	p, err := oidc.NewProvider(context.Background(), issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenCh, errCh := make(chan string, 1), make(chan error, 1)
+	go func() {
+		token, err := dtg.deviceFlow(ctx, p, "sigstore", "")
+		tokenCh <- token
+		errCh <- err
+	}()
+	return tokenCh, errCh
+}
+
+func TestDeviceFlowTokenGetter_slowDown(t *testing.T) {
+	td := testDriver{
+		respCh: make(chan interface{}, 4),
+		t:      t,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(td.handler))
+	defer ts.Close()
+
+	var sleeps []time.Duration
+	var auth DeviceAuthorization
+	dtg := &DeviceFlowTokenGetter{
+		MessagePrinter:        td.writeMsg,
+		Sleeper:               func(d time.Duration) { sleeps = append(sleeps, d) },
+		Issuer:                ts.URL,
+		OnDeviceAuthorization: func(a DeviceAuthorization) { auth = a },
+	}
+	tokenCh, errCh := startDeviceFlow(context.Background(), t, dtg, ts.URL)
+
+	code := codeResponse()
+	code.VerificationURI = "uri"
+	code.ExpiresIn = 600
+	td.respCh <- code
+	td.respCh <- tokenResponse("", "slow_down")
+	td.respCh <- tokenResponse("", "authorization_pending")
+	td.respCh <- tokenResponse("mytoken", "")
+
+	if token, err := <-tokenCh, <-errCh; err != nil || token != "mytoken" {
+		t.Fatalf("expected mytoken, got %q, %v", token, err)
+	}
+	// the interval of 3 seconds is increased by 5 seconds for every later poll
+	if len(sleeps) != 2 || sleeps[0] != 8*time.Second || sleeps[1] != 8*time.Second {
+		t.Fatalf("expected two 8s waits, got %v", sleeps)
+	}
+	if auth.UserCode != "mysecret" || auth.VerificationURI != "uri" || auth.VerificationURIComplete != "complete-uri" {
+		t.Fatalf("unexpected device authorization %+v", auth)
+	}
+	if time.Until(auth.ExpiresAt) < 590*time.Second || time.Until(auth.ExpiresAt) > 600*time.Second {
+		t.Fatalf("unexpected expiry %v", auth.ExpiresAt)
+	}
+	for _, msg := range td.msgs {
+		if strings.Contains(msg, "mysecret") {
+			t.Fatalf("expected the user code to be passed to the callback only, got message %q", msg)
+		}
+	}
+}
+
+func TestDeviceFlowTokenGetter_expiresIn(t *testing.T) {
+	td := testDriver{
+		respCh: make(chan interface{}, 10),
+		t:      t,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(td.handler))
+	defer ts.Close()
+
+	dtg := &DeviceFlowTokenGetter{
+		MessagePrinter: td.writeMsg,
+		Issuer:         ts.URL,
+	}
+	tokenCh, errCh := startDeviceFlow(context.Background(), t, dtg, ts.URL)
+
+	code := codeResponse()
+	code.Interval = 1
+	code.ExpiresIn = 1
+	td.respCh <- code
+	for i := 0; i < 5; i++ {
+		td.respCh <- tokenResponse("", "authorization_pending")
+	}
+
+	select {
+	case <-tokenCh:
+		if err := <-errCh; err == nil || !strings.Contains(err.Error(), "expired") {
+			t.Fatalf("expected the device code to expire, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("device flow did not stop at expires_in")
+	}
+}
+
+func TestDeviceFlowTokenGetter_cancel(t *testing.T) {
+	td := testDriver{
+		respCh: make(chan interface{}, 10),
+		t:      t,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(td.handler))
+	defer ts.Close()
+
+	// every request goes through the caller's client
+	var requests int
+	client := ts.Client()
+	transport := client.Transport
+	client.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return transport.RoundTrip(r)
+	})
+	dtg := &DeviceFlowTokenGetter{
+		MessagePrinter: td.writeMsg,
+		Issuer:         ts.URL,
+		HTTPClient:     client,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	tokenCh, errCh := startDeviceFlow(ctx, t, dtg, ts.URL)
+
+	td.respCh <- codeResponse()
+	td.respCh <- tokenResponse("", "authorization_pending")
+	// cancel once the first poll was answered, while waiting for the interval of 3 seconds
+	for len(td.respCh) > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-tokenCh:
+		if err := <-errCh; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("device flow did not stop when the context was cancelled")
+	}
+	// the well-known configuration, the device code and the poll
+	if requests != 3 {
+		t.Fatalf("expected the requests to use the caller's client, got %d requests", requests)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
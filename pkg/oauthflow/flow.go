@@ -41,6 +41,12 @@ type TokenGetter interface {
 	GetIDToken(provider *oidc.Provider, config oauth2.Config) (*OIDCIDToken, error)
 }
 
+// ContextTokenGetter is a TokenGetter whose flow can be cancelled or bounded with a context
+type ContextTokenGetter interface {
+	TokenGetter
+	GetIDTokenContext(ctx context.Context, provider *oidc.Provider, config oauth2.Config) (*OIDCIDToken, error)
+}
+
 // OIDCIDToken represents an OIDC Identity Token
 type OIDCIDToken struct {
 	RawString string // RawString provides the raw token (a base64-encoded JWT) value
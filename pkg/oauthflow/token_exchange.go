@@ -0,0 +1,228 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauthflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	soidc "github.com/sigstore/sigstore/pkg/oauth/oidc"
+	"github.com/sigstore/sigstore/pkg/oauthflow/internal"
+	"golang.org/x/oauth2"
+)
+
+// Token type identifiers, as specified in RFC 8693 section 3
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeIDToken     = "urn:ietf:params:oauth:token-type:id_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// tokenExchangeGrantType is the grant type of a token exchange request, as specified in RFC 8693 section 2.1
+const tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// SubjectTokenSource provides the token which is exchanged by a TokenExchangeTokenGetter
+type SubjectTokenSource interface {
+	// SubjectToken returns the token to exchange or an error.
+	SubjectToken(ctx context.Context) (string, error)
+}
+
+type staticSubjectTokenSource string
+
+func (s staticSubjectTokenSource) SubjectToken(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// StaticSubjectTokenSource returns a SubjectTokenSource which always returns the given token
+func StaticSubjectTokenSource(token string) SubjectTokenSource {
+	return staticSubjectTokenSource(token)
+}
+
+type idTokenSubjectTokenSource struct {
+	src soidc.IDTokenSource
+}
+
+func (s idTokenSubjectTokenSource) SubjectToken(ctx context.Context) (string, error) {
+	idToken, err := s.src.IDToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	if idToken.RawToken == "" {
+		return "", errors.New("ID token source did not return a raw token")
+	}
+	return idToken.RawToken, nil
+}
+
+// IDTokenSubjectTokenSource returns a SubjectTokenSource which returns the raw ID tokens of src, such as the
+// `AmbientIDTokenSource` of the CI or cloud environment
+func IDTokenSubjectTokenSource(src soidc.IDTokenSource) SubjectTokenSource {
+	return idTokenSubjectTokenSource{src: src}
+}
+
+var _ ContextTokenGetter = (*TokenExchangeTokenGetter)(nil)
+
+// TokenExchangeTokenGetter fetches an OIDC Identity token for a token issued by another issuer using the Token Exchange
+// grant as specified in RFC8693
+type TokenExchangeTokenGetter struct {
+	// SubjectTokenSource provides the token to exchange
+	SubjectTokenSource SubjectTokenSource
+	// SubjectTokenType identifies the type of the subject token, TokenTypeIDToken by default
+	SubjectTokenType string
+	// Audience is the audience of the requested ID token. If empty, the provider chooses the audience, which must then
+	// be the client ID.
+	Audience string
+	// HTTPClient sends the token exchange request. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// NewTokenExchangeTokenGetter creates a new TokenExchangeTokenGetter that exchanges the tokens of src for OIDC Identity
+// Tokens for the audience
+func NewTokenExchangeTokenGetter(src SubjectTokenSource, audience string) *TokenExchangeTokenGetter {
+	return &TokenExchangeTokenGetter{
+		SubjectTokenSource: src,
+		Audience:           audience,
+	}
+}
+
+func (t *TokenExchangeTokenGetter) httpClient() *http.Client {
+	if t.HTTPClient != nil {
+		return t.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Exchange exchanges a subject token for an ID token at the token endpoint of the provider, authenticating with the
+// client ID and secret of cfg, and returns the ID token once verified with the provider's keys
+func (t *TokenExchangeTokenGetter) Exchange(ctx context.Context, p *oidc.Provider, cfg oauth2.Config) (*soidc.IDToken, error) {
+	if t.SubjectTokenSource == nil {
+		return nil, errors.New("no subject token source")
+	}
+	subjectToken, err := t.SubjectTokenSource.SubjectToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting subject token: %w", err)
+	}
+	subjectTokenType := t.SubjectTokenType
+	if subjectTokenType == "" {
+		subjectTokenType = TokenTypeIDToken
+	}
+
+	data := url.Values{
+		"grant_type":           []string{tokenExchangeGrantType},
+		"subject_token":        []string{subjectToken},
+		"subject_token_type":   []string{subjectTokenType},
+		"requested_token_type": []string{TokenTypeIDToken},
+	}
+	if t.Audience != "" {
+		data["audience"] = []string{t.Audience}
+	}
+	if len(cfg.Scopes) > 0 {
+		data["scope"] = []string{strings.Join(cfg.Scopes, " ")}
+	}
+	if cfg.ClientID != "" {
+		data["client_id"] = []string{cfg.ClientID}
+	}
+	if cfg.ClientSecret != "" {
+		data["client_secret"] = []string{cfg.ClientSecret}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoint().TokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := t.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	token, err := internal.ParseAccessTokenResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging token: %w", err)
+	}
+
+	// the issued token is returned as the access token, as specified in RFC 8693 section 2.2.1, although some providers
+	// return it as an id_token alongside an access token instead
+	unverifiedIDToken, _ := token.Extra("id_token").(string)
+	accessToken := token.AccessToken
+	if issuedTokenType, _ := token.Extra("issued_token_type").(string); issuedTokenType == TokenTypeIDToken {
+		unverifiedIDToken, accessToken = token.AccessToken, ""
+	}
+	if unverifiedIDToken == "" {
+		return nil, errors.New("id_token not present")
+	}
+
+	clientID := t.Audience
+	if clientID == "" {
+		clientID = cfg.ClientID
+	}
+	// verify the audience and access token hash before using it
+	idToken, err := p.Verifier(&oidc.Config{ClientID: clientID}).Verify(ctx, unverifiedIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if idToken.AccessTokenHash != "" && accessToken != "" {
+		if err := idToken.VerifyAccessToken(accessToken); err != nil {
+			return nil, err
+		}
+	}
+	return &soidc.IDToken{IDToken: *idToken, RawToken: unverifiedIDToken}, nil
+}
+
+// GetIDToken gets an OIDC ID Token from the specified provider using the Token Exchange grant
+func (t *TokenExchangeTokenGetter) GetIDToken(p *oidc.Provider, cfg oauth2.Config) (*OIDCIDToken, error) {
+	return t.GetIDTokenContext(context.Background(), p, cfg)
+}
+
+// GetIDTokenContext gets an OIDC ID Token from the specified provider using the Token Exchange grant, stopping when ctx
+// is done
+func (t *TokenExchangeTokenGetter) GetIDTokenContext(ctx context.Context, p *oidc.Provider, cfg oauth2.Config) (*OIDCIDToken, error) {
+	idToken, err := t.Exchange(ctx, p, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	subj, err := SubjectFromToken(&idToken.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCIDToken{
+		RawString: idToken.RawToken,
+		Subject:   subj,
+	}, nil
+}
+
+type tokenExchangeIDTokenSource struct {
+	tg  *TokenExchangeTokenGetter
+	p   *oidc.Provider
+	cfg oauth2.Config
+}
+
+func (s tokenExchangeIDTokenSource) IDToken(ctx context.Context) (*soidc.IDToken, error) {
+	return s.tg.Exchange(ctx, s.p, s.cfg)
+}
+
+// IDTokenSource returns an `IDTokenSource` which exchanges a subject token for each ID token, exposing its claims. It
+// can be wrapped with `CachingIDTokenSource` to reuse ID tokens until they expire.
+func (t *TokenExchangeTokenGetter) IDTokenSource(p *oidc.Provider, cfg oauth2.Config) soidc.IDTokenSource {
+	return tokenExchangeIDTokenSource{tg: t, p: p, cfg: cfg}
+}
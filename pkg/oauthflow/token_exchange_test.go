@@ -0,0 +1,193 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauthflow
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v3"
+	soidc "github.com/sigstore/sigstore/pkg/oauth/oidc"
+	"github.com/sigstore/sigstore/pkg/oauthflow/internal"
+	"golang.org/x/oauth2"
+)
+
+// testExchangeServer is an issuer which exchanges "subject-token" for an ID token for the requested audience
+type testExchangeServer struct {
+	*httptest.Server
+	priv *ecdsa.PrivateKey
+	// signingKey, if set, signs ID tokens in place of priv
+	signingKey *ecdsa.PrivateKey
+	// idTokenField returns the ID token as an id_token alongside an access token
+	idTokenField bool
+}
+
+func newTestExchangeServer(t *testing.T) *testExchangeServer {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testExchangeServer{priv: priv}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                s.URL,
+			"token_endpoint":                        s.URL + "/token",
+			"jwks_uri":                              s.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{string(jose.ES256)},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &priv.PublicKey, Algorithm: string(jose.ES256), Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *testExchangeServer) token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.FormValue("grant_type") != tokenExchangeGrantType || r.FormValue("requested_token_type") != TokenTypeIDToken ||
+		r.FormValue("subject_token_type") != TokenTypeIDToken || r.FormValue("client_id") != "sigstore" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_request"}`))
+		return
+	}
+	if r.FormValue("subject_token") != "subject-token" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"subject token is not trusted"}`))
+		return
+	}
+
+	key := s.priv
+	if s.signingKey != nil {
+		key = s.signingKey
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":            s.URL,
+		"sub":            "build-123",
+		"aud":            r.FormValue("audience"),
+		"email":          "builder@example.com",
+		"email_verified": true,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	})
+	jws, err := signer.Sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	idToken, _ := jws.CompactSerialize()
+	if s.idTokenField {
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "myaccesstoken", "token_type": "Bearer", "id_token": idToken})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"access_token": idToken, "issued_token_type": TokenTypeIDToken, "token_type": "N_A"})
+}
+
+func (s *testExchangeServer) provider(t *testing.T) (*oidc.Provider, oauth2.Config) {
+	t.Helper()
+	p, err := oidc.NewProvider(context.Background(), s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, oauth2.Config{ClientID: "sigstore", Endpoint: p.Endpoint(), Scopes: []string{oidc.ScopeOpenID, "email"}}
+}
+
+func TestTokenExchangeTokenGetter(t *testing.T) {
+	s := newTestExchangeServer(t)
+	p, cfg := s.provider(t)
+	tg := NewTokenExchangeTokenGetter(StaticSubjectTokenSource("subject-token"), "sigstore")
+
+	token, err := tg.GetIDToken(p, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error exchanging token: %v", err)
+	}
+	if token.Subject != "builder@example.com" || token.RawString == "" {
+		t.Fatalf("unexpected token %+v", token)
+	}
+
+	// the claims are available from the IDTokenSource
+	idToken, err := tg.IDTokenSource(p, cfg).IDToken(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error exchanging token: %v", err)
+	}
+	var claims struct {
+		Email string `json:"email"`
+	}
+	if err := idToken.Claims(&claims); err != nil || claims.Email != "builder@example.com" || idToken.Subject != "build-123" {
+		t.Fatalf("unexpected claims %+v, %v", claims, err)
+	}
+
+	// some providers return an id_token alongside an access token
+	s.idTokenField = true
+	if _, err := tg.GetIDToken(p, cfg); err != nil {
+		t.Fatalf("unexpected error exchanging token: %v", err)
+	}
+}
+
+func TestTokenExchangeTokenGetter_subjectTokenSource(t *testing.T) {
+	s := newTestExchangeServer(t)
+	p, cfg := s.provider(t)
+
+	// the subject token can come from any IDTokenSource, such as the ambient environment
+	subject := soidc.StaticIDTokenSource(&soidc.IDToken{RawToken: "subject-token"})
+	if _, err := NewTokenExchangeTokenGetter(IDTokenSubjectTokenSource(subject), "sigstore").GetIDToken(p, cfg); err != nil {
+		t.Fatalf("unexpected error exchanging token: %v", err)
+	}
+
+	_, err := NewTokenExchangeTokenGetter(StaticSubjectTokenSource("untrusted"), "sigstore").GetIDToken(p, cfg)
+	var respErr *internal.ErrorTokenResponse
+	if !errors.As(err, &respErr) || respErr.Code != "invalid_grant" {
+		t.Fatalf("expected invalid_grant error, got %v", err)
+	}
+}
+
+func TestTokenExchangeTokenGetter_verify(t *testing.T) {
+	s := newTestExchangeServer(t)
+	p, cfg := s.provider(t)
+
+	// without an audience, the ID token must be issued for the client ID
+	if _, err := NewTokenExchangeTokenGetter(StaticSubjectTokenSource("subject-token"), "").GetIDToken(p, cfg); err == nil {
+		t.Fatal("expected error for an ID token without the client ID as audience")
+	}
+
+	// the ID token must be signed by the provider
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.signingKey = other
+	if _, err := NewTokenExchangeTokenGetter(StaticSubjectTokenSource("subject-token"), "sigstore").GetIDToken(p, cfg); err == nil {
+		t.Fatal("expected error for an ID token signed by another key")
+	}
+}
@@ -0,0 +1,183 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// CertificateRequestOptions configures the certificate signing request created by CreateCertificateRequest
+type CertificateRequestOptions struct {
+	// Subject is the distinguished name of the request. Fulcio ignores it, so it may be left empty.
+	Subject pkix.Name
+	// SANs are the subject alternative names of the request
+	SANs []cryptoutils.SubjectAlternativeName
+	// OtherName, if set, is added as an OtherName SAN with the type-id cryptoutils.OIDOtherName,
+	// encoded as by cryptoutils.MarshalOtherNameSAN
+	OtherName string
+	// ExtraExtensions are added to the request as is. They must not include a SAN extension.
+	ExtraExtensions []pkix.Extension
+	// SignatureAlgorithm is the algorithm used to sign the request, such as x509.SHA256WithRSAPSS
+	// for an RSAPSSSigner. If unset, crypto/x509 chooses a default for the key type. RSA-PSS
+	// signers must use rsa.PSSSaltLengthEqualsHash, as crypto/x509 requires.
+	SignatureAlgorithm x509.SignatureAlgorithm
+}
+
+// cryptoSignerProvider is implemented by signers which can provide a crypto.Signer, such as kms.SignerVerifier
+type cryptoSignerProvider interface {
+	CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error)
+}
+
+// CreateCertificateRequest returns a DER encoded PKCS#10 certificate signing request for the public
+// key of signer, signed by signer. signer may be any Signer, including a kms.SignerVerifier. The
+// signature over the request is verified before it is returned.
+//
+// The SAN extension is marked critical if the subject is empty, as required by RFC 5280.
+func CreateCertificateRequest(ctx context.Context, signer Signer, opts CertificateRequestOptions) ([]byte, error) {
+	cs, err := toCryptoSigner(ctx, signer)
+	if err != nil {
+		return nil, err
+	}
+	if cs.Public() == nil {
+		return nil, errors.New("signer has no public key")
+	}
+
+	template := &x509.CertificateRequest{
+		Subject:            opts.Subject,
+		ExtraExtensions:    make([]pkix.Extension, 0, len(opts.ExtraExtensions)+1),
+		SignatureAlgorithm: opts.SignatureAlgorithm,
+	}
+	for _, ext := range opts.ExtraExtensions {
+		if ext.Id.Equal(cryptoutils.SANOID) {
+			return nil, errors.New("the SAN extension must be set with SANs or OtherName, not ExtraExtensions")
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+
+	critical := len(opts.Subject.ToRDNSequence()) == 0
+	var sanExt *pkix.Extension
+	switch {
+	case len(opts.SANs) > 0:
+		sans := opts.SANs
+		if opts.OtherName != "" {
+			sans = append(sans[:len(sans):len(sans)], cryptoutils.OtherNameSAN(cryptoutils.OIDOtherName, opts.OtherName))
+		}
+		sanExt, err = cryptoutils.MarshalSANs(sans, critical)
+	case opts.OtherName != "":
+		sanExt, err = cryptoutils.MarshalOtherNameSAN(opts.OtherName, critical)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshaling SANs: %w", err)
+	}
+	if sanExt != nil {
+		template.ExtraExtensions = append(template.ExtraExtensions, *sanExt)
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, cs)
+	if err != nil {
+		return nil, fmt.Errorf("creating certificate request: %w", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate request: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("verifying certificate request signature: %w", err)
+	}
+	return der, nil
+}
+
+// CreateCertificateRequestPEM returns a PEM encoded certificate signing request, as created by
+// CreateCertificateRequest
+func CreateCertificateRequestPEM(ctx context.Context, signer Signer, opts CertificateRequestOptions) ([]byte, error) {
+	der, err := CreateCertificateRequest(ctx, signer, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cryptoutils.PEMEncode(cryptoutils.CertificateRequestPEMType, der), nil
+}
+
+// toCryptoSigner returns a crypto.Signer which signs with signer
+func toCryptoSigner(ctx context.Context, signer Signer) (crypto.Signer, error) {
+	if signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+	// fetch the public key first, as the crypto.Signer of a KMS cannot report why it has none
+	pub, err := signer.PublicKey(options.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("getting public key: %w", err)
+	}
+	if p, ok := signer.(cryptoSignerProvider); ok {
+		cs, _, err := p.CryptoSigner(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("getting crypto.Signer: %w", err)
+		}
+		return cs, nil
+	}
+	if cs, ok := signer.(crypto.Signer); ok {
+		return cs, nil
+	}
+	return &cryptoSignerAdapter{ctx: ctx, signer: signer, pub: pub}, nil
+}
+
+// cryptoSignerAdapter adapts a Signer which does not implement crypto.Signer itself
+type cryptoSignerAdapter struct {
+	ctx    context.Context
+	signer Signer
+	pub    crypto.PublicKey
+}
+
+func (c *cryptoSignerAdapter) Public() crypto.PublicKey {
+	return c.pub
+}
+
+func (c *cryptoSignerAdapter) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	signOpts := []SignOption{options.WithContext(c.ctx), options.WithRand(rand)}
+	if opts == nil || opts.HashFunc() == crypto.Hash(0) {
+		// the message itself is signed, as with ed25519
+		return c.signer.SignMessage(bytes.NewReader(digest), signOpts...)
+	}
+	signOpts = append(signOpts, options.WithDigest(digest), options.WithCryptoSignerOpts(opts))
+	return c.signer.SignMessage(nil, signOpts...)
+}
+
+// SignProofOfPossession returns the signature Fulcio requires to prove possession of the private
+// key when requesting a certificate with a public key rather than a CSR: a signature over the
+// subject of the OIDC identity token, which is its email claim for email identities and its sub
+// claim otherwise.
+func SignProofOfPossession(ctx context.Context, signer Signer, subject string) ([]byte, error) {
+	if subject == "" {
+		return nil, errors.New("subject must not be empty")
+	}
+	return signer.SignMessage(strings.NewReader(subject), options.WithContext(ctx))
+}
+
+// VerifyProofOfPossession verifies a signature created by SignProofOfPossession over subject
+func VerifyProofOfPossession(ctx context.Context, verifier Verifier, subject string, sig []byte) error {
+	return verifier.VerifySignature(bytes.NewReader(sig), strings.NewReader(subject), options.WithContext(ctx))
+}
@@ -0,0 +1,227 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms/fake"
+)
+
+// signerOnly hides every method of a signer except those of signature.Signer
+type signerOnly struct {
+	signature.Signer
+}
+
+func TestCreateCertificateRequest(t *testing.T) {
+	ctx := context.Background()
+	ecdsaSV, _, err := signature.NewDefaultECDSASignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519SV, _, err := signature.NewDefaultED25519SignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaSV, _, err := signature.NewDefaultRSAPKCS1v15SignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rsaKey, err := signature.NewDefaultRSAPSSSignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pssSV, err := signature.LoadRSAPSSSignerVerifier(rsaKey, crypto.SHA256, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kmsSV, err := fake.LoadSignerVerifier(ctx, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer signature.Signer
+		alg    x509.SignatureAlgorithm
+	}{
+		{name: "ecdsa", signer: ecdsaSV},
+		{name: "ed25519", signer: ed25519SV},
+		{name: "rsa", signer: rsaSV},
+		{name: "rsa pss", signer: pssSV, alg: x509.SHA256WithRSAPSS},
+		{name: "kms", signer: kmsSV},
+		{name: "ecdsa signer only", signer: signerOnly{ecdsaSV}},
+		{name: "ed25519 signer only", signer: signerOnly{ed25519SV}},
+		{name: "rsa signer only", signer: signerOnly{rsaSV}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sans := []cryptoutils.SubjectAlternativeName{
+				cryptoutils.EmailSAN("user@example.com"),
+				cryptoutils.URISAN("https://example.com/workflow"),
+			}
+			pemBytes, err := signature.CreateCertificateRequestPEM(ctx, tc.signer, signature.CertificateRequestOptions{
+				SANs:               sans,
+				OtherName:          "foo!example.com",
+				SignatureAlgorithm: tc.alg,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating CSR: %v", err)
+			}
+			csr, err := cryptoutils.ParseCSR(pemBytes)
+			if err != nil {
+				t.Fatalf("unexpected error parsing CSR: %v", err)
+			}
+			if err := csr.CheckSignature(); err != nil {
+				t.Fatalf("invalid CSR signature: %v", err)
+			}
+			pub, _ := tc.signer.PublicKey()
+			if err := cryptoutils.EqualKeys(pub, csr.PublicKey); err != nil {
+				t.Fatalf("unexpected public key in CSR: %v", err)
+			}
+			if !reflect.DeepEqual(csr.EmailAddresses, []string{"user@example.com"}) || len(csr.URIs) != 1 {
+				t.Fatalf("unexpected SANs in CSR: %v, %v", csr.EmailAddresses, csr.URIs)
+			}
+			got, err := cryptoutils.UnmarshalSANs(csr.Extensions)
+			if err != nil {
+				t.Fatalf("unexpected error unmarshaling SANs: %v", err)
+			}
+			expected := append(sans, cryptoutils.OtherNameSAN(cryptoutils.OIDOtherName, "foo!example.com"))
+			if !reflect.DeepEqual(got, expected) {
+				t.Fatalf("expected SANs %v, got %v", expected, got)
+			}
+			for _, ext := range csr.Extensions {
+				if ext.Id.Equal(cryptoutils.SANOID) && !ext.Critical {
+					t.Fatal("expected SAN extension to be critical without a subject")
+				}
+			}
+		})
+	}
+}
+
+func TestCreateCertificateRequestOtherName(t *testing.T) {
+	sv, _, err := signature.NewDefaultECDSASignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := signature.CreateCertificateRequest(context.Background(), sv, signature.CertificateRequestOptions{
+		Subject:   pkix.Name{CommonName: "sigstore"},
+		OtherName: "foo!example.com",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		t.Fatalf("unexpected error parsing CSR: %v", err)
+	}
+	if csr.Subject.CommonName != "sigstore" {
+		t.Fatalf("unexpected subject %v", csr.Subject)
+	}
+	otherName, err := cryptoutils.UnmarshalOtherNameSAN(csr.Extensions)
+	if err != nil || otherName != "foo!example.com" {
+		t.Fatalf("unexpected OtherName %q: %v", otherName, err)
+	}
+	expected, _ := cryptoutils.MarshalOtherNameSAN("foo!example.com", false)
+	for _, ext := range csr.Extensions {
+		if ext.Id.Equal(cryptoutils.SANOID) && (ext.Critical || !bytes.Equal(ext.Value, expected.Value)) {
+			t.Fatalf("expected SAN extension %v, got %v", expected, ext)
+		}
+	}
+
+	// the SAN extension cannot be set directly
+	_, err = signature.CreateCertificateRequest(context.Background(), sv, signature.CertificateRequestOptions{
+		ExtraExtensions: []pkix.Extension{*expected},
+	})
+	if err == nil {
+		t.Fatal("expected error with SAN extension in ExtraExtensions")
+	}
+}
+
+var errUnavailable = errors.New("permission denied")
+
+// unavailableKMS is a KMS signer whose key cannot be fetched
+type unavailableKMS struct {
+	signature.Signer
+}
+
+func (unavailableKMS) PublicKey(...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	return nil, errUnavailable
+}
+
+func (unavailableKMS) CryptoSigner(context.Context, func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	return unavailableCryptoSigner{}, crypto.SHA256, nil
+}
+
+// unavailableCryptoSigner is the crypto.Signer of unavailableKMS, which has no public key
+type unavailableCryptoSigner struct{}
+
+func (unavailableCryptoSigner) Public() crypto.PublicKey {
+	return nil
+}
+
+func (unavailableCryptoSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, errUnavailable
+}
+
+func TestCreateCertificateRequestPublicKeyError(t *testing.T) {
+	if _, err := signature.CreateCertificateRequest(context.Background(), unavailableKMS{}, signature.CertificateRequestOptions{}); !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the error fetching the public key, got %v", err)
+	}
+}
+
+func TestCreateCertificateRequestMismatchedAlgorithm(t *testing.T) {
+	// a PSS signer cannot produce the default PKCS#1 v1.5 signature for an RSA key
+	sv, _, err := signature.NewDefaultRSAPSSSignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signature.CreateCertificateRequest(context.Background(), sv, signature.CertificateRequestOptions{}); err == nil {
+		t.Fatal("expected error when the signature does not match the algorithm")
+	}
+}
+
+func TestProofOfPossession(t *testing.T) {
+	ctx := context.Background()
+	sv, _, err := signature.NewDefaultECDSASignerVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signature.SignProofOfPossession(ctx, sv, "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error signing proof of possession: %v", err)
+	}
+	if err := signature.VerifyProofOfPossession(ctx, sv, "user@example.com", sig); err != nil {
+		t.Fatalf("unexpected error verifying proof of possession: %v", err)
+	}
+	if err := signature.VerifyProofOfPossession(ctx, sv, "other@example.com", sig); err == nil {
+		t.Fatal("expected error verifying proof of possession for another subject")
+	}
+	if _, err := signature.SignProofOfPossession(ctx, sv, ""); err == nil {
+		t.Fatal("expected error with empty subject")
+	}
+}
@@ -25,10 +25,11 @@ import (
 
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
 // WrapSigner returns a signature.Signer that uses the DSSE encoding format
-func WrapSigner(s signature.Signer, payloadType string) signature.Signer {
+func WrapSigner(s signature.Signer, payloadType string) EnvelopeSigner {
 	return &wrappedSigner{
 		s:           s,
 		payloadType: payloadType,
@@ -51,33 +52,48 @@ func (w *wrappedSigner) SignMessage(r io.Reader, opts ...signature.SignOption) (
 	if err != nil {
 		return nil, err
 	}
-	pae := dsse.PAE(w.payloadType, p)
+	env, err := w.sign(w.payloadType, p, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// Sign returns an envelope containing payload, signed over its pre-authentication encoding with payloadType
+func (w *wrappedSigner) Sign(ctx context.Context, payloadType string, payload []byte) (*Envelope, error) {
+	return w.sign(payloadType, payload, options.WithContext(ctx))
+}
+
+func (w *wrappedSigner) sign(payloadType string, payload []byte, opts ...signature.SignOption) (*Envelope, error) {
+	pae := dsse.PAE(payloadType, payload)
 	sig, err := w.s.SignMessage(bytes.NewReader(pae), opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	env := dsse.Envelope{
-		PayloadType: w.payloadType,
-		Payload:     base64.StdEncoding.EncodeToString(p),
-		Signatures: []dsse.Signature{
+	return &Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []Signature{
 			{
 				Sig: base64.StdEncoding.EncodeToString(sig),
 			},
 		},
-	}
-	return json.Marshal(env)
+	}, nil
 }
 
-// WrapVerifier returns a signature.Verifier that uses the DSSE encoding format
-func WrapVerifier(v signature.Verifier) signature.Verifier {
+// WrapVerifier returns a signature.Verifier that uses the DSSE encoding format. Envelopes are
+// additionally checked against the payload type and key ID requirements in opts.
+func WrapVerifier(v signature.Verifier, opts ...VerifierOption) EnvelopeVerifier {
 	return &wrappedVerifier{
-		v: v,
+		v:    v,
+		opts: makeVerifierOptions(opts),
 	}
 }
 
 type wrappedVerifier struct {
-	v signature.Verifier
+	v    signature.Verifier
+	opts *verifierOptions
 }
 
 // PublicKey returns the public key associated with the verifier
@@ -87,42 +103,52 @@ func (w *wrappedVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.P
 
 // VerifySignature verifies the signature specified in an DSSE envelope
 func (w *wrappedVerifier) VerifySignature(s, _ io.Reader, _ ...signature.VerifyOption) error {
-	sig, err := io.ReadAll(s)
+	env, err := unmarshalEnvelope(s)
 	if err != nil {
 		return err
 	}
 
-	env := dsse.Envelope{}
-	if err := json.Unmarshal(sig, &env); err != nil {
-		return err
-	}
+	_, err = w.Verify(context.Background(), env)
+	return err
+}
 
-	pub, err := w.PublicKey()
+// Verify verifies the signature in env and returns its decoded content
+func (w *wrappedVerifier) Verify(ctx context.Context, env *Envelope) (*VerifiedPayload, error) {
+	pub, err := w.PublicKey(options.WithContext(ctx))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	verifier, err := dsse.NewEnvelopeVerifier(&VerifierAdapter{
+	return verifyEnvelope(ctx, env, w.opts, 1, &VerifierAdapter{
 		SignatureVerifier: w.v,
 
 		Pub:      pub,
 		PubKeyID: "", // We do not want to limit verification to a specific key.
 	})
+}
+
+// VerifySignatures verifies each signature in env on its own and returns one result per signature
+func (w *wrappedVerifier) VerifySignatures(ctx context.Context, env *Envelope) ([]SignatureResult, error) {
+	pub, err := w.PublicKey(options.WithContext(ctx))
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	_, err = verifier.Verify(context.Background(), &env)
-	return err
+	results, _, err := verifySignatures(ctx, env, w.opts, &VerifierAdapter{
+		SignatureVerifier: w.v,
+		Pub:               pub,
+	})
+	return results, err
 }
 
-// WrapSignerVerifier returns a signature.SignerVerifier that uses the DSSE encoding format
-func WrapSignerVerifier(sv signature.SignerVerifier, payloadType string) signature.SignerVerifier {
+// WrapSignerVerifier returns a signature.SignerVerifier that uses the DSSE encoding format.
+// Envelopes are additionally checked against the requirements in opts when verifying.
+func WrapSignerVerifier(sv signature.SignerVerifier, payloadType string, opts ...VerifierOption) EnvelopeSignerVerifier {
 	signer := &wrappedSigner{
 		payloadType: payloadType,
 		s:           sv,
 	}
 	verifier := &wrappedVerifier{
-		v: sv,
+		v:    sv,
+		opts: makeVerifierOptions(opts),
 	}
 
 	return &wrappedSignerVerifier{
@@ -150,3 +176,18 @@ func (w *wrappedSignerVerifier) VerifySignature(s, r io.Reader, opts ...signatur
 func (w *wrappedSignerVerifier) SignMessage(r io.Reader, opts ...signature.SignOption) ([]byte, error) {
 	return w.signer.SignMessage(r, opts...)
 }
+
+// Sign returns an envelope containing payload, signed over its pre-authentication encoding with payloadType
+func (w *wrappedSignerVerifier) Sign(ctx context.Context, payloadType string, payload []byte) (*Envelope, error) {
+	return w.signer.Sign(ctx, payloadType, payload)
+}
+
+// Verify verifies the signature in env and returns its decoded content
+func (w *wrappedSignerVerifier) Verify(ctx context.Context, env *Envelope) (*VerifiedPayload, error) {
+	return w.verifier.Verify(ctx, env)
+}
+
+// VerifySignatures verifies each signature in env on its own and returns one result per signature
+func (w *wrappedSignerVerifier) VerifySignatures(ctx context.Context, env *Envelope) ([]SignatureResult, error) {
+	return w.verifier.VerifySignatures(ctx, env)
+}
@@ -17,12 +17,14 @@ package dsse
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -182,3 +184,280 @@ func TestInvalidSignature(t *testing.T) {
 		t.Fatalf("Did not fail verification on bogus signature")
 	}
 }
+
+func TestSignVerifyEnvelope(t *testing.T) {
+	p, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sv, err := signature.LoadECDSASignerVerifier(p, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID, err := dsse.SHA256KeyID(p.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	data := []byte("sometestdata")
+	payloadType := "foo"
+
+	// the payload type passed to Sign takes precedence over the one the signer was wrapped with
+	wsv := WrapSignerVerifier(sv, "bar")
+	env, err := wsv.Sign(ctx, payloadType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, v := range map[string]EnvelopeVerifier{
+		"signer verifier": wsv,
+		"verifier":        WrapVerifier(sv),
+	} {
+		t.Run(name, func(t *testing.T) {
+			vp, err := v.Verify(ctx, env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vp.PayloadType != payloadType {
+				t.Errorf("Expected payloadType %s, got %s", payloadType, vp.PayloadType)
+			}
+			if !bytes.Equal(vp.Payload, data) {
+				t.Errorf("Expected payload %s, got %s", data, vp.Payload)
+			}
+			if len(vp.KeyIDs) != 1 || vp.KeyIDs[0] != keyID {
+				t.Errorf("Expected key IDs [%s], got %v", keyID, vp.KeyIDs)
+			}
+		})
+	}
+
+	// envelopes from Sign can be verified by VerifySignature and vice versa
+	b, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wsv.VerifySignature(bytes.NewReader(b), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *env
+	tampered.PayloadType = "bar"
+	if _, err := wsv.Verify(ctx, &tampered); err == nil {
+		t.Fatal("Did not fail verification with modified payload type")
+	}
+	if _, err := wsv.Verify(ctx, nil); err == nil {
+		t.Fatal("Did not fail verification of nil envelope")
+	}
+}
+
+func TestMultiSignVerifyEnvelope(t *testing.T) {
+	var svs []signature.SignerVerifier
+	var keyIDs []string
+	for i := 0; i < 3; i++ {
+		p, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sv, err := signature.LoadECDSASignerVerifier(p, crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyID, err := dsse.SHA256KeyID(p.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		svs = append(svs, sv)
+		keyIDs = append(keyIDs, keyID)
+	}
+
+	ctx := context.Background()
+	data := []byte("sometestdata")
+	payloadType := "foo"
+
+	env, err := WrapMultiSigner(payloadType, svs[0], svs[1]).Sign(ctx, payloadType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env.Signatures) != 2 || env.Signatures[0].KeyID != keyIDs[0] || env.Signatures[1].KeyID != keyIDs[1] {
+		t.Fatalf("Unexpected signatures %v", env.Signatures)
+	}
+
+	vp, err := WrapMultiVerifier(payloadType, 2, svs[0], svs[1], svs[2]).Verify(ctx, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vp.PayloadType != payloadType || !bytes.Equal(vp.Payload, data) {
+		t.Errorf("Unexpected verified payload %s of type %s", vp.Payload, vp.PayloadType)
+	}
+	if strings.Join(vp.KeyIDs, ",") != strings.Join(keyIDs[:2], ",") {
+		t.Errorf("Expected key IDs %v, got %v", keyIDs[:2], vp.KeyIDs)
+	}
+
+	if _, err := WrapMultiVerifier(payloadType, 2, svs[0], svs[2]).Verify(ctx, env); err == nil {
+		t.Fatal("Did not fail verification below threshold")
+	}
+}
+
+func TestAddSignature(t *testing.T) {
+	var svs []signature.SignerVerifier
+	var keyIDs []string
+	for i := 0; i < 3; i++ {
+		p, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sv, err := signature.LoadECDSASignerVerifier(p, crypto.SHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyID, err := dsse.SHA256KeyID(p.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		svs = append(svs, sv)
+		keyIDs = append(keyIDs, keyID)
+	}
+
+	ctx := context.Background()
+	payloadType := "foo"
+	env, err := WrapSigner(svs[0], payloadType).Sign(ctx, payloadType, []byte("sometestdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := env.Signatures[0]
+
+	cosigned, err := AddSignature(ctx, env, svs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env.Signatures) != 1 {
+		t.Fatalf("AddSignature modified the original envelope: %v", env.Signatures)
+	}
+	if len(cosigned.Signatures) != 2 || cosigned.Signatures[0] != first || cosigned.Signatures[1].KeyID != keyIDs[1] {
+		t.Fatalf("Unexpected signatures %v", cosigned.Signatures)
+	}
+	if _, err := AddSignature(ctx, cosigned, svs[1]); err == nil {
+		t.Fatal("Did not fail adding a second signature with the same key")
+	}
+
+	wv := WrapMultiVerifier(payloadType, 2, svs[0], svs[1], svs[2])
+	if _, err := wv.Verify(ctx, env); err == nil {
+		t.Fatal("Did not fail verification below threshold")
+	}
+	vp, err := wv.Verify(ctx, cosigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vp.KeyIDs) != 2 {
+		t.Errorf("Expected two key IDs, got %v", vp.KeyIDs)
+	}
+
+	// per-signature results are reported independently of the threshold
+	results, err := WrapMultiVerifier(payloadType, 1, svs[1], svs[2]).VerifySignatures(ctx, cosigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected two results, got %v", results)
+	}
+	if results[0].Err == nil || results[0].Signature != first {
+		t.Errorf("Expected signature from an unknown key to fail, got %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].KeyID != keyIDs[1] {
+		t.Errorf("Expected signature to verify with key %s, got %+v", keyIDs[1], results[1])
+	}
+}
+
+func TestVerifierOptions(t *testing.T) {
+	p, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(p, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSV, err := signature.LoadECDSASignerVerifier(other, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	payloadType := "application/vnd.in-toto+json"
+	// WrapSigner does not set a key ID, AddSignature does
+	unbound, err := WrapSigner(sv, payloadType).Sign(ctx, payloadType, []byte("sometestdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bound, err := AddSignature(ctx, &Envelope{PayloadType: unbound.PayloadType, Payload: unbound.Payload}, sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrongKeyID := *bound
+	wrongKeyID.Signatures = []Signature{{KeyID: "SHA256:other", Sig: bound.Signatures[0].Sig}}
+	otherEnv, err := AddSignature(ctx, &Envelope{PayloadType: unbound.PayloadType, Payload: unbound.Payload}, otherSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badSig := *otherEnv
+	badSig.Signatures = []Signature{{KeyID: bound.Signatures[0].KeyID, Sig: otherEnv.Signatures[0].Sig}}
+
+	tests := []struct {
+		name    string
+		env     *Envelope
+		opts    []VerifierOption
+		wantErr interface{}
+	}{
+		{name: "no options", env: unbound},
+		{name: "expected type", env: unbound, opts: []VerifierOption{WithPayloadType(payloadType)}},
+		{name: "allowed types", env: unbound, opts: []VerifierOption{WithAllowedPayloadTypes("foo", payloadType)}},
+		{name: "unexpected type", env: unbound, opts: []VerifierOption{WithPayloadType("foo")}, wantErr: &PayloadTypeError{}},
+		{name: "type not allowed", env: unbound, opts: []VerifierOption{WithAllowedPayloadTypes("foo", "bar")}, wantErr: &PayloadTypeError{}},
+		{name: "strict key ID", env: bound, opts: []VerifierOption{WithStrictKeyID()}},
+		{name: "strict missing key ID", env: unbound, opts: []VerifierOption{WithStrictKeyID()}, wantErr: &KeyIDError{}},
+		{name: "strict wrong key ID", env: &wrongKeyID, opts: []VerifierOption{WithStrictKeyID()}, wantErr: &KeyIDError{}},
+		{name: "bad signature", env: &badSig, opts: []VerifierOption{WithStrictKeyID()}, wantErr: &SignatureError{}},
+		{name: "other key", env: otherEnv, wantErr: &KeyIDError{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, v := range map[string]EnvelopeVerifier{
+				"single": WrapVerifier(sv, tt.opts...),
+				"multi":  WrapMultiVerifierWithOpts(payloadType, 1, []signature.Verifier{sv}, tt.opts...),
+			} {
+				_, err := v.Verify(ctx, tt.env)
+				switch want := tt.wantErr.(type) {
+				case nil:
+					if err != nil {
+						t.Errorf("%s: unexpected error %v", name, err)
+					}
+				case *PayloadTypeError:
+					if !errors.As(err, &want) {
+						t.Errorf("%s: expected PayloadTypeError, got %v", name, err)
+					}
+				case *KeyIDError:
+					if !errors.As(err, &want) {
+						t.Errorf("%s: expected KeyIDError, got %v", name, err)
+					}
+				case *SignatureError:
+					var keyIDErr *KeyIDError
+					if !errors.As(err, &want) || errors.As(err, &keyIDErr) {
+						t.Errorf("%s: expected only SignatureError, got %v", name, err)
+					}
+				}
+				// signature failures are reported through the threshold check
+				if _, ok := tt.wantErr.(*KeyIDError); ok {
+					var thresholdErr *ThresholdError
+					if !errors.As(err, &thresholdErr) {
+						t.Errorf("%s: expected ThresholdError, got %v", name, err)
+					}
+				}
+			}
+		})
+	}
+}
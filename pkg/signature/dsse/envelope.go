@@ -0,0 +1,253 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsse
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// Envelope is a DSSE envelope, see https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+type Envelope = dsse.Envelope
+
+// Signature is a single signature within a DSSE envelope
+type Signature = dsse.Signature
+
+// VerifiedPayload is the content of an envelope whose signatures were verified
+type VerifiedPayload struct {
+	// PayloadType is the payload type the signatures were verified over
+	PayloadType string
+	// Payload is the decoded payload
+	Payload []byte
+	// KeyIDs lists the IDs of the keys whose signatures were accepted
+	KeyIDs []string
+}
+
+// EnvelopeSigner is a signature.Signer which can also return the DSSE envelope it creates
+type EnvelopeSigner interface {
+	signature.Signer
+	// Sign returns an envelope containing payload, signed over its pre-authentication encoding with payloadType
+	Sign(ctx context.Context, payloadType string, payload []byte) (*Envelope, error)
+}
+
+// EnvelopeVerifier is a signature.Verifier which can also return the content of the DSSE envelope it verifies
+type EnvelopeVerifier interface {
+	signature.Verifier
+	// Verify verifies the signatures in env and returns its decoded content
+	Verify(ctx context.Context, env *Envelope) (*VerifiedPayload, error)
+	// VerifySignatures verifies each signature in env on its own, without applying a threshold,
+	// and returns one result per signature in the order they appear in env
+	VerifySignatures(ctx context.Context, env *Envelope) ([]SignatureResult, error)
+}
+
+// EnvelopeSignerVerifier combines EnvelopeSigner and EnvelopeVerifier
+type EnvelopeSignerVerifier interface {
+	EnvelopeSigner
+	EnvelopeVerifier
+}
+
+// unmarshalEnvelope reads a JSON encoded envelope from r
+func unmarshalEnvelope(r io.Reader) (*Envelope, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	env := &Envelope{}
+	if err := json.Unmarshal(b, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// SignatureResult is the outcome of verifying a single signature in an envelope
+type SignatureResult struct {
+	// Signature is the signature as it appears in the envelope
+	Signature Signature
+	// KeyID is the ID of the key which verified the signature, if any
+	KeyID string
+	// Err is nil if the signature was verified, and otherwise describes why it was not
+	Err error
+
+	verifier int
+}
+
+// verifierKeyID returns the key ID of v, deriving it from the public key if v does not provide one
+func verifierKeyID(v dsse.Verifier) string {
+	if keyID, err := v.KeyID(); err == nil && keyID != "" {
+		return keyID
+	}
+	keyID, err := dsse.SHA256KeyID(v.Public())
+	if err != nil {
+		return ""
+	}
+	return keyID
+}
+
+// verifySignatures verifies each signature in env against verifiers independently. An error
+// is returned only if the envelope itself cannot be verified.
+func verifySignatures(ctx context.Context, env *Envelope, o *verifierOptions, verifiers ...dsse.Verifier) ([]SignatureResult, []byte, error) {
+	if env == nil {
+		return nil, nil, errors.New("cannot verify a nil envelope")
+	}
+	if err := o.checkPayloadType(env.PayloadType); err != nil {
+		return nil, nil, err
+	}
+	if len(env.Signatures) == 0 {
+		return nil, nil, dsse.ErrNoSignature
+	}
+	payload, err := env.DecodeB64Payload()
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding payload: %w", err)
+	}
+	pae := dsse.PAE(env.PayloadType, payload)
+
+	keyIDs := make([]string, len(verifiers))
+	for i, v := range verifiers {
+		if o.strictKeyID {
+			// an unusable key ID is left empty, which no signature can match
+			keyIDs[i], _ = dsse.SHA256KeyID(v.Public())
+		} else {
+			keyIDs[i] = verifierKeyID(v)
+		}
+	}
+
+	results := make([]SignatureResult, len(env.Signatures))
+	for i, s := range env.Signatures {
+		results[i] = SignatureResult{Signature: s, verifier: -1}
+		if o.strictKeyID && s.KeyID == "" {
+			results[i].Err = &KeyIDError{}
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			if sig, err = base64.URLEncoding.DecodeString(s.Sig); err != nil {
+				results[i].Err = &SignatureError{KeyID: s.KeyID, Err: errors.New("unable to base64 decode signature")}
+				continue
+			}
+		}
+		candidates := 0
+		for j, v := range verifiers {
+			// signatures and verifiers which both carry a key ID are only matched with each other,
+			// and in strict mode both always do
+			if s.KeyID != "" && (keyIDs[j] != "" || o.strictKeyID) && s.KeyID != keyIDs[j] {
+				continue
+			}
+			candidates++
+			if err := v.Verify(ctx, pae, sig); err == nil {
+				results[i].KeyID = keyIDs[j]
+				results[i].verifier = j
+				break
+			}
+		}
+		switch {
+		case results[i].verifier >= 0:
+		case candidates == 0:
+			results[i].Err = &KeyIDError{KeyID: s.KeyID}
+		default:
+			results[i].Err = &SignatureError{KeyID: s.KeyID}
+		}
+	}
+	return results, payload, nil
+}
+
+// verifyEnvelope verifies that at least threshold of the verifiers accept a signature in env
+func verifyEnvelope(ctx context.Context, env *Envelope, o *verifierOptions, threshold int, verifiers ...dsse.Verifier) (*VerifiedPayload, error) {
+	if threshold <= 0 || threshold > len(verifiers) {
+		return nil, errors.New("invalid threshold")
+	}
+	results, payload, err := verifySignatures(ctx, env, o, verifiers...)
+	if err != nil {
+		return nil, err
+	}
+
+	// each verifier counts towards the threshold at most once
+	accepted := map[int]bool{}
+	keyIDs := []string{}
+	for _, r := range results {
+		if r.Err != nil || accepted[r.verifier] {
+			continue
+		}
+		accepted[r.verifier] = true
+		keyIDs = append(keyIDs, r.KeyID)
+	}
+	if len(accepted) < threshold {
+		return nil, &ThresholdError{Accepted: len(accepted), Threshold: threshold, Results: results}
+	}
+	return &VerifiedPayload{
+		PayloadType: env.PayloadType,
+		Payload:     payload,
+		KeyIDs:      keyIDs,
+	}, nil
+}
+
+// AddSignature returns a copy of env with a signature from s appended to its existing signatures,
+// which are left untouched. The new signature is made over the pre-authentication encoding of the
+// envelope's payload and payload type, and carries the SHA256 key ID of the signer's public key.
+// If s is also a signature.Verifier, the new signature is verified before it is added.
+func AddSignature(ctx context.Context, env *Envelope, s signature.Signer) (*Envelope, error) {
+	if env == nil {
+		return nil, errors.New("cannot add a signature to a nil envelope")
+	}
+	payload, err := env.DecodeB64Payload()
+	if err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+	pub, err := s.PublicKey(options.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := dsse.SHA256KeyID(pub)
+	if err != nil {
+		return nil, fmt.Errorf("computing key ID: %w", err)
+	}
+	for _, existing := range env.Signatures {
+		if existing.KeyID == keyID {
+			return nil, fmt.Errorf("envelope is already signed by key ID %q", keyID)
+		}
+	}
+
+	pae := dsse.PAE(env.PayloadType, payload)
+	sig, err := s.SignMessage(bytes.NewReader(pae), options.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := s.(signature.Verifier); ok {
+		if err := v.VerifySignature(bytes.NewReader(sig), bytes.NewReader(pae), options.WithContext(ctx)); err != nil {
+			return nil, fmt.Errorf("verifying new signature: %w", err)
+		}
+	}
+
+	signatures := make([]Signature, 0, len(env.Signatures)+1)
+	signatures = append(signatures, env.Signatures...)
+	signatures = append(signatures, Signature{
+		KeyID: keyID,
+		Sig:   base64.StdEncoding.EncodeToString(sig),
+	})
+	return &Envelope{
+		PayloadType: env.PayloadType,
+		Payload:     env.Payload,
+		Signatures:  signatures,
+	}, nil
+}
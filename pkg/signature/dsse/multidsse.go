@@ -32,7 +32,7 @@ type wrappedMultiSigner struct {
 }
 
 // WrapMultiSigner returns a signature.Signer that uses the DSSE encoding format
-func WrapMultiSigner(payloadType string, sL ...signature.Signer) signature.Signer {
+func WrapMultiSigner(payloadType string, sL ...signature.Signer) EnvelopeSigner {
 	signerAdapterL := make([]dsse.Signer, 0, len(sL))
 	for _, s := range sL {
 		pub, err := s.PublicKey()
@@ -47,7 +47,7 @@ func WrapMultiSigner(payloadType string, sL ...signature.Signer) signature.Signe
 
 		signerAdapter := &SignerAdapter{
 			SignatureSigner: s,
-			Pub:             s.PublicKey,
+			Pub:             pub,
 			PubKeyID:        keyID, // We do not want to limit verification to a specific key.
 		}
 
@@ -72,27 +72,40 @@ func (wL *wrappedMultiSigner) SignMessage(r io.Reader, _ ...signature.SignOption
 		return nil, err
 	}
 
-	envSigner, err := dsse.NewEnvelopeSigner(wL.sLAdapters...)
+	env, err := wL.Sign(context.Background(), wL.payloadType, p)
 	if err != nil {
 		return nil, err
 	}
 
-	env, err := envSigner.SignPayload(context.Background(), wL.payloadType, p)
+	return json.Marshal(env)
+}
+
+// Sign returns an envelope containing payload with one signature per signer, each over its
+// pre-authentication encoding with payloadType
+func (wL *wrappedMultiSigner) Sign(ctx context.Context, payloadType string, payload []byte) (*Envelope, error) {
+	envSigner, err := dsse.NewEnvelopeSigner(wL.sLAdapters...)
 	if err != nil {
 		return nil, err
 	}
 
-	return json.Marshal(env)
+	return envSigner.SignPayload(ctx, payloadType, payload)
 }
 
 type wrappedMultiVerifier struct {
 	vLAdapters  []dsse.Verifier
 	threshold   int
 	payloadType string
+	opts        *verifierOptions
 }
 
 // WrapMultiVerifier returns a signature.Verifier that uses the DSSE encoding format
-func WrapMultiVerifier(payloadType string, threshold int, vL ...signature.Verifier) signature.Verifier {
+func WrapMultiVerifier(payloadType string, threshold int, vL ...signature.Verifier) EnvelopeVerifier {
+	return WrapMultiVerifierWithOpts(payloadType, threshold, vL)
+}
+
+// WrapMultiVerifierWithOpts returns a signature.Verifier that uses the DSSE encoding format and
+// additionally checks envelopes against the payload type and key ID requirements in opts
+func WrapMultiVerifierWithOpts(payloadType string, threshold int, vL []signature.Verifier, opts ...VerifierOption) EnvelopeVerifier {
 	verifierAdapterL := make([]dsse.Verifier, 0, len(vL))
 	for _, v := range vL {
 		pub, err := v.PublicKey()
@@ -107,7 +120,7 @@ func WrapMultiVerifier(payloadType string, threshold int, vL ...signature.Verifi
 
 		verifierAdapter := &VerifierAdapter{
 			SignatureVerifier: v,
-			Pub:               v.PublicKey,
+			Pub:               pub,
 			PubKeyID:          keyID, // We do not want to limit verification to a specific key.
 		}
 
@@ -118,6 +131,7 @@ func WrapMultiVerifier(payloadType string, threshold int, vL ...signature.Verifi
 		vLAdapters:  verifierAdapterL,
 		payloadType: payloadType,
 		threshold:   threshold,
+		opts:        makeVerifierOptions(opts),
 	}
 }
 
@@ -128,27 +142,30 @@ func (wL *wrappedMultiVerifier) PublicKey(_ ...signature.PublicKeyOption) (crypt
 
 // VerifySignature verifies the signature specified in an DSSE envelope
 func (wL *wrappedMultiVerifier) VerifySignature(s, _ io.Reader, _ ...signature.VerifyOption) error {
-	sig, err := io.ReadAll(s)
+	env, err := unmarshalEnvelope(s)
 	if err != nil {
 		return err
 	}
 
-	env := dsse.Envelope{}
-	if err := json.Unmarshal(sig, &env); err != nil {
-		return err
-	}
+	_, err = wL.Verify(context.Background(), env)
+	return err
+}
 
-	envVerifier, err := dsse.NewMultiEnvelopeVerifier(wL.threshold, wL.vLAdapters...)
-	if err != nil {
-		return err
-	}
+// Verify verifies that signatures from at least threshold of the verifiers are present
+// in env and returns its decoded content
+func (wL *wrappedMultiVerifier) Verify(ctx context.Context, env *Envelope) (*VerifiedPayload, error) {
+	return verifyEnvelope(ctx, env, wL.opts, wL.threshold, wL.vLAdapters...)
+}
 
-	_, err = envVerifier.Verify(context.Background(), &env)
-	return err
+// VerifySignatures verifies each signature in env on its own and returns one result per
+// signature, reporting which signatures would count towards the threshold checked by Verify
+func (wL *wrappedMultiVerifier) VerifySignatures(ctx context.Context, env *Envelope) ([]SignatureResult, error) {
+	results, _, err := verifySignatures(ctx, env, wL.opts, wL.vLAdapters...)
+	return results, err
 }
 
 // WrapMultiSignerVerifier returns a signature.SignerVerifier that uses the DSSE encoding format
-func WrapMultiSignerVerifier(payloadType string, threshold int, svL ...signature.SignerVerifier) signature.SignerVerifier {
+func WrapMultiSignerVerifier(payloadType string, threshold int, svL ...signature.SignerVerifier) EnvelopeSignerVerifier {
 	signerL := make([]signature.Signer, 0, len(svL))
 	verifierL := make([]signature.Verifier, 0, len(svL))
 	for _, sv := range svL {
@@ -166,8 +183,8 @@ func WrapMultiSignerVerifier(payloadType string, threshold int, svL ...signature
 }
 
 type wrappedMultiSignerVerifier struct {
-	signer   signature.Signer
-	verifier signature.Verifier
+	signer   EnvelopeSigner
+	verifier EnvelopeVerifier
 }
 
 // PublicKey returns the public key associated with the verifier
@@ -184,3 +201,18 @@ func (w *wrappedMultiSignerVerifier) VerifySignature(s, r io.Reader, opts ...sig
 func (w *wrappedMultiSignerVerifier) SignMessage(r io.Reader, opts ...signature.SignOption) ([]byte, error) {
 	return w.signer.SignMessage(r, opts...)
 }
+
+// Sign returns an envelope containing payload with one signature per signer
+func (w *wrappedMultiSignerVerifier) Sign(ctx context.Context, payloadType string, payload []byte) (*Envelope, error) {
+	return w.signer.Sign(ctx, payloadType, payload)
+}
+
+// Verify verifies the signatures in env against the threshold and returns its decoded content
+func (w *wrappedMultiSignerVerifier) Verify(ctx context.Context, env *Envelope) (*VerifiedPayload, error) {
+	return w.verifier.Verify(ctx, env)
+}
+
+// VerifySignatures verifies each signature in env on its own and returns one result per signature
+func (w *wrappedMultiSignerVerifier) VerifySignatures(ctx context.Context, env *Envelope) ([]SignatureResult, error) {
+	return w.verifier.VerifySignatures(ctx, env)
+}
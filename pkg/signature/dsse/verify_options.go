@@ -0,0 +1,131 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsse
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// VerifierOption configures the checks made by a DSSE verifier in addition to signature verification
+type VerifierOption func(*verifierOptions)
+
+type verifierOptions struct {
+	payloadTypes []string
+	strictKeyID  bool
+}
+
+func makeVerifierOptions(opts []VerifierOption) *verifierOptions {
+	o := &verifierOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithPayloadType rejects envelopes whose payload type is not payloadType with a *PayloadTypeError
+func WithPayloadType(payloadType string) VerifierOption {
+	return WithAllowedPayloadTypes(payloadType)
+}
+
+// WithAllowedPayloadTypes rejects envelopes whose payload type is not one of payloadTypes
+// with a *PayloadTypeError. Types from repeated uses of this option are combined.
+func WithAllowedPayloadTypes(payloadTypes ...string) VerifierOption {
+	return func(o *verifierOptions) {
+		o.payloadTypes = append(o.payloadTypes, payloadTypes...)
+	}
+}
+
+// WithStrictKeyID only accepts signatures whose keyid equals the dsse.SHA256KeyID of the
+// verifying key. Signatures without a keyid, or with one that matches no verifier, fail with
+// a *KeyIDError instead of being tried against every key.
+func WithStrictKeyID() VerifierOption {
+	return func(o *verifierOptions) {
+		o.strictKeyID = true
+	}
+}
+
+// checkPayloadType returns a *PayloadTypeError if payloadType is not allowed
+func (o *verifierOptions) checkPayloadType(payloadType string) error {
+	if len(o.payloadTypes) == 0 || slices.Contains(o.payloadTypes, payloadType) {
+		return nil
+	}
+	return &PayloadTypeError{PayloadType: payloadType, Allowed: o.payloadTypes}
+}
+
+// PayloadTypeError is returned when the payload type of an envelope is not allowed by the verifier
+type PayloadTypeError struct {
+	PayloadType string
+	Allowed     []string
+}
+
+func (e *PayloadTypeError) Error() string {
+	return fmt.Sprintf("payload type %q is not allowed, expected one of [%s]", e.PayloadType, strings.Join(e.Allowed, ", "))
+}
+
+// KeyIDError is returned for a signature whose keyid does not identify any of the verifying keys
+type KeyIDError struct {
+	KeyID string
+}
+
+func (e *KeyIDError) Error() string {
+	if e.KeyID == "" {
+		return "signature has no key ID"
+	}
+	return fmt.Sprintf("no verifier for key ID %q", e.KeyID)
+}
+
+// SignatureError is returned for a signature which is malformed or does not verify with any of
+// the candidate keys
+type SignatureError struct {
+	KeyID string
+	Err   error
+}
+
+func (e *SignatureError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid signature: %v", e.Err)
+	}
+	return "signature did not verify with any key"
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// ThresholdError is returned when fewer keys than required accepted a signature in the envelope.
+// It wraps the errors of the individual signatures, so the reason they were rejected can be
+// checked with errors.As.
+type ThresholdError struct {
+	Accepted  int
+	Threshold int
+	Results   []SignatureResult
+}
+
+func (e *ThresholdError) Error() string {
+	return fmt.Sprintf("accepted signatures do not match threshold, Found: %d, Expected %d", e.Accepted, e.Threshold)
+}
+
+func (e *ThresholdError) Unwrap() []error {
+	var errs []error
+	for _, r := range e.Results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
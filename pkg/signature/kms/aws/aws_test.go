@@ -15,7 +15,14 @@
 
 package aws
 
-import "testing"
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
 
 func TestParseReference(t *testing.T) {
 	tests := []struct {
@@ -156,3 +163,30 @@ func TestParseReference(t *testing.T) {
 		})
 	}
 }
+
+func TestSupportedAlgorithms(t *testing.T) {
+	sv := &SignerVerifier{}
+	for _, algorithm := range sv.SupportedAlgorithms() {
+		alg, err := cryptoutils.GetSigningAlgorithm(algorithm)
+		if err != nil {
+			t.Fatalf("supported algorithm is not a canonical ID: %v", err)
+		}
+		spec, ok := awsKeySpecs[algorithm]
+		if !ok {
+			t.Fatalf("no key spec for %s", algorithm)
+		}
+		// the legacy key spec maps to itself
+		if awsKeySpecs[string(spec)] != spec {
+			t.Fatalf("key spec %s is not accepted", spec)
+		}
+		if alg.KeyType == cryptoutils.KeyTypeRSA && spec != types.CustomerMasterKeySpec(fmt.Sprintf("RSA_%d", alg.RSAKeySize)) {
+			t.Fatalf("%s maps to key spec %s", algorithm, spec)
+		}
+	}
+	if sv.DefaultAlgorithm() != cryptoutils.AlgorithmECDSAP256SHA256 {
+		t.Fatalf("unexpected default algorithm %s", sv.DefaultAlgorithm())
+	}
+	if _, err := sv.CreateKey(context.Background(), cryptoutils.AlgorithmED25519); err == nil {
+		t.Fatal("expected error for unsupported algorithm")
+	}
+}
@@ -41,9 +41,11 @@ import (
 )
 
 func init() {
-	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+	if err := sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
 		return LoadSignerVerifier(ctx, keyResourceID)
-	})
+	}); err != nil {
+		panic(err)
+	}
 }
 
 const (
@@ -245,7 +247,7 @@ func (a *awsClient) getCMK(ctx context.Context) (*cmk, error) {
 	return nil, lerr
 }
 
-func (a *awsClient) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+func (a *awsClient) createKey(ctx context.Context, spec types.CustomerMasterKeySpec) (crypto.PublicKey, error) {
 	if a.alias == "" {
 		return nil, errors.New("must use alias key format")
 	}
@@ -266,7 +268,7 @@ func (a *awsClient) createKey(ctx context.Context, algorithm string) (crypto.Pub
 	usage := types.KeyUsageTypeSignVerify
 	description := "Created by Sigstore"
 	key, err := a.client.CreateKey(ctx, &kms.CreateKeyInput{
-		CustomerMasterKeySpec: types.CustomerMasterKeySpec(algorithm),
+		CustomerMasterKeySpec: spec,
 		KeyUsage:              usage,
 		Description:           &description,
 	})
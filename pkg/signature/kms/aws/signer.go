@@ -20,20 +20,41 @@ import (
 	"crypto"
 	"fmt"
 	"io"
+	"slices"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
-var awsSupportedAlgorithms = []types.CustomerMasterKeySpec{
-	types.CustomerMasterKeySpecRsa2048,
-	types.CustomerMasterKeySpecRsa3072,
-	types.CustomerMasterKeySpecRsa4096,
-	types.CustomerMasterKeySpecEccNistP256,
-	types.CustomerMasterKeySpecEccNistP384,
-	types.CustomerMasterKeySpecEccNistP521,
+// awsSupportedAlgorithms are the canonical IDs of the algorithms of keys that can be created. RSA
+// keys are used with the first signing algorithm AWS reports for them, RSASSA-PKCS1-v1_5 with SHA-256.
+var awsSupportedAlgorithms = []string{
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256,
+	cryptoutils.AlgorithmECDSAP256SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384,
+	cryptoutils.AlgorithmECDSAP521SHA512,
+}
+
+// awsKeySpecs maps the canonical IDs of the supported algorithms, and the key specs accepted before
+// them, to key specs
+var awsKeySpecs = map[string]types.CustomerMasterKeySpec{
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256:     types.CustomerMasterKeySpecRsa2048,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256:     types.CustomerMasterKeySpecRsa3072,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256:     types.CustomerMasterKeySpecRsa4096,
+	cryptoutils.AlgorithmECDSAP256SHA256:           types.CustomerMasterKeySpecEccNistP256,
+	cryptoutils.AlgorithmECDSAP384SHA384:           types.CustomerMasterKeySpecEccNistP384,
+	cryptoutils.AlgorithmECDSAP521SHA512:           types.CustomerMasterKeySpecEccNistP521,
+	string(types.CustomerMasterKeySpecRsa2048):     types.CustomerMasterKeySpecRsa2048,
+	string(types.CustomerMasterKeySpecRsa3072):     types.CustomerMasterKeySpecRsa3072,
+	string(types.CustomerMasterKeySpecRsa4096):     types.CustomerMasterKeySpecRsa4096,
+	string(types.CustomerMasterKeySpecEccNistP256): types.CustomerMasterKeySpecEccNistP256,
+	string(types.CustomerMasterKeySpecEccNistP384): types.CustomerMasterKeySpecEccNistP384,
+	string(types.CustomerMasterKeySpecEccNistP521): types.CustomerMasterKeySpecEccNistP521,
 }
 
 var awsSupportedHashFuncs = []crypto.Hash{
@@ -180,9 +201,14 @@ func (a *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signatu
 	return a.client.verifyRemotely(ctx, sigBytes, digest)
 }
 
-// CreateKey attempts to create a new key in Vault with the specified algorithm.
+// CreateKey attempts to create a new key in AWS KMS with the specified algorithm, which is one of
+// SupportedAlgorithms or an AWS key spec such as "ECC_NIST_P256".
 func (a *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
-	return a.client.createKey(ctx, algorithm)
+	spec, ok := awsKeySpecs[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	return a.client.createKey(ctx, spec)
 }
 
 type cryptoSignerWrapper struct {
@@ -232,16 +258,12 @@ func (a *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error))
 	return csw, defaultHf, nil
 }
 
-// SupportedAlgorithms returns the list of algorithms supported by the AWS KMS service
+// SupportedAlgorithms returns the canonical IDs of the algorithms supported by the AWS KMS service
 func (*SignerVerifier) SupportedAlgorithms() []string {
-	s := make([]string, len(awsSupportedAlgorithms))
-	for i := range awsSupportedAlgorithms {
-		s[i] = string(awsSupportedAlgorithms[i])
-	}
-	return s
+	return slices.Clone(awsSupportedAlgorithms)
 }
 
-// DefaultAlgorithm returns the default algorithm for the AWS KMS service
+// DefaultAlgorithm returns the canonical ID of the default algorithm for the AWS KMS service
 func (*SignerVerifier) DefaultAlgorithm() string {
-	return string(types.CustomerMasterKeySpecEccNistP256)
+	return cryptoutils.AlgorithmECDSAP256SHA256
 }
@@ -45,9 +45,11 @@ import (
 )
 
 func init() {
-	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+	if err := sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
 		return LoadSignerVerifier(ctx, keyResourceID)
-	})
+	}); err != nil {
+		panic(err)
+	}
 }
 
 type kvClient interface {
@@ -218,7 +220,7 @@ func (a *azureVaultClient) public(ctx context.Context) (crypto.PublicKey, error)
 	return item.Value(), nil
 }
 
-func (a *azureVaultClient) createKey(ctx context.Context) (crypto.PublicKey, error) {
+func (a *azureVaultClient) createKey(ctx context.Context, curve azkeys.CurveName) (crypto.PublicKey, error) {
 	// check if the key already exists by attempting to fetch it
 	_, err := a.getKey(ctx)
 	// if the error is nil, this means the key already exists
@@ -257,7 +259,8 @@ func (a *azureVaultClient) createKey(ctx context.Context) (crypto.PublicKey, err
 				to.Ptr(azkeys.KeyOperationSign),
 				to.Ptr(azkeys.KeyOperationVerify),
 			},
-			Kty: to.Ptr(azkeys.KeyTypeEC),
+			Kty:   to.Ptr(azkeys.KeyTypeEC),
+			Curve: to.Ptr(curve),
 			Tags: map[string]*string{
 				"use": to.Ptr("sigstore"),
 			},
@@ -28,6 +28,7 @@ import (
 	"testing"
 
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
@@ -35,15 +36,17 @@ import (
 )
 
 type testKVClient struct {
-	key azkeys.JSONWebKey
+	key    azkeys.JSONWebKey
+	params azkeys.CreateKeyParameters
 }
 
-func (c *testKVClient) CreateKey(_ context.Context, _ string, _ azkeys.CreateKeyParameters, _ *azkeys.CreateKeyOptions) (azkeys.CreateKeyResponse, error) {
+func (c *testKVClient) CreateKey(_ context.Context, _ string, params azkeys.CreateKeyParameters, _ *azkeys.CreateKeyOptions) (azkeys.CreateKeyResponse, error) {
 	key, err := generatePublicKey("EC")
 	if err != nil {
 		return azkeys.CreateKeyResponse{}, err
 	}
 	c.key = key
+	c.params = params
 
 	return azkeys.CreateKeyResponse{
 		KeyBundle: azkeys.KeyBundle{
@@ -233,7 +236,7 @@ func TestAzureVaultClientCreateKey(t *testing.T) {
 			),
 		}
 
-		_, err = client.createKey(context.Background())
+		_, err = client.createKey(context.Background(), azkeys.CurveNameP256)
 		if err != nil && tc.expectSuccess {
 			t.Fatalf("Test '%s' failed. Expected nil error, actual value: %v", tc.name, err)
 		}
@@ -243,6 +246,42 @@ func TestAzureVaultClientCreateKey(t *testing.T) {
 	}
 }
 
+func TestSignerVerifierCreateKeyAlgorithms(t *testing.T) {
+	key, err := generatePublicKey("EC")
+	if err != nil {
+		t.Fatalf("unexpected error while generating public key for testing: %v", err)
+	}
+	for algorithm, curve := range map[string]azkeys.CurveName{
+		"":                                   azkeys.CurveNameP256,
+		cryptoutils.AlgorithmECDSAP256SHA256: azkeys.CurveNameP256,
+		cryptoutils.AlgorithmECDSAP384SHA384: azkeys.CurveNameP384,
+		cryptoutils.AlgorithmECDSAP521SHA512: azkeys.CurveNameP521,
+		AlgorithmES384:                       azkeys.CurveNameP384,
+	} {
+		kv := &keyNotFoundClient{key: key, getKeyReturnsErr: true, getKeyCallThreshold: 1}
+		sv := &SignerVerifier{client: &azureVaultClient{
+			client:   kv,
+			keyCache: ttlcache.New[string, crypto.PublicKey](ttlcache.WithDisableTouchOnHit[string, crypto.PublicKey]()),
+		}}
+		if _, err := sv.CreateKey(context.Background(), algorithm); err != nil {
+			t.Fatalf("%q: unexpected error creating key: %v", algorithm, err)
+		}
+		if kv.params.Curve == nil || *kv.params.Curve != curve {
+			t.Fatalf("%q: expected key with curve %s, got %v", algorithm, curve, kv.params.Curve)
+		}
+	}
+
+	sv := &SignerVerifier{client: &azureVaultClient{client: &testKVClient{}}}
+	if _, err := sv.CreateKey(context.Background(), cryptoutils.AlgorithmRSAPKCS1v152048SHA256); err == nil {
+		t.Fatal("expected error for unsupported algorithm")
+	}
+	for _, algorithm := range sv.SupportedAlgorithms() {
+		if _, err := cryptoutils.GetSigningAlgorithm(algorithm); err != nil {
+			t.Fatalf("supported algorithm is not a canonical ID: %v", err)
+		}
+	}
+}
+
 func TestParseReference(t *testing.T) {
 	tests := []struct {
 		in             string
@@ -27,6 +27,7 @@ import (
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -125,7 +126,7 @@ func TestCreateKey(t *testing.T) {
 		t.Fatalf("LoadSignerVerifier unexpectedly returned non-nil error: %v", err)
 	}
 
-	publicKey, err := sv.client.createKey(context.Background())
+	publicKey, err := sv.client.createKey(context.Background(), azkeys.CurveNameP256)
 	if err != nil {
 		t.Errorf("getKey failed with error: %v", err)
 	}
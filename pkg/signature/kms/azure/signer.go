@@ -26,6 +26,8 @@ import (
 	"golang.org/x/crypto/cryptobyte"
 	"golang.org/x/crypto/cryptobyte/asn1"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
@@ -36,6 +38,8 @@ var azureSupportedHashFuncs = []crypto.Hash{
 	crypto.SHA512,
 }
 
+// Algorithm names accepted by CreateKey in addition to the canonical IDs in cryptoutils
+//
 //nolint:revive
 const (
 	AlgorithmES256 = "ES256"
@@ -43,10 +47,21 @@ const (
 	AlgorithmES512 = "ES512"
 )
 
+// azureSupportedAlgorithms are the canonical IDs of the algorithms of keys that can be created
 var azureSupportedAlgorithms = []string{
-	AlgorithmES256,
-	AlgorithmES384,
-	AlgorithmES512,
+	cryptoutils.AlgorithmECDSAP256SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384,
+	cryptoutils.AlgorithmECDSAP521SHA512,
+}
+
+// azureCurves maps the canonical IDs and legacy names of the supported algorithms to key curves
+var azureCurves = map[string]azkeys.CurveName{
+	cryptoutils.AlgorithmECDSAP256SHA256: azkeys.CurveNameP256,
+	cryptoutils.AlgorithmECDSAP384SHA384: azkeys.CurveNameP384,
+	cryptoutils.AlgorithmECDSAP521SHA512: azkeys.CurveNameP521,
+	AlgorithmES256:                       azkeys.CurveNameP256,
+	AlgorithmES384:                       azkeys.CurveNameP384,
+	AlgorithmES512:                       azkeys.CurveNameP521,
 }
 
 // SignerVerifier creates and verifies digital signatures over a message using Azure KMS service
@@ -184,9 +199,18 @@ func (a *SignerVerifier) PublicKey(_ ...signature.PublicKeyOption) (crypto.Publi
 	return a.client.public(a.defaultCtx)
 }
 
-// CreateKey attempts to create a new key in Vault with the specified algorithm.
-func (a *SignerVerifier) CreateKey(ctx context.Context, _ string) (crypto.PublicKey, error) {
-	return a.client.createKey(ctx)
+// CreateKey attempts to create a new key in Vault with the specified algorithm, which is one of
+// SupportedAlgorithms or one of the Algorithm constants of this package. If algorithm is empty,
+// DefaultAlgorithm is used.
+func (a *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if algorithm == "" {
+		algorithm = a.DefaultAlgorithm()
+	}
+	curve, ok := azureCurves[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	return a.client.createKey(ctx, curve)
 }
 
 type cryptoSignerWrapper struct {
@@ -236,12 +260,12 @@ func (a *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error))
 	return csw, hashFunc, nil
 }
 
-// SupportedAlgorithms returns the list of algorithms supported by the Azure KMS service
+// SupportedAlgorithms returns the canonical IDs of the algorithms supported by the Azure KMS service
 func (*SignerVerifier) SupportedAlgorithms() []string {
 	return azureSupportedAlgorithms
 }
 
-// DefaultAlgorithm returns the default algorithm for the Azure KMS service
+// DefaultAlgorithm returns the canonical ID of the default algorithm for the Azure KMS service
 func (*SignerVerifier) DefaultAlgorithm() string {
-	return AlgorithmES256
+	return cryptoutils.AlgorithmECDSAP256SHA256
 }
@@ -0,0 +1,106 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cliplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/common"
+)
+
+var (
+	// ErrorPluginNotFound is returned when no plugin executable exists for the scheme of a key reference
+	ErrorPluginNotFound = errors.New("kms plugin not found")
+
+	// schemes name executables, so only allow characters that are safe in a file name
+	schemeRegex = regexp.MustCompile(`^([a-z0-9][a-z0-9.+-]*)://`)
+)
+
+// PluginError is an error reported by the plugin executable in its response
+type PluginError struct {
+	Executable string
+	Message    string
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("kms plugin %s: %s", e.Executable, e.Message)
+}
+
+// ExecutableName returns the name of the plugin executable serving the scheme of keyResourceID,
+// e.g. "sigstore-kms-mykms" for "mykms://my-key".
+func ExecutableName(keyResourceID string) (string, error) {
+	m := schemeRegex.FindStringSubmatch(keyResourceID)
+	if m == nil {
+		return "", fmt.Errorf("kms reference %q has no valid scheme", keyResourceID)
+	}
+	return common.PluginBinaryPrefix + m[1], nil
+}
+
+// findExecutable looks up the plugin executable for keyResourceID in PATH.
+func findExecutable(keyResourceID string) (string, error) {
+	name, err := ExecutableName(keyResourceID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrorPluginNotFound, err)
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrorPluginNotFound, err)
+	}
+	return path, nil
+}
+
+// invoke runs the plugin executable once for a single request. The protocol version is passed
+// as the only argument, the JSON-encoded request is written to stdin, and the JSON-encoded
+// response is read from stdout. The plugin's stderr is passed through so that it can log or prompt.
+func invoke(ctx context.Context, executable string, args *common.PluginArgs) (*common.PluginResp, error) {
+	args.ProtocolVersion = common.ProtocolVersion
+	argsBytes, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("encoding kms plugin request: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, executable, common.ProtocolVersion)
+	cmd.Stdin = bytes.NewReader(argsBytes)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	var resp common.PluginResp
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("running kms plugin %s: %w", executable, runErr)
+		}
+		return nil, fmt.Errorf("decoding kms plugin response: %w", err)
+	}
+	if resp.ErrorMessage != "" {
+		return nil, &PluginError{Executable: executable, Message: resp.ErrorMessage}
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("running kms plugin %s: %w", executable, runErr)
+	}
+	if resp.ProtocolVersion != common.ProtocolVersion {
+		return nil, fmt.Errorf("kms plugin %s responded with protocol version %q, want %q", executable, resp.ProtocolVersion, common.ProtocolVersion)
+	}
+	return &resp, nil
+}
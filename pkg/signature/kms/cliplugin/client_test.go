@@ -0,0 +1,238 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cliplugin
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/common"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+const (
+	testPluginName = common.PluginBinaryPrefix + "clienttest"
+	// testModeEnv selects the canned behaviour of the test plugin
+	testModeEnv = "SIGSTORE_KMS_CLIPLUGIN_TEST_MODE"
+)
+
+// TestMain runs the test binary as a plugin when it is invoked through the
+// testPluginName symlink created by installTestPlugin.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == testPluginName {
+		os.Exit(runTestPlugin())
+	}
+	os.Exit(m.Run())
+}
+
+func runTestPlugin() int {
+	var args common.PluginArgs
+	if err := json.NewDecoder(os.Stdin).Decode(&args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	resp := common.PluginResp{ProtocolVersion: common.ProtocolVersion}
+	switch os.Getenv(testModeEnv) {
+	case "error":
+		resp.ErrorMessage = "key is disabled"
+		_ = json.NewEncoder(os.Stdout).Encode(resp)
+		return 1
+	case "version":
+		resp.ProtocolVersion = "v0"
+	case "garbage":
+		fmt.Fprint(os.Stdout, "not json")
+		return 3
+	case "sleep":
+		time.Sleep(time.Minute)
+	case "echo":
+		// report the request back through the signature field
+		argsBytes, _ := json.Marshal(args)
+		version := "7"
+		resp.SignMessage = &common.SignMessageResp{Signature: argsBytes, KeyVersionUsed: &version}
+		resp.DefaultAlgorithm = &common.DefaultAlgorithmResp{DefaultAlgorithm: "echo-" + os.Args[1]}
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// installTestPlugin places the test plugin in an otherwise empty PATH.
+func installTestPlugin(t *testing.T, mode string) {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(self, filepath.Join(dir, testPluginName)); err != nil {
+		t.Skipf("cannot create symlink: %v", err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv(testModeEnv, mode)
+}
+
+func TestExecutableName(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "mykms://key", want: "sigstore-kms-mykms"},
+		{ref: "my-kms.v2://projects/p/keys/k", want: "sigstore-kms-my-kms.v2"},
+		{ref: "mykms:key", wantErr: true},
+		{ref: "../bin/sh://key", wantErr: true},
+		{ref: "MyKMS://key", wantErr: true},
+		{ref: "://key", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ExecutableName(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ExecutableName(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ExecutableName(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSignerVerifierNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	for _, ref := range []string{"clienttest://key", "no-scheme"} {
+		if _, err := LoadSignerVerifier(context.Background(), ref, crypto.SHA256); !errors.Is(err, ErrorPluginNotFound) {
+			t.Fatalf("LoadSignerVerifier(%q) error = %v, want ErrorPluginNotFound", ref, err)
+		}
+	}
+}
+
+func TestRequestEncoding(t *testing.T) {
+	installTestPlugin(t, "echo")
+	sv, err := LoadSignerVerifier(context.Background(), "clienttest://key", crypto.SHA384,
+		options.WithKeyVersion("3"), options.WithRPCAuthOpts(options.RPCAuth{Token: "secret"}))
+	if err != nil {
+		t.Fatalf("unexpected error loading plugin: %v", err)
+	}
+
+	if got := sv.DefaultAlgorithm(); got != "echo-"+common.ProtocolVersion {
+		t.Fatalf("plugin was not passed the protocol version, got %q", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	var keyVersionUsed string
+	digest := bytes.Repeat([]byte{1}, crypto.SHA256.Size())
+	echoed, err := sv.SignMessage(nil, options.WithContext(ctx), options.WithDigest(digest),
+		options.WithCryptoSignerOpts(crypto.SHA256), options.ReturnKeyVersionUsed(&keyVersionUsed))
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if keyVersionUsed != "7" {
+		t.Fatalf("key version used = %q, want 7", keyVersionUsed)
+	}
+
+	var args common.PluginArgs
+	if err := json.Unmarshal(echoed, &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ProtocolVersion != common.ProtocolVersion || args.Method != common.SignMessageMethodName {
+		t.Fatalf("unexpected request header: %+v", args)
+	}
+	initOpts := args.InitOptions
+	if initOpts.KeyResourceID != "clienttest://key" || initOpts.HashFunc != crypto.SHA384 {
+		t.Fatalf("unexpected init options: %+v", initOpts)
+	}
+	if initOpts.RPCOptions.KeyVersion == nil || *initOpts.RPCOptions.KeyVersion != "3" {
+		t.Fatalf("key version was not forwarded: %+v", initOpts.RPCOptions)
+	}
+	if initOpts.RPCOptions.RPCAuth == nil || initOpts.RPCOptions.RPCAuth.Token != "secret" {
+		t.Fatalf("RPC auth was not forwarded: %+v", initOpts.RPCOptions)
+	}
+	signOpts := args.SignMessage.SignOptions
+	if args.SignMessage.Message != nil {
+		t.Fatal("message should not be sent along with a digest")
+	}
+	if !bytes.Equal(signOpts.MessageOptions.Digest, digest) || *signOpts.MessageOptions.HashFunc != crypto.SHA256 {
+		t.Fatalf("unexpected message options: %+v", signOpts.MessageOptions)
+	}
+	if !signOpts.ReturnKeyVersionUsed {
+		t.Fatal("ReturnKeyVersionUsed was not forwarded")
+	}
+	if signOpts.RPCOptions.CtxDeadline == nil {
+		t.Fatal("context deadline was not forwarded")
+	}
+
+	if _, err := sv.SignMessage(nil); err == nil {
+		t.Fatal("expected error signing without message or digest")
+	}
+}
+
+func TestPluginFailures(t *testing.T) {
+	tests := []struct {
+		mode  string
+		check func(error) bool
+	}{
+		{mode: "error", check: func(err error) bool {
+			var pluginErr *PluginError
+			return errors.As(err, &pluginErr) && pluginErr.Message == "key is disabled"
+		}},
+		{mode: "version"},
+		{mode: "garbage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			installTestPlugin(t, tt.mode)
+			sv, err := LoadSignerVerifier(context.Background(), "clienttest://key", crypto.SHA256)
+			if err != nil {
+				t.Fatalf("unexpected error loading plugin: %v", err)
+			}
+			_, err = sv.PublicKey()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.check != nil && !tt.check(err) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := sv.SupportedAlgorithms(); got != nil {
+				t.Fatalf("SupportedAlgorithms() = %v, want nil on failure", got)
+			}
+		})
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	installTestPlugin(t, "sleep")
+	sv, err := LoadSignerVerifier(context.Background(), "clienttest://key", crypto.SHA256)
+	if err != nil {
+		t.Fatalf("unexpected error loading plugin: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := sv.PublicKey(options.WithContext(ctx)); err == nil {
+		t.Fatal("expected error when the context expires")
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Fatalf("plugin was not killed when the context expired, took %v", elapsed)
+	}
+}
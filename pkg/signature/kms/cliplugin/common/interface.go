@@ -0,0 +1,167 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+const (
+	// ProtocolVersion is the version of the plugin protocol spoken by this package. It is
+	// passed to the plugin as its first command-line argument and in every request.
+	ProtocolVersion = "v1"
+	// PluginBinaryPrefix is prepended to the scheme of a key reference to name the plugin
+	// executable, e.g. "sigstore-kms-mykms" serves "mykms://" references.
+	PluginBinaryPrefix = "sigstore-kms-"
+)
+
+// Method names carried in PluginArgs.Method.
+const (
+	DefaultAlgorithmMethodName    = "defaultAlgorithm"
+	SupportedAlgorithmsMethodName = "supportedAlgorithms"
+	CreateKeyMethodName           = "createKey"
+	PublicKeyMethodName           = "publicKey"
+	SignMessageMethodName         = "signMessage"
+	VerifySignatureMethodName     = "verifySignature"
+)
+
+// PluginArgs is the request written as JSON to the plugin's stdin. Exactly one of the
+// method-specific fields is set, matching Method.
+type PluginArgs struct {
+	ProtocolVersion     string                   `json:"protocolVersion"`
+	Method              string                   `json:"method"`
+	InitOptions         *InitOptions             `json:"initOptions"`
+	DefaultAlgorithm    *DefaultAlgorithmArgs    `json:"defaultAlgorithm,omitempty"`
+	SupportedAlgorithms *SupportedAlgorithmsArgs `json:"supportedAlgorithms,omitempty"`
+	CreateKey           *CreateKeyArgs           `json:"createKey,omitempty"`
+	PublicKey           *PublicKeyArgs           `json:"publicKey,omitempty"`
+	SignMessage         *SignMessageArgs         `json:"signMessage,omitempty"`
+	VerifySignature     *VerifySignatureArgs     `json:"verifySignature,omitempty"`
+}
+
+// InitOptions carries the arguments the SignerVerifier was loaded with, so that the
+// plugin can construct its own SignerVerifier for each request.
+type InitOptions struct {
+	KeyResourceID string      `json:"keyResourceID"`
+	HashFunc      crypto.Hash `json:"hashFunc"`
+	RPCOptions    *RPCOptions `json:"rpcOptions"`
+}
+
+// RPCOptions is the serializable form of signature.RPCOption.
+type RPCOptions struct {
+	CtxDeadline        *time.Time       `json:"ctxDeadline,omitempty"`
+	KeyVersion         *string          `json:"keyVersion,omitempty"`
+	RemoteVerification *bool            `json:"remoteVerification,omitempty"`
+	RPCAuth            *options.RPCAuth `json:"rpcAuth,omitempty"`
+}
+
+// MessageOptions is the serializable form of signature.MessageOption.
+type MessageOptions struct {
+	Digest   []byte       `json:"digest,omitempty"`
+	HashFunc *crypto.Hash `json:"hashFunc,omitempty"`
+}
+
+// SignOptions is the serializable form of signature.SignOption.
+type SignOptions struct {
+	RPCOptions     *RPCOptions     `json:"rpcOptions"`
+	MessageOptions *MessageOptions `json:"messageOptions"`
+	// ReturnKeyVersionUsed asks the plugin to report the key version in SignMessageResp.
+	ReturnKeyVersionUsed bool `json:"returnKeyVersionUsed,omitempty"`
+}
+
+// VerifyOptions is the serializable form of signature.VerifyOption.
+type VerifyOptions struct {
+	RPCOptions     *RPCOptions     `json:"rpcOptions"`
+	MessageOptions *MessageOptions `json:"messageOptions"`
+}
+
+// DefaultAlgorithmArgs are the arguments of DefaultAlgorithm.
+type DefaultAlgorithmArgs struct{}
+
+// SupportedAlgorithmsArgs are the arguments of SupportedAlgorithms.
+type SupportedAlgorithmsArgs struct{}
+
+// CreateKeyArgs are the arguments of CreateKey.
+type CreateKeyArgs struct {
+	CtxDeadline *time.Time `json:"ctxDeadline,omitempty"`
+	Algorithm   string     `json:"algorithm"`
+}
+
+// PublicKeyArgs are the arguments of PublicKey.
+type PublicKeyArgs struct {
+	PublicKeyOptions *RPCOptions `json:"publicKeyOptions"`
+}
+
+// SignMessageArgs are the arguments of SignMessage. Message is omitted when
+// SignOptions carries a digest.
+type SignMessageArgs struct {
+	Message     []byte       `json:"message,omitempty"`
+	SignOptions *SignOptions `json:"signOptions"`
+}
+
+// VerifySignatureArgs are the arguments of VerifySignature. Message is omitted when
+// VerifyOptions carries a digest.
+type VerifySignatureArgs struct {
+	Signature     []byte         `json:"signature"`
+	Message       []byte         `json:"message,omitempty"`
+	VerifyOptions *VerifyOptions `json:"verifyOptions"`
+}
+
+// PluginResp is the response the plugin writes as JSON to its stdout. If the request
+// failed, ErrorMessage is set and the plugin should exit with a non-zero status.
+type PluginResp struct {
+	ProtocolVersion     string                   `json:"protocolVersion"`
+	ErrorMessage        string                   `json:"errorMessage,omitempty"`
+	DefaultAlgorithm    *DefaultAlgorithmResp    `json:"defaultAlgorithm,omitempty"`
+	SupportedAlgorithms *SupportedAlgorithmsResp `json:"supportedAlgorithms,omitempty"`
+	CreateKey           *CreateKeyResp           `json:"createKey,omitempty"`
+	PublicKey           *PublicKeyResp           `json:"publicKey,omitempty"`
+	SignMessage         *SignMessageResp         `json:"signMessage,omitempty"`
+	VerifySignature     *VerifySignatureResp     `json:"verifySignature,omitempty"`
+}
+
+// DefaultAlgorithmResp is the response of DefaultAlgorithm.
+type DefaultAlgorithmResp struct {
+	DefaultAlgorithm string `json:"defaultAlgorithm"`
+}
+
+// SupportedAlgorithmsResp is the response of SupportedAlgorithms.
+type SupportedAlgorithmsResp struct {
+	SupportedAlgorithms []string `json:"supportedAlgorithms"`
+}
+
+// CreateKeyResp is the response of CreateKey.
+type CreateKeyResp struct {
+	PublicKeyPEM string `json:"publicKeyPEM"`
+}
+
+// PublicKeyResp is the response of PublicKey.
+type PublicKeyResp struct {
+	PublicKeyPEM string `json:"publicKeyPEM"`
+}
+
+// SignMessageResp is the response of SignMessage.
+type SignMessageResp struct {
+	Signature      []byte  `json:"signature"`
+	KeyVersionUsed *string `json:"keyVersionUsed,omitempty"`
+}
+
+// VerifySignatureResp is the response of VerifySignature. A successful verification
+// has no fields; failures are reported in PluginResp.ErrorMessage.
+type VerifySignatureResp struct{}
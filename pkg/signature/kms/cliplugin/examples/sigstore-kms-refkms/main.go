@@ -0,0 +1,68 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// sigstore-kms-refkms is a reference KMS plugin serving "refkms://" references. It stores keys
+// the same way as the filekms provider, so "refkms:///path/to/keyring/keyname" names the same
+// key as "filekms:///path/to/keyring/keyname". The keyring password is read from the
+// FILEKMS_PASSWORD environment variable, or from the RPCAuth token.
+package main
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/common"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/handler"
+	"github.com/sigstore/sigstore/pkg/signature/kms/file"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// ReferenceScheme is the scheme served by this plugin
+const ReferenceScheme = "refkms://"
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != common.ProtocolVersion {
+		fmt.Fprintf(os.Stderr, "usage: %s %s < request.json\n", os.Args[0], common.ProtocolVersion)
+		os.Exit(2)
+	}
+	if _, err := handler.Dispatch(os.Stdout, os.Stdin, loadSignerVerifier); err != nil {
+		os.Exit(1)
+	}
+}
+
+func loadSignerVerifier(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (kms.SignerVerifier, error) {
+	if !strings.HasPrefix(keyResourceID, ReferenceScheme) {
+		return nil, fmt.Errorf("kms specification should be in the format %s/path/to/keyring/keyname", ReferenceScheme)
+	}
+	ref := file.ReferenceScheme + strings.TrimPrefix(keyResourceID, ReferenceScheme)
+
+	rpcAuth := options.RPCAuth{}
+	for _, opt := range opts {
+		opt.ApplyRPCAuthOpts(&rpcAuth)
+	}
+	// a nil PassFunc makes filekms use the RPCAuth token; otherwise use the environment,
+	// since stdin carries the request and cannot be used to prompt for the password
+	var pf cryptoutils.PassFunc
+	if rpcAuth.Token == "" {
+		pf = cryptoutils.PasswordFromEnv(file.PasswordEnv)
+	}
+	return file.LoadSignerVerifier(ctx, ref, hashFunc, pf, opts...)
+}
@@ -0,0 +1,225 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/common"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// Dispatch reads a single request from stdin, serves it with the SignerVerifier returned by
+// loadSV for the request's key reference, and writes the response to stdout.
+//
+// A plugin's main function typically consists of a call to Dispatch with os.Stdout and os.Stdin,
+// exiting with a non-zero status if it returns an error. The error is also reported to the
+// client in the response, so plugins must not write anything else to stdout.
+func Dispatch(stdout io.Writer, stdin io.Reader, loadSV kms.ProviderInit) (*common.PluginResp, error) {
+	resp, err := dispatch(stdin, loadSV)
+	if resp == nil {
+		resp = &common.PluginResp{}
+	}
+	resp.ProtocolVersion = common.ProtocolVersion
+	if err != nil {
+		resp.ErrorMessage = err.Error()
+	}
+	if encErr := json.NewEncoder(stdout).Encode(resp); encErr != nil {
+		return resp, errors.Join(err, fmt.Errorf("encoding response: %w", encErr))
+	}
+	return resp, err
+}
+
+func dispatch(stdin io.Reader, loadSV kms.ProviderInit) (*common.PluginResp, error) {
+	var args common.PluginArgs
+	if err := json.NewDecoder(stdin).Decode(&args); err != nil {
+		return nil, fmt.Errorf("decoding request: %w", err)
+	}
+	if args.ProtocolVersion != common.ProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %q, want %q", args.ProtocolVersion, common.ProtocolVersion)
+	}
+	if args.InitOptions == nil {
+		return nil, errors.New("request has no initOptions")
+	}
+
+	ctx, cancel := contextWithDeadline(methodDeadline(&args))
+	defer cancel()
+
+	initOpts := toSignOptions(ctx, args.InitOptions.RPCOptions)
+	rpcOpts := make([]signature.RPCOption, 0, len(initOpts))
+	for _, opt := range initOpts {
+		rpcOpts = append(rpcOpts, opt)
+	}
+	sv, err := loadSV(ctx, args.InitOptions.KeyResourceID, args.InitOptions.HashFunc, rpcOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &common.PluginResp{}
+	switch args.Method {
+	case common.DefaultAlgorithmMethodName:
+		resp.DefaultAlgorithm = &common.DefaultAlgorithmResp{
+			DefaultAlgorithm: sv.DefaultAlgorithm(),
+		}
+	case common.SupportedAlgorithmsMethodName:
+		resp.SupportedAlgorithms = &common.SupportedAlgorithmsResp{
+			SupportedAlgorithms: sv.SupportedAlgorithms(),
+		}
+	case common.CreateKeyMethodName:
+		if args.CreateKey == nil {
+			return nil, missingArgs(args.Method)
+		}
+		pub, err := sv.CreateKey(ctx, args.CreateKey.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+		if err != nil {
+			return nil, err
+		}
+		resp.CreateKey = &common.CreateKeyResp{PublicKeyPEM: string(pemBytes)}
+	case common.PublicKeyMethodName:
+		if args.PublicKey == nil {
+			return nil, missingArgs(args.Method)
+		}
+		signOpts := toSignOptions(ctx, args.PublicKey.PublicKeyOptions)
+		opts := make([]signature.PublicKeyOption, 0, len(signOpts))
+		for _, opt := range signOpts {
+			opts = append(opts, opt)
+		}
+		pub, err := sv.PublicKey(opts...)
+		if err != nil {
+			return nil, err
+		}
+		pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+		if err != nil {
+			return nil, err
+		}
+		resp.PublicKey = &common.PublicKeyResp{PublicKeyPEM: string(pemBytes)}
+	case common.SignMessageMethodName:
+		if args.SignMessage == nil || args.SignMessage.SignOptions == nil {
+			return nil, missingArgs(args.Method)
+		}
+		signOpts := args.SignMessage.SignOptions
+		opts := append(toSignOptions(ctx, signOpts.RPCOptions), toMessageOptions(signOpts.MessageOptions)...)
+		var keyVersionUsed string
+		if signOpts.ReturnKeyVersionUsed {
+			opts = append(opts, options.ReturnKeyVersionUsed(&keyVersionUsed))
+		}
+		sig, err := sv.SignMessage(messageReader(args.SignMessage.Message, signOpts.MessageOptions), opts...)
+		if err != nil {
+			return nil, err
+		}
+		resp.SignMessage = &common.SignMessageResp{Signature: sig}
+		if signOpts.ReturnKeyVersionUsed {
+			resp.SignMessage.KeyVersionUsed = &keyVersionUsed
+		}
+	case common.VerifySignatureMethodName:
+		if args.VerifySignature == nil || args.VerifySignature.VerifyOptions == nil {
+			return nil, missingArgs(args.Method)
+		}
+		verifyOpts := args.VerifySignature.VerifyOptions
+		signOpts := append(toSignOptions(ctx, verifyOpts.RPCOptions), toMessageOptions(verifyOpts.MessageOptions)...)
+		opts := make([]signature.VerifyOption, 0, len(signOpts))
+		for _, opt := range signOpts {
+			opts = append(opts, opt)
+		}
+		if err := sv.VerifySignature(bytes.NewReader(args.VerifySignature.Signature), messageReader(args.VerifySignature.Message, verifyOpts.MessageOptions), opts...); err != nil {
+			return nil, err
+		}
+		resp.VerifySignature = &common.VerifySignatureResp{}
+	default:
+		return nil, fmt.Errorf("unsupported method %q", args.Method)
+	}
+	return resp, nil
+}
+
+func missingArgs(method string) error {
+	return fmt.Errorf("request has no arguments for method %q", method)
+}
+
+// methodDeadline returns the context deadline sent with the request's method, if any.
+func methodDeadline(args *common.PluginArgs) *time.Time {
+	switch {
+	case args.CreateKey != nil:
+		return args.CreateKey.CtxDeadline
+	case args.PublicKey != nil && args.PublicKey.PublicKeyOptions != nil:
+		return args.PublicKey.PublicKeyOptions.CtxDeadline
+	case args.SignMessage != nil && args.SignMessage.SignOptions != nil && args.SignMessage.SignOptions.RPCOptions != nil:
+		return args.SignMessage.SignOptions.RPCOptions.CtxDeadline
+	case args.VerifySignature != nil && args.VerifySignature.VerifyOptions != nil && args.VerifySignature.VerifyOptions.RPCOptions != nil:
+		return args.VerifySignature.VerifyOptions.RPCOptions.CtxDeadline
+	}
+	return nil
+}
+
+func contextWithDeadline(deadline *time.Time) (context.Context, context.CancelFunc) {
+	if deadline == nil {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), *deadline)
+}
+
+// messageReader returns nil when the client sent a digest instead of the message,
+// so that providers which distinguish the two see the same inputs as in-process callers.
+func messageReader(message []byte, msgOpts *common.MessageOptions) io.Reader {
+	if message == nil && msgOpts != nil && len(msgOpts.Digest) > 0 {
+		return nil
+	}
+	return bytes.NewReader(message)
+}
+
+// toSignOptions rebuilds the options serialized by the client. SignOption is
+// used as the element type since it is satisfied by every RPC option.
+func toSignOptions(ctx context.Context, rpcOpts *common.RPCOptions) []signature.SignOption {
+	opts := []signature.SignOption{options.WithContext(ctx)}
+	if rpcOpts == nil {
+		return opts
+	}
+	if rpcOpts.KeyVersion != nil {
+		opts = append(opts, options.WithKeyVersion(*rpcOpts.KeyVersion))
+	}
+	if rpcOpts.RemoteVerification != nil {
+		opts = append(opts, options.WithRemoteVerification(*rpcOpts.RemoteVerification))
+	}
+	if rpcOpts.RPCAuth != nil {
+		opts = append(opts, options.WithRPCAuthOpts(*rpcOpts.RPCAuth))
+	}
+	return opts
+}
+
+func toMessageOptions(msgOpts *common.MessageOptions) []signature.SignOption {
+	var opts []signature.SignOption
+	if msgOpts == nil {
+		return opts
+	}
+	if len(msgOpts.Digest) > 0 {
+		opts = append(opts, options.WithDigest(msgOpts.Digest))
+	}
+	if msgOpts.HashFunc != nil {
+		opts = append(opts, options.WithCryptoSignerOpts(*msgOpts.HashFunc))
+	}
+	return opts
+}
@@ -0,0 +1,252 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/common"
+	"github.com/sigstore/sigstore/pkg/signature/kms/file"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+const (
+	testScheme     = "handlertest://"
+	testPluginName = common.PluginBinaryPrefix + "handlertest"
+)
+
+// TestMain runs the test binary as a plugin backed by the filekms provider when it is
+// invoked through the testPluginName symlink created by installTestPlugin.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == testPluginName {
+		if _, err := Dispatch(os.Stdout, os.Stdin, loadFileSignerVerifier); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func loadFileSignerVerifier(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (kms.SignerVerifier, error) {
+	ref := file.ReferenceScheme + strings.TrimPrefix(keyResourceID, testScheme)
+	return file.LoadSignerVerifier(ctx, ref, hashFunc, nil, opts...)
+}
+
+func installTestPlugin(t *testing.T) {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(self, filepath.Join(dir, testPluginName)); err != nil {
+		t.Skipf("cannot create symlink: %v", err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv(file.PasswordEnv, "hunter2")
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+	installTestPlugin(t)
+	keyRing := t.TempDir()
+	msg := []byte("mydata")
+
+	for _, algorithm := range []string{cryptoutils.AlgorithmECDSAP256SHA256, cryptoutils.AlgorithmED25519, cryptoutils.AlgorithmRSAPKCS1v152048SHA256} {
+		t.Run(algorithm, func(t *testing.T) {
+			hashFunc := crypto.SHA256
+			if algorithm == cryptoutils.AlgorithmED25519 {
+				hashFunc = crypto.Hash(0)
+			}
+			sv, err := kms.Get(context.Background(), testScheme+keyRing+"/"+algorithm, hashFunc)
+			if err != nil {
+				t.Fatalf("unexpected error getting signer: %v", err)
+			}
+			if _, ok := sv.(*cliplugin.SignerVerifier); !ok {
+				t.Fatalf("expected plugin SignerVerifier, got %T", sv)
+			}
+			if got := sv.DefaultAlgorithm(); got != cryptoutils.AlgorithmECDSAP256SHA256 {
+				t.Fatalf("DefaultAlgorithm() = %q", got)
+			}
+			if !slices.Contains(sv.SupportedAlgorithms(), algorithm) {
+				t.Fatalf("SupportedAlgorithms() = %v, missing %s", sv.SupportedAlgorithms(), algorithm)
+			}
+
+			pub, err := sv.CreateKey(context.Background(), algorithm)
+			if err != nil {
+				t.Fatalf("unexpected error creating key: %v", err)
+			}
+			got, err := sv.PublicKey()
+			if err != nil {
+				t.Fatalf("unexpected error fetching public key: %v", err)
+			}
+			if err := cryptoutils.EqualKeys(pub, got); err != nil {
+				t.Fatal(err)
+			}
+
+			sig, err := sv.SignMessage(bytes.NewReader(msg))
+			if err != nil {
+				t.Fatalf("unexpected error signing: %v", err)
+			}
+			verifier, err := signature.LoadVerifier(pub, hashFunc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("plugin signature does not verify locally: %v", err)
+			}
+			if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("unexpected error verifying: %v", err)
+			}
+			if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("otherdata"))); err == nil {
+				t.Fatal("expected verification of different message to fail")
+			}
+
+			cs, _, err := sv.CryptoSigner(context.Background(), func(err error) { t.Fatal(err) })
+			if err != nil {
+				t.Fatal(err)
+			}
+			input := msg
+			if hashFunc != crypto.Hash(0) {
+				digest := sha256.Sum256(msg)
+				input = digest[:]
+			}
+			sig, err = cs.Sign(rand.Reader, input, hashFunc)
+			if err != nil {
+				t.Fatalf("unexpected error signing with crypto.Signer: %v", err)
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("crypto.Signer signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestPluginKeyVersions(t *testing.T) {
+	installTestPlugin(t)
+	keyPath := filepath.Join(t.TempDir(), "versioned")
+	msg := []byte("mydata")
+
+	local, err := file.LoadSignerVerifier(context.Background(), file.ReferenceScheme+keyPath, crypto.SHA256, cryptoutils.StaticPasswordFunc([]byte("hunter2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.CreateKey(context.Background(), file.AlgorithmECDSAP256); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := local.RotateKey(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	sv, err := kms.Get(context.Background(), testScheme+keyPath, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("unexpected error getting signer: %v", err)
+	}
+	var versionUsed string
+	sig, err := sv.SignMessage(bytes.NewReader(msg), options.WithKeyVersion("1"), options.ReturnKeyVersionUsed(&versionUsed))
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if versionUsed != "1" {
+		t.Fatalf("key version used = %q, want 1", versionUsed)
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err == nil {
+		t.Fatal("expected verification against latest version to fail")
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg), options.WithKeyVersion("1")); err != nil {
+		t.Fatalf("unexpected error verifying with version 1: %v", err)
+	}
+
+	// options given at load time are forwarded with every request
+	pinned, err := kms.Get(context.Background(), testScheme+keyPath, crypto.SHA256, options.WithKeyVersion("1"))
+	if err != nil {
+		t.Fatalf("unexpected error getting signer: %v", err)
+	}
+	if err := pinned.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Fatalf("unexpected error verifying with pinned version: %v", err)
+	}
+
+	// provider errors are reported through the protocol
+	_, err = sv.SignMessage(bytes.NewReader(msg), options.WithKeyVersion("9"))
+	var pluginErr *cliplugin.PluginError
+	if !errors.As(err, &pluginErr) || !strings.Contains(pluginErr.Message, "does not exist") {
+		t.Fatalf("expected PluginError for missing version, got %v", err)
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request string
+		wantErr string
+	}{
+		{
+			name:    "malformed",
+			request: "{",
+			wantErr: "decoding request",
+		},
+		{
+			name:    "protocol version",
+			request: `{"protocolVersion":"v0","method":"defaultAlgorithm","initOptions":{}}`,
+			wantErr: "unsupported protocol version",
+		},
+		{
+			name:    "no init options",
+			request: `{"protocolVersion":"v1","method":"defaultAlgorithm"}`,
+			wantErr: "no initOptions",
+		},
+		{
+			name:    "unknown method",
+			request: `{"protocolVersion":"v1","method":"deleteKey","initOptions":{"keyResourceID":"handlertest:///tmp/key"}}`,
+			wantErr: "unsupported method",
+		},
+		{
+			name:    "missing arguments",
+			request: `{"protocolVersion":"v1","method":"signMessage","initOptions":{"keyResourceID":"handlertest:///tmp/key"}}`,
+			wantErr: "no arguments",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			_, err := Dispatch(&stdout, strings.NewReader(tt.request), loadFileSignerVerifier)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Dispatch() error = %v, want %q", err, tt.wantErr)
+			}
+			var resp common.PluginResp
+			if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+				t.Fatalf("response is not valid JSON: %v", err)
+			}
+			if resp.ProtocolVersion != common.ProtocolVersion || !strings.Contains(resp.ErrorMessage, tt.wantErr) {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cliplugin
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin/common"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// SignerVerifier creates and verifies digital signatures over a message by invoking a plugin
+// executable for each operation
+type SignerVerifier struct {
+	executable  string
+	hashFunc    crypto.Hash
+	initOptions common.InitOptions
+}
+
+// LoadSignerVerifier returns a SignerVerifier backed by the plugin executable named
+// "sigstore-kms-<scheme>" for the scheme of keyResourceID, which must be found in PATH.
+// It returns an error wrapping ErrorPluginNotFound if there is no such executable.
+//
+// The key version, remote verification and RPC auth options in opts are forwarded to the
+// plugin with every request; the context is not.
+func LoadSignerVerifier(_ context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (*SignerVerifier, error) {
+	executable, err := findExecutable(keyResourceID)
+	if err != nil {
+		return nil, err
+	}
+	_, rpcOpts := packRPCOptions(opts)
+	rpcOpts.CtxDeadline = nil
+
+	return &SignerVerifier{
+		executable: executable,
+		hashFunc:   hashFunc,
+		initOptions: common.InitOptions{
+			KeyResourceID: keyResourceID,
+			HashFunc:      hashFunc,
+			RPCOptions:    rpcOpts,
+		},
+	}, nil
+}
+
+func (p *SignerVerifier) invoke(ctx context.Context, args *common.PluginArgs) (*common.PluginResp, error) {
+	args.InitOptions = &p.initOptions
+	return invoke(ctx, p.executable, args)
+}
+
+// DefaultAlgorithm returns the default algorithm reported by the plugin, or
+// an empty string if the plugin could not be invoked.
+func (p *SignerVerifier) DefaultAlgorithm() string {
+	resp, err := p.invoke(context.Background(), &common.PluginArgs{
+		Method:           common.DefaultAlgorithmMethodName,
+		DefaultAlgorithm: &common.DefaultAlgorithmArgs{},
+	})
+	if err != nil || resp.DefaultAlgorithm == nil {
+		return ""
+	}
+	return resp.DefaultAlgorithm.DefaultAlgorithm
+}
+
+// SupportedAlgorithms returns the list of algorithms reported by the plugin, or
+// nil if the plugin could not be invoked.
+func (p *SignerVerifier) SupportedAlgorithms() []string {
+	resp, err := p.invoke(context.Background(), &common.PluginArgs{
+		Method:              common.SupportedAlgorithmsMethodName,
+		SupportedAlgorithms: &common.SupportedAlgorithmsArgs{},
+	})
+	if err != nil || resp.SupportedAlgorithms == nil {
+		return nil
+	}
+	return resp.SupportedAlgorithms.SupportedAlgorithms
+}
+
+// CreateKey asks the plugin to create a key with the specified algorithm, and returns its public key.
+func (p *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	args := &common.CreateKeyArgs{
+		Algorithm: algorithm,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		args.CtxDeadline = &deadline
+	}
+	resp, err := p.invoke(ctx, &common.PluginArgs{
+		Method:    common.CreateKeyMethodName,
+		CreateKey: args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.CreateKey == nil {
+		return nil, fmt.Errorf("kms plugin %s returned no %s response", p.executable, common.CreateKeyMethodName)
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey([]byte(resp.CreateKey.PublicKeyPEM))
+}
+
+// PublicKey returns the public key reported by the plugin.
+//
+// PublicKey recognizes the following Options:
+//
+// - WithContext()
+//
+// - WithKeyVersion()
+//
+// - WithRPCAuthOpts()
+//
+// All other options are ignored if specified.
+func (p *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	ctx, rpcOpts := packRPCOptions(opts)
+	resp, err := p.invoke(ctx, &common.PluginArgs{
+		Method: common.PublicKeyMethodName,
+		PublicKey: &common.PublicKeyArgs{
+			PublicKeyOptions: rpcOpts,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.PublicKey == nil {
+		return nil, fmt.Errorf("kms plugin %s returned no %s response", p.executable, common.PublicKeyMethodName)
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey([]byte(resp.PublicKey.PublicKeyPEM))
+}
+
+// SignMessage asks the plugin to sign the provided message. If a digest is provided with
+// WithDigest(), the message is not sent to the plugin.
+//
+// SignMessage recognizes the following Options listed in order of preference:
+//
+// - WithContext()
+//
+// - WithDigest()
+//
+// - WithCryptoSignerOpts()
+//
+// - WithKeyVersion()
+//
+// - ReturnKeyVersionUsed()
+//
+// Only the hash function of the crypto.SignerOpts is forwarded to the plugin.
+// All other options are ignored if specified.
+func (p *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	ctx, rpcOpts := packRPCOptions(opts)
+	msgOpts := packMessageOptions(opts)
+	var keyVersionUsedPtr *string
+	for _, opt := range opts {
+		opt.ApplyKeyVersionUsed(&keyVersionUsedPtr)
+	}
+
+	args := &common.SignMessageArgs{
+		SignOptions: &common.SignOptions{
+			RPCOptions:           rpcOpts,
+			MessageOptions:       msgOpts,
+			ReturnKeyVersionUsed: keyVersionUsedPtr != nil,
+		},
+	}
+	if len(msgOpts.Digest) == 0 {
+		if message == nil {
+			return nil, fmt.Errorf("either message or digest must be provided")
+		}
+		var err error
+		if args.Message, err = io.ReadAll(message); err != nil {
+			return nil, fmt.Errorf("reading message: %w", err)
+		}
+	}
+
+	resp, err := p.invoke(ctx, &common.PluginArgs{
+		Method:      common.SignMessageMethodName,
+		SignMessage: args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.SignMessage == nil {
+		return nil, fmt.Errorf("kms plugin %s returned no %s response", p.executable, common.SignMessageMethodName)
+	}
+	if keyVersionUsedPtr != nil && resp.SignMessage.KeyVersionUsed != nil {
+		*keyVersionUsedPtr = *resp.SignMessage.KeyVersionUsed
+	}
+	return resp.SignMessage.Signature, nil
+}
+
+// VerifySignature asks the plugin to verify the signature for the given message. If a
+// digest is provided with WithDigest(), the message is not sent to the plugin.
+//
+// This function returns nil if the verification succeeded, and an error message otherwise.
+//
+// This function recognizes the following Options listed in order of preference:
+//
+// - WithContext()
+//
+// - WithDigest()
+//
+// - WithRemoteVerification()
+//
+// - WithCryptoSignerOpts()
+//
+// - WithKeyVersion()
+//
+// All other options are ignored if specified.
+func (p *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	ctx, rpcOpts := packRPCOptions(opts)
+	msgOpts := packMessageOptions(opts)
+
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+	args := &common.VerifySignatureArgs{
+		Signature: sigBytes,
+		VerifyOptions: &common.VerifyOptions{
+			RPCOptions:     rpcOpts,
+			MessageOptions: msgOpts,
+		},
+	}
+	if len(msgOpts.Digest) == 0 {
+		if message == nil {
+			return fmt.Errorf("either message or digest must be provided")
+		}
+		if args.Message, err = io.ReadAll(message); err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+	}
+
+	_, err = p.invoke(ctx, &common.PluginArgs{
+		Method:          common.VerifySignatureMethodName,
+		VerifySignature: args,
+	})
+	return err
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	pluginOptions := []signature.SignOption{
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	}
+
+	return c.sv.SignMessage(nil, pluginOptions...)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (p *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       p,
+		hashFunc: p.hashFunc,
+		errFunc:  errFunc,
+	}
+
+	return csw, p.hashFunc, nil
+}
+
+// packRPCOptions applies opts and returns the resulting context along with
+// their serializable form.
+func packRPCOptions[T signature.RPCOption](opts []T) (context.Context, *common.RPCOptions) {
+	ctx := context.Background()
+	var keyVersion string
+	var remoteVerification bool
+	rpcAuth := options.RPCAuth{}
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+		opt.ApplyKeyVersion(&keyVersion)
+		opt.ApplyRemoteVerification(&remoteVerification)
+		opt.ApplyRPCAuthOpts(&rpcAuth)
+	}
+
+	rpcOpts := &common.RPCOptions{}
+	if deadline, ok := ctx.Deadline(); ok {
+		rpcOpts.CtxDeadline = &deadline
+	}
+	if keyVersion != "" {
+		rpcOpts.KeyVersion = &keyVersion
+	}
+	if remoteVerification {
+		rpcOpts.RemoteVerification = &remoteVerification
+	}
+	if rpcAuth != (options.RPCAuth{}) {
+		rpcOpts.RPCAuth = &rpcAuth
+	}
+	return ctx, rpcOpts
+}
+
+// packMessageOptions returns the serializable form of the message options in opts.
+func packMessageOptions[T signature.MessageOption](opts []T) *common.MessageOptions {
+	var digest []byte
+	var signerOpts crypto.SignerOpts
+	for _, opt := range opts {
+		opt.ApplyDigest(&digest)
+		opt.ApplyCryptoSignerOpts(&signerOpts)
+	}
+
+	msgOpts := &common.MessageOptions{
+		Digest: digest,
+	}
+	if signerOpts != nil {
+		hashFunc := signerOpts.HashFunc()
+		msgOpts.HashFunc = &hashFunc
+	}
+	return msgOpts
+}
@@ -20,6 +20,7 @@ import (
 	"crypto"
 	"io"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
 	"github.com/sigstore/sigstore/pkg/signature/options"
@@ -37,9 +38,11 @@ type SignerVerifier struct {
 const ReferenceScheme = "fakekms://"
 
 func init() {
-	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, _ string, hf crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+	if err := sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, _ string, hf crypto.Hash, _ ...signature.RPCOption) (sigkms.SignerVerifier, error) {
 		return LoadSignerVerifier(ctx, hf)
-	})
+	}); err != nil {
+		panic(err)
+	}
 }
 
 // LoadSignerVerifier generates a signer/verifier using the default ECDSA signer or loads
@@ -144,12 +147,12 @@ func (g *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error))
 	return csw, crypto.SHA256, nil
 }
 
-// SupportedAlgorithms returns a list with the default algorithm
+// SupportedAlgorithms returns a list with the canonical ID of the default algorithm
 func (g *SignerVerifier) SupportedAlgorithms() (result []string) {
-	return []string{"ecdsa-p256-sha256"}
+	return []string{cryptoutils.AlgorithmECDSAP256SHA256}
 }
 
-// DefaultAlgorithm returns the default algorithm for the signer
+// DefaultAlgorithm returns the canonical ID of the default algorithm for the signer
 func (g *SignerVerifier) DefaultAlgorithm() string {
-	return "ecdsa-p256-sha256"
+	return cryptoutils.AlgorithmECDSAP256SHA256
 }
@@ -0,0 +1,370 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package file implement the interface with keys stored encrypted on the local filesystem
+package file
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+)
+
+const (
+	// ReferenceScheme is the scheme for keys stored on the local filesystem
+	ReferenceScheme = "filekms://"
+
+	privateKeySuffix = ".key"
+	publicKeySuffix  = ".pub"
+)
+
+var (
+	errReference = errors.New("kms specification should be in the format filekms:///path/to/keyring/keyname")
+	// errKeyNotFound is returned when the key has no versions on disk
+	errKeyNotFound = errors.New("key does not exist")
+
+	referenceRegex = regexp.MustCompile(`^filekms://(?P<keyring>/(?:.*/)?)(?P<keyname>\w(?:[\w.-]*\w)?)$`)
+	versionRegex   = regexp.MustCompile(`^v([1-9][0-9]*)` + regexp.QuoteMeta(privateKeySuffix) + `$`)
+)
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	if !referenceRegex.MatchString(ref) {
+		return errReference
+	}
+	return nil
+}
+
+// ParseReference parses a filekms-scheme URI into the keyring directory and key name.
+func ParseReference(resourceID string) (keyRing, keyName string, err error) {
+	v := referenceRegex.FindStringSubmatch(resourceID)
+	if v == nil {
+		err = fmt.Errorf("invalid filekms format %q", resourceID)
+		return
+	}
+	keyRing = filepath.Clean(v[referenceRegex.SubexpIndex("keyring")])
+	keyName = v[referenceRegex.SubexpIndex("keyname")]
+	return
+}
+
+// fileClient stores each version of a key as a pair of files in
+// <keyring>/<keyname>/: v<N>.key holds the private key encrypted with
+// cryptoutils.MarshalPrivateKeyToEncryptedDER, and v<N>.pub holds the
+// PEM-encoded public key so that verification does not need the password.
+type fileClient struct {
+	keyDir     string
+	passFunc   cryptoutils.PassFunc
+	keyVersion uint64
+
+	mu   sync.Mutex
+	keys map[uint64]crypto.PrivateKey
+}
+
+func newFileClient(keyResourceID string, pf cryptoutils.PassFunc, keyVersion uint64) (*fileClient, error) {
+	if err := ValidReference(keyResourceID); err != nil {
+		return nil, err
+	}
+	keyRing, keyName, err := ParseReference(keyResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileClient{
+		keyDir:     filepath.Join(keyRing, keyName),
+		passFunc:   pf,
+		keyVersion: keyVersion,
+		keys:       map[uint64]crypto.PrivateKey{},
+	}, nil
+}
+
+func (f *fileClient) versionPath(version uint64, suffix string) string {
+	return filepath.Join(f.keyDir, fmt.Sprintf("v%d%s", version, suffix))
+}
+
+// versions returns the key versions present on disk in ascending order.
+func (f *fileClient) versions() ([]uint64, error) {
+	entries, err := os.ReadDir(f.keyDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading key directory: %w", err)
+	}
+	var versions []uint64
+	for _, e := range entries {
+		m := versionRegex.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// resolveVersion returns the key version to use for an operation. An empty
+// request selects the version given when the client was created, or the
+// latest version if none was given.
+func (f *fileClient) resolveVersion(requested string) (uint64, error) {
+	if requested != "" {
+		v, err := strconv.ParseUint(requested, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing requested key version: %w", err)
+		}
+		if v == 0 {
+			return 0, errors.New("key version must be >= 1")
+		}
+		return v, nil
+	}
+	if f.keyVersion != 0 {
+		return f.keyVersion, nil
+	}
+	versions, err := f.versions()
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, errKeyNotFound
+	}
+	return versions[len(versions)-1], nil
+}
+
+func (f *fileClient) public(version uint64) (crypto.PublicKey, error) {
+	pemBytes, err := os.ReadFile(f.versionPath(version, publicKeySuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("key version %d: %w", version, errKeyNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading public key: %w", err)
+	}
+	return cryptoutils.UnmarshalPEMToPublicKey(pemBytes)
+}
+
+func (f *fileClient) privateKey(version uint64) (crypto.PrivateKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if priv, ok := f.keys[version]; ok {
+		return priv, nil
+	}
+	pemBytes, err := os.ReadFile(f.versionPath(version, privateKeySuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("key version %d: %w", version, errKeyNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	priv, err := cryptoutils.UnmarshalPEMToPrivateKey(pemBytes, f.passFunc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting private key: %w", err)
+	}
+	f.keys[version] = priv
+	return priv, nil
+}
+
+// createKey creates the first version of the key, or returns the public key
+// of the latest version if the key already exists with the same algorithm.
+func (f *fileClient) createKey(algorithm string) (crypto.PublicKey, error) {
+	alg, err := supportedAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := f.versions()
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) > 0 {
+		pub, err := f.public(versions[len(versions)-1])
+		if err != nil {
+			return nil, err
+		}
+		if !alg.MatchesPublicKey(pub) {
+			return nil, fmt.Errorf("key already exists with algorithm %s", algorithmForPublicKey(pub))
+		}
+		return pub, nil
+	}
+	return f.writeVersion(1, alg)
+}
+
+// rotateKey adds a new version of the key using the algorithm of the latest version.
+func (f *fileClient) rotateKey() (crypto.PublicKey, string, error) {
+	versions, err := f.versions()
+	if err != nil {
+		return nil, "", err
+	}
+	if len(versions) == 0 {
+		return nil, "", errKeyNotFound
+	}
+	latest := versions[len(versions)-1]
+	pub, err := f.public(latest)
+	if err != nil {
+		return nil, "", err
+	}
+	alg, err := cryptoutils.SigningAlgorithmForPublicKey(pub)
+	if err != nil {
+		return nil, "", err
+	}
+	pub, err = f.writeVersion(latest+1, alg)
+	if err != nil {
+		return nil, "", err
+	}
+	return pub, strconv.FormatUint(latest+1, 10), nil
+}
+
+func (f *fileClient) writeVersion(version uint64, alg cryptoutils.SigningAlgorithm) (crypto.PublicKey, error) {
+	priv, err := alg.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	pub := priv.Public()
+
+	encrypted, err := cryptoutils.MarshalPrivateKeyToEncryptedDER(priv, f.passFunc)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting private key: %w", err)
+	}
+	pubPEM, err := cryptoutils.MarshalPublicKeyToPEM(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(f.keyDir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating key directory: %w", err)
+	}
+	privPEM := cryptoutils.PEMEncode(cryptoutils.EncryptedSigstorePrivateKeyPEMType, encrypted)
+	if err := f.publishVersion(version, privPEM, pubPEM); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.keys[version] = priv
+	f.mu.Unlock()
+	return pub, nil
+}
+
+// linkFile is os.Link, replaced in tests
+var linkFile = os.Link
+
+// publishVersion writes the key files of a version so that a version is never seen without both of them.
+// The files are written to temporary names and then linked into place, public key first. Linking the private
+// key file is what makes the version visible, and fails if the file exists, so a concurrent writer can never
+// replace an existing version. A public key file without a private key file is left behind by an interrupted
+// write and is replaced.
+func (f *fileClient) publishVersion(version uint64, privPEM, pubPEM []byte) error {
+	pubTemp, err := writeTempFile(f.keyDir, pubPEM)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pubTemp)
+	privTemp, err := writeTempFile(f.keyDir, privPEM)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(privTemp)
+
+	pubPath := f.versionPath(version, publicKeySuffix)
+	privPath := f.versionPath(version, privateKeySuffix)
+	err = linkFile(pubTemp, pubPath)
+	if errors.Is(err, fs.ErrExist) {
+		if _, statErr := os.Lstat(privPath); statErr == nil {
+			return fmt.Errorf("creating key file: key version %d already exists", version)
+		}
+		if err = os.Remove(pubPath); err == nil || errors.Is(err, fs.ErrNotExist) {
+			err = linkFile(pubTemp, pubPath)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("creating key file: %w", err)
+	}
+	if err := linkFile(privTemp, privPath); err != nil {
+		// a concurrent writer which published the version may have replaced the public key file
+		if sameFile(pubTemp, pubPath) {
+			_ = os.Remove(pubPath)
+		}
+		return fmt.Errorf("creating key file: %w", err)
+	}
+	// the version is ours, so restore its public key file if a concurrent writer replaced it
+	if !sameFile(pubTemp, pubPath) {
+		if err := os.Rename(pubTemp, pubPath); err != nil {
+			return fmt.Errorf("creating key file: %w", err)
+		}
+	}
+	return nil
+}
+
+// sameFile reports whether both paths name the same existing file
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ai, bi)
+}
+
+// writeTempFile writes contents to a new file in dir, readable only by the owner, and returns its path
+func writeTempFile(dir string, contents []byte) (string, error) {
+	file, err := os.CreateTemp(dir, ".tmp-")
+	if err != nil {
+		return "", fmt.Errorf("creating key file: %w", err)
+	}
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("writing key file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("writing key file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// supportedAlgorithm returns the registered algorithm for a canonical ID in fileSupportedAlgorithms
+// or one of the legacy algorithm names
+func supportedAlgorithm(algorithm string) (cryptoutils.SigningAlgorithm, error) {
+	if id, ok := legacyAlgorithms[algorithm]; ok {
+		algorithm = id
+	}
+	if !slices.Contains(fileSupportedAlgorithms, algorithm) {
+		return cryptoutils.SigningAlgorithm{}, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	return cryptoutils.GetSigningAlgorithm(algorithm)
+}
+
+// algorithmForPublicKey returns the canonical ID of the algorithm of pub
+func algorithmForPublicKey(pub crypto.PublicKey) string {
+	alg, err := cryptoutils.SigningAlgorithmForPublicKey(pub)
+	if err != nil {
+		return fmt.Sprintf("%T", pub)
+	}
+	return alg.ID
+}
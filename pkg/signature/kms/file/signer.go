@@ -0,0 +1,303 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// PasswordEnv names the environment variable holding the keyring password when
+// neither a PassFunc nor an RPCAuth token is provided.
+const PasswordEnv = "FILEKMS_PASSWORD"
+
+func init() {
+	if err := sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+		return LoadSignerVerifier(ctx, keyResourceID, hashFunc, nil, opts...)
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// Algorithm names accepted by CreateKey in addition to the canonical IDs in cryptoutils
+//
+// nolint:revive
+const (
+	AlgorithmECDSAP256 = "ecdsa-p256"
+	AlgorithmECDSAP384 = "ecdsa-p384"
+	AlgorithmECDSAP521 = "ecdsa-p521"
+	AlgorithmED25519   = "ed25519"
+	AlgorithmRSA2048   = "rsa-2048"
+	AlgorithmRSA3072   = "rsa-3072"
+	AlgorithmRSA4096   = "rsa-4096"
+)
+
+// fileSupportedAlgorithms are the canonical IDs of the algorithms of keys stored on disk. RSA keys
+// are used with PKCS#1 v1.5 padding.
+var fileSupportedAlgorithms = []string{
+	cryptoutils.AlgorithmECDSAP256SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384,
+	cryptoutils.AlgorithmECDSAP521SHA512,
+	cryptoutils.AlgorithmED25519,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256,
+}
+
+// legacyAlgorithms maps the names accepted before the canonical IDs to their canonical IDs
+var legacyAlgorithms = map[string]string{
+	AlgorithmECDSAP256: cryptoutils.AlgorithmECDSAP256SHA256,
+	AlgorithmECDSAP384: cryptoutils.AlgorithmECDSAP384SHA384,
+	AlgorithmECDSAP521: cryptoutils.AlgorithmECDSAP521SHA512,
+	AlgorithmRSA2048:   cryptoutils.AlgorithmRSAPKCS1v152048SHA256,
+	AlgorithmRSA3072:   cryptoutils.AlgorithmRSAPKCS1v153072SHA256,
+	AlgorithmRSA4096:   cryptoutils.AlgorithmRSAPKCS1v154096SHA256,
+}
+
+// SignerVerifier creates and verifies digital signatures over a message using keys stored encrypted on disk
+type SignerVerifier struct {
+	hashFunc crypto.Hash
+	client   *fileClient
+}
+
+// LoadSignerVerifier generates signatures using the key stored at the filekms reference and hash algorithm.
+//
+// The private key is decrypted with the password returned by pf. If pf is nil, the password is taken
+// from the RPCAuth token in opts, then from the FILEKMS_PASSWORD environment variable, and finally read
+// interactively. If a key version is given with options.WithKeyVersion, it is used as the default
+// version for all operations; otherwise the latest version on disk is used.
+func LoadSignerVerifier(_ context.Context, referenceStr string, hashFunc crypto.Hash, pf cryptoutils.PassFunc, opts ...signature.RPCOption) (*SignerVerifier, error) {
+	rpcAuth := options.RPCAuth{}
+	var keyVersion string
+	for _, opt := range opts {
+		opt.ApplyRPCAuthOpts(&rpcAuth)
+		opt.ApplyKeyVersion(&keyVersion)
+	}
+
+	var keyVersionUint uint64
+	var err error
+	if keyVersion != "" {
+		keyVersionUint, err = strconv.ParseUint(keyVersion, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key version: %w", err)
+		}
+	}
+
+	if pf == nil {
+		switch pw, ok := os.LookupEnv(PasswordEnv); {
+		case rpcAuth.Token != "":
+			pf = cryptoutils.StaticPasswordFunc([]byte(rpcAuth.Token))
+		case ok:
+			pf = cryptoutils.StaticPasswordFunc([]byte(pw))
+		default:
+			pf = cryptoutils.GetPasswordFromStdIn
+		}
+	}
+
+	f := &SignerVerifier{
+		hashFunc: hashFunc,
+	}
+	f.client, err = newFileClient(referenceStr, pf, keyVersionUint)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SignMessage signs the provided message using the key stored on disk. If the message is provided,
+// this method will compute the digest according to the hash function specified
+// when the SignerVerifier was created.
+//
+// SignMessage recognizes the following Options listed in order of preference:
+//
+// - WithDigest()
+//
+// - WithCryptoSignerOpts()
+//
+// - WithKeyVersion()
+//
+// - ReturnKeyVersionUsed()
+//
+// All other options are ignored if specified.
+func (f *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	var digest []byte
+	var keyVersion string
+	var keyVersionUsedPtr *string
+	for _, opt := range opts {
+		opt.ApplyDigest(&digest)
+		opt.ApplyKeyVersion(&keyVersion)
+		opt.ApplyKeyVersionUsed(&keyVersionUsedPtr)
+	}
+
+	version, err := f.client.resolveVersion(keyVersion)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := f.client.privateKey(version)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := signature.LoadSigner(priv, f.hashFunc)
+	if err != nil {
+		return nil, err
+	}
+	// ed25519 signs the message itself, which crypto.Signer callers pass as the digest
+	if _, ok := priv.(ed25519.PrivateKey); ok && message == nil && len(digest) > 0 {
+		message = bytes.NewReader(digest)
+	}
+
+	sig, err := signer.SignMessage(message, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if keyVersionUsedPtr != nil {
+		*keyVersionUsedPtr = strconv.FormatUint(version, 10)
+	}
+	return sig, nil
+}
+
+// PublicKey returns the public key that can be used to verify signatures created by
+// this signer. If a key version is given with options.WithKeyVersion, the public
+// key of that version is returned.
+//
+// All other options are ignored if specified.
+func (f *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	var keyVersion string
+	for _, opt := range opts {
+		opt.ApplyKeyVersion(&keyVersion)
+	}
+
+	version, err := f.client.resolveVersion(keyVersion)
+	if err != nil {
+		return nil, err
+	}
+	return f.client.public(version)
+}
+
+// VerifySignature verifies the signature for the given message. Unless provided
+// in an option, the digest of the message will be computed using the hash function specified
+// when the SignerVerifier was created.
+//
+// This function returns nil if the verification succeeded, and an error message otherwise.
+//
+// This function recognizes the following Options listed in order of preference:
+//
+// - WithDigest()
+//
+// - WithCryptoSignerOpts()
+//
+// - WithKeyVersion()
+//
+// All other options are ignored if specified.
+func (f *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	var keyVersion string
+	for _, opt := range opts {
+		opt.ApplyKeyVersion(&keyVersion)
+	}
+
+	version, err := f.client.resolveVersion(keyVersion)
+	if err != nil {
+		return err
+	}
+	pub, err := f.client.public(version)
+	if err != nil {
+		return err
+	}
+	verifier, err := signature.LoadVerifier(pub, f.hashFunc)
+	if err != nil {
+		return err
+	}
+	return verifier.VerifySignature(sig, message, opts...)
+}
+
+// CreateKey creates the first version of the key with the specified algorithm, which is one of
+// SupportedAlgorithms or one of the Algorithm constants of this package. If the key already
+// exists with the same algorithm, the public key of its latest version is returned.
+func (f *SignerVerifier) CreateKey(_ context.Context, algorithm string) (crypto.PublicKey, error) {
+	return f.client.createKey(algorithm)
+}
+
+// RotateKey adds a new version of the key using the algorithm of the latest version, and
+// returns its public key and version. Signing operations that do not request a specific
+// key version will use the new version, unless the SignerVerifier was loaded with
+// options.WithKeyVersion.
+func (f *SignerVerifier) RotateKey(_ context.Context) (crypto.PublicKey, string, error) {
+	return f.client.rotateKey()
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	fileOptions := []signature.SignOption{
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	}
+
+	return c.sv.SignMessage(nil, fileOptions...)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (f *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       f,
+		hashFunc: f.hashFunc,
+		errFunc:  errFunc,
+	}
+
+	return csw, f.hashFunc, nil
+}
+
+// SupportedAlgorithms returns the canonical IDs of the algorithms supported for keys stored on disk
+func (*SignerVerifier) SupportedAlgorithms() []string {
+	return slices.Clone(fileSupportedAlgorithms)
+}
+
+// DefaultAlgorithm returns the canonical ID of the default algorithm for keys stored on disk
+func (*SignerVerifier) DefaultAlgorithm() string {
+	return cryptoutils.AlgorithmECDSAP256SHA256
+}
@@ -0,0 +1,415 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+var testPassFunc = cryptoutils.StaticPasswordFunc([]byte("hunter2"))
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantKeyRing string
+		wantKeyName string
+		wantErr     bool
+	}{
+		{
+			in:          "filekms:///path/to/keyring/keyname",
+			wantKeyRing: "/path/to/keyring",
+			wantKeyName: "keyname",
+		},
+		{
+			in:          "filekms:///release.key-1",
+			wantKeyRing: "/",
+			wantKeyName: "release.key-1",
+		},
+		{
+			in:      "filekms://relative/keyname",
+			wantErr: true,
+		},
+		{
+			in:      "filekms:///path/to/keyring/",
+			wantErr: true,
+		},
+		{
+			in:      "hashivault://keyname",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if err := ValidReference(tt.in); (err != nil) != tt.wantErr {
+				t.Fatalf("ValidReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			keyRing, keyName, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if keyRing != tt.wantKeyRing || keyName != tt.wantKeyName {
+				t.Errorf("ParseReference() = %q, %q, want %q, %q", keyRing, keyName, tt.wantKeyRing, tt.wantKeyName)
+			}
+		})
+	}
+}
+
+func TestCreateKeySignVerify(t *testing.T) {
+	keyRing := t.TempDir()
+	msg := []byte("mydata")
+
+	for _, algorithm := range (&SignerVerifier{}).SupportedAlgorithms() {
+		t.Run(algorithm, func(t *testing.T) {
+			sv, err := LoadSignerVerifier(context.Background(), "filekms://"+keyRing+"/"+algorithm, crypto.SHA256, testPassFunc)
+			if err != nil {
+				t.Fatalf("unexpected error loading signer: %v", err)
+			}
+			if _, err := sv.PublicKey(); !errors.Is(err, errKeyNotFound) {
+				t.Fatalf("expected errKeyNotFound before key creation, got %v", err)
+			}
+
+			pub, err := sv.CreateKey(context.Background(), algorithm)
+			if err != nil {
+				t.Fatalf("unexpected error creating key: %v", err)
+			}
+			if got := algorithmForPublicKey(pub); got != algorithm {
+				t.Fatalf("created key has algorithm %s, want %s", got, algorithm)
+			}
+			again, err := sv.CreateKey(context.Background(), algorithm)
+			if err != nil {
+				t.Fatalf("unexpected error creating existing key: %v", err)
+			}
+			if err := cryptoutils.EqualKeys(pub, again); err != nil {
+				t.Fatalf("expected existing key to be returned: %v", err)
+			}
+
+			sig, err := sv.SignMessage(bytes.NewReader(msg))
+			if err != nil {
+				t.Fatalf("unexpected error signing: %v", err)
+			}
+			if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("unexpected error verifying: %v", err)
+			}
+			if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("otherdata"))); err == nil {
+				t.Fatal("expected verification of different message to fail")
+			}
+
+			cs, _, err := sv.CryptoSigner(context.Background(), func(err error) { t.Fatal(err) })
+			if err != nil {
+				t.Fatalf("unexpected error fetching crypto.Signer: %v", err)
+			}
+			if err := cryptoutils.EqualKeys(cs.Public(), pub); err != nil {
+				t.Fatalf("expected public keys to be equal: %v", err)
+			}
+			input := msg
+			if algorithm != cryptoutils.AlgorithmED25519 {
+				digest := sha256.Sum256(msg)
+				input = digest[:]
+			}
+			sig, err = cs.Sign(rand.Reader, input, nil)
+			if err != nil {
+				t.Fatalf("unexpected error signing with crypto.Signer: %v", err)
+			}
+			if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("unexpected error verifying crypto.Signer signature: %v", err)
+			}
+		})
+	}
+
+	sv, err := LoadSignerVerifier(context.Background(), "filekms://"+keyRing+"/"+cryptoutils.AlgorithmECDSAP256SHA256, crypto.SHA256, testPassFunc)
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	if _, err := sv.CreateKey(context.Background(), cryptoutils.AlgorithmRSAPKCS1v152048SHA256); err == nil {
+		t.Fatal("expected error creating existing key with a different algorithm")
+	}
+	// the legacy name of the existing key's algorithm returns the existing key
+	if _, err := sv.CreateKey(context.Background(), AlgorithmECDSAP256); err != nil {
+		t.Fatalf("unexpected error creating existing key with legacy algorithm name: %v", err)
+	}
+	for _, algorithm := range []string{"dsa-1024", cryptoutils.AlgorithmRSAPSS2048SHA256, cryptoutils.AlgorithmED25519ph} {
+		if _, err := sv.CreateKey(context.Background(), algorithm); err == nil {
+			t.Fatalf("expected error for unsupported algorithm %s", algorithm)
+		}
+	}
+}
+
+func TestCreateKeyLegacyAlgorithms(t *testing.T) {
+	keyRing := t.TempDir()
+	for legacy, canonical := range legacyAlgorithms {
+		sv, err := LoadSignerVerifier(context.Background(), "filekms://"+keyRing+"/"+legacy, crypto.SHA256, testPassFunc)
+		if err != nil {
+			t.Fatalf("unexpected error loading signer: %v", err)
+		}
+		pub, err := sv.CreateKey(context.Background(), legacy)
+		if err != nil {
+			t.Fatalf("unexpected error creating key with %s: %v", legacy, err)
+		}
+		if got := algorithmForPublicKey(pub); got != canonical {
+			t.Fatalf("key created with %s has algorithm %s, want %s", legacy, got, canonical)
+		}
+	}
+}
+
+func TestKeyIsEncryptedOnDisk(t *testing.T) {
+	keyRing := t.TempDir()
+	sv, err := LoadSignerVerifier(context.Background(), "filekms://"+keyRing+"/key", crypto.SHA256, testPassFunc)
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	if _, err := sv.CreateKey(context.Background(), AlgorithmECDSAP256); err != nil {
+		t.Fatalf("unexpected error creating key: %v", err)
+	}
+
+	path := filepath.Join(keyRing, "key", "v1"+privateKeySuffix)
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading key file: %v", err)
+	}
+	block, _ := pem.Decode(contents)
+	if block == nil || block.Type != string(cryptoutils.EncryptedSigstorePrivateKeyPEMType) {
+		t.Fatalf("expected an encrypted sigstore private key on disk")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("key file has permissions %v, want 0600", perm)
+	}
+
+	wrongPassword, err := LoadSignerVerifier(context.Background(), "filekms://"+keyRing+"/key", crypto.SHA256, cryptoutils.StaticPasswordFunc([]byte("wrong")))
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	if _, err := wrongPassword.SignMessage(bytes.NewReader([]byte("mydata"))); err == nil {
+		t.Fatal("expected signing with the wrong password to fail")
+	}
+	// verification only needs the public key
+	if _, err := wrongPassword.PublicKey(); err != nil {
+		t.Fatalf("unexpected error reading public key: %v", err)
+	}
+}
+
+func TestKeyVersions(t *testing.T) {
+	ref := "filekms://" + t.TempDir() + "/versioned"
+	msg := []byte("mydata")
+
+	sv, err := LoadSignerVerifier(context.Background(), ref, crypto.SHA256, testPassFunc)
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	pub1, err := sv.CreateKey(context.Background(), AlgorithmECDSAP256)
+	if err != nil {
+		t.Fatalf("unexpected error creating key: %v", err)
+	}
+	pub2, version, err := sv.RotateKey(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error rotating key: %v", err)
+	}
+	if version != "2" {
+		t.Fatalf("RotateKey() version = %s, want 2", version)
+	}
+	if err := cryptoutils.EqualKeys(pub1, pub2); err == nil {
+		t.Fatal("expected rotated key to differ")
+	}
+
+	var versionUsed string
+	sig, err := sv.SignMessage(bytes.NewReader(msg), options.ReturnKeyVersionUsed(&versionUsed))
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if versionUsed != "2" {
+		t.Fatalf("expected latest version to be used, got %q", versionUsed)
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+		t.Fatalf("unexpected error verifying: %v", err)
+	}
+
+	sig, err = sv.SignMessage(bytes.NewReader(msg), options.WithKeyVersion("1"), options.ReturnKeyVersionUsed(&versionUsed))
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if versionUsed != "1" {
+		t.Fatalf("expected version 1 to be used, got %q", versionUsed)
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err == nil {
+		t.Fatal("expected verification against latest version to fail")
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg), options.WithKeyVersion("1")); err != nil {
+		t.Fatalf("unexpected error verifying with version 1: %v", err)
+	}
+
+	if _, err := sv.SignMessage(bytes.NewReader(msg), options.WithKeyVersion("3")); !errors.Is(err, errKeyNotFound) {
+		t.Fatalf("expected errKeyNotFound for missing version, got %v", err)
+	}
+	if _, err := sv.SignMessage(bytes.NewReader(msg), options.WithKeyVersion("0")); err == nil {
+		t.Fatal("expected error for version 0")
+	}
+
+	pinned, err := LoadSignerVerifier(context.Background(), ref, crypto.SHA256, testPassFunc, options.WithKeyVersion("1"))
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	pub, err := pinned.PublicKey()
+	if err != nil {
+		t.Fatalf("unexpected error reading public key: %v", err)
+	}
+	if err := cryptoutils.EqualKeys(pub, pub1); err != nil {
+		t.Fatalf("expected pinned version to be used: %v", err)
+	}
+}
+
+func TestCreateKeyFailedWrite(t *testing.T) {
+	keyRing := t.TempDir()
+	ref := "filekms://" + keyRing + "/key"
+	sv, err := LoadSignerVerifier(context.Background(), ref, crypto.SHA256, testPassFunc)
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+
+	// the private key file fails to be linked into place after the public key file
+	defer func() { linkFile = os.Link }()
+	links := 0
+	linkFile = func(oldname, newname string) error {
+		if links++; links == 2 {
+			return errors.New("disk full")
+		}
+		return os.Link(oldname, newname)
+	}
+	if _, err := sv.CreateKey(context.Background(), AlgorithmECDSAP256); err == nil {
+		t.Fatal("expected error creating key")
+	}
+	entries, err := os.ReadDir(filepath.Join(keyRing, "key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files to be left behind, got %v", entries)
+	}
+
+	// the key can be created once writing succeeds again
+	linkFile = os.Link
+	if _, err := sv.CreateKey(context.Background(), AlgorithmECDSAP256); err != nil {
+		t.Fatalf("unexpected error creating key: %v", err)
+	}
+	if _, err := sv.SignMessage(bytes.NewReader([]byte("mydata"))); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+}
+
+func TestRotateKeyAfterInterruptedWrite(t *testing.T) {
+	keyRing := t.TempDir()
+	sv, err := LoadSignerVerifier(context.Background(), "filekms://"+keyRing+"/key", crypto.SHA256, testPassFunc)
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	if _, err := sv.CreateKey(context.Background(), AlgorithmECDSAP256); err != nil {
+		t.Fatalf("unexpected error creating key: %v", err)
+	}
+
+	// the process stops after the public key file of version 2 is linked into place
+	defer func() { linkFile = os.Link }()
+	linkFile = func(oldname, newname string) error {
+		if strings.HasSuffix(newname, privateKeySuffix) {
+			panic("crash")
+		}
+		return os.Link(oldname, newname)
+	}
+	func() {
+		defer func() { _ = recover() }()
+		_, _, _ = sv.RotateKey(context.Background())
+	}()
+	if _, err := os.Stat(filepath.Join(keyRing, "key", "v2.pub")); err != nil {
+		t.Fatalf("expected the public key file to be left behind: %v", err)
+	}
+
+	linkFile = os.Link
+	pub, version, err := sv.RotateKey(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error rotating key: %v", err)
+	}
+	if version != "2" {
+		t.Fatalf("RotateKey() version = %s, want 2", version)
+	}
+	stored, err := sv.PublicKey(options.WithKeyVersion("2"))
+	if err != nil {
+		t.Fatalf("unexpected error reading public key: %v", err)
+	}
+	if err := cryptoutils.EqualKeys(pub, stored); err != nil {
+		t.Fatalf("public key file of version 2 does not match the rotated key: %v", err)
+	}
+	sig, err := sv.SignMessage(bytes.NewReader([]byte("mydata")))
+	if err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+	if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("mydata")), options.WithKeyVersion("2")); err != nil {
+		t.Fatalf("unexpected error verifying: %v", err)
+	}
+}
+
+func TestSupportedAlgorithmsCopy(t *testing.T) {
+	sv, err := LoadSignerVerifier(context.Background(), "filekms://"+t.TempDir()+"/key", crypto.SHA256, testPassFunc)
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	algs := sv.SupportedAlgorithms()
+	for i := range algs {
+		algs[i] = "modified"
+	}
+	if _, err := sv.CreateKey(context.Background(), cryptoutils.AlgorithmECDSAP256SHA256); err != nil {
+		t.Fatalf("modifying the returned algorithms affected CreateKey: %v", err)
+	}
+}
+
+func TestKMSGet(t *testing.T) {
+	ref := "filekms://" + t.TempDir() + "/viaget"
+	t.Setenv(PasswordEnv, "hunter2")
+
+	sv, err := kms.Get(context.Background(), ref, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("unexpected error getting signer: %v", err)
+	}
+	if _, err := sv.CreateKey(context.Background(), sv.DefaultAlgorithm()); err != nil {
+		t.Fatalf("unexpected error creating key: %v", err)
+	}
+
+	// the RPCAuth token takes precedence over the environment
+	byToken, err := kms.Get(context.Background(), ref, crypto.SHA256, options.WithRPCAuthOpts(options.RPCAuth{Token: "wrong"}))
+	if err != nil {
+		t.Fatalf("unexpected error getting signer: %v", err)
+	}
+	if _, err := byToken.SignMessage(bytes.NewReader([]byte("mydata"))); err == nil {
+		t.Fatal("expected signing with the RPCAuth token as password to fail")
+	}
+	if _, err := sv.SignMessage(bytes.NewReader([]byte("mydata"))); err != nil {
+		t.Fatalf("unexpected error signing: %v", err)
+	}
+}
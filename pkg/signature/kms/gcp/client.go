@@ -42,11 +42,15 @@ import (
 )
 
 func init() {
-	sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+	if err := sigkms.AddProvider(ReferenceScheme, func(ctx context.Context, keyResourceID string, _ crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
 		return LoadSignerVerifier(ctx, keyResourceID)
-	})
+	}); err != nil {
+		panic(err)
+	}
 }
 
+// Algorithm names accepted by CreateKey in addition to the canonical IDs in cryptoutils
+//
 //nolint:revive
 const (
 	AlgorithmECDSAP256SHA256       = "ecdsa-p256-sha256"
@@ -61,17 +65,43 @@ const (
 	AlgorithmRSAPSS4096SHA512      = "rsa-pss-4096-sha512"
 )
 
+// gcpSupportedAlgorithms are the canonical IDs of the algorithms of keys that can be created
+var gcpSupportedAlgorithms = []string{
+	cryptoutils.AlgorithmECDSAP256SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA512,
+	cryptoutils.AlgorithmRSAPSS2048SHA256,
+	cryptoutils.AlgorithmRSAPSS3072SHA256,
+	cryptoutils.AlgorithmRSAPSS4096SHA256,
+	cryptoutils.AlgorithmRSAPSS4096SHA512,
+}
+
+// algorithmMap maps the canonical IDs of the supported algorithms, and the names accepted before
+// them, to GCP KMS algorithms
 var algorithmMap = map[string]kmspb.CryptoKeyVersion_CryptoKeyVersionAlgorithm{
-	AlgorithmECDSAP256SHA256:       kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256,
-	AlgorithmECDSAP384SHA384:       kmspb.CryptoKeyVersion_EC_SIGN_P384_SHA384,
-	AlgorithmRSAPKCS1v152048SHA256: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256,
-	AlgorithmRSAPKCS1v153072SHA256: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_3072_SHA256,
-	AlgorithmRSAPKCS1v154096SHA256: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA256,
-	AlgorithmRSAPKCS1v154096SHA512: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA512,
-	AlgorithmRSAPSS2048SHA256:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256,
-	AlgorithmRSAPSS3072SHA256:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_3072_SHA256,
-	AlgorithmRSAPSS4096SHA256:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA256,
-	AlgorithmRSAPSS4096SHA512:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA512,
+	cryptoutils.AlgorithmECDSAP256SHA256:       kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384:       kmspb.CryptoKeyVersion_EC_SIGN_P384_SHA384,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_3072_SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA512: kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA512,
+	cryptoutils.AlgorithmRSAPSS2048SHA256:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256,
+	cryptoutils.AlgorithmRSAPSS3072SHA256:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_3072_SHA256,
+	cryptoutils.AlgorithmRSAPSS4096SHA256:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA256,
+	cryptoutils.AlgorithmRSAPSS4096SHA512:      kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA512,
+	AlgorithmECDSAP256SHA256:                   kmspb.CryptoKeyVersion_EC_SIGN_P256_SHA256,
+	AlgorithmECDSAP384SHA384:                   kmspb.CryptoKeyVersion_EC_SIGN_P384_SHA384,
+	AlgorithmRSAPKCS1v152048SHA256:             kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_2048_SHA256,
+	AlgorithmRSAPKCS1v153072SHA256:             kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_3072_SHA256,
+	AlgorithmRSAPKCS1v154096SHA256:             kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA256,
+	AlgorithmRSAPKCS1v154096SHA512:             kmspb.CryptoKeyVersion_RSA_SIGN_PKCS1_4096_SHA512,
+	AlgorithmRSAPSS2048SHA256:                  kmspb.CryptoKeyVersion_RSA_SIGN_PSS_2048_SHA256,
+	AlgorithmRSAPSS3072SHA256:                  kmspb.CryptoKeyVersion_RSA_SIGN_PSS_3072_SHA256,
+	AlgorithmRSAPSS4096SHA256:                  kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA256,
+	AlgorithmRSAPSS4096SHA512:                  kmspb.CryptoKeyVersion_RSA_SIGN_PSS_4096_SHA512,
 }
 
 type gcpClient struct {
@@ -19,6 +19,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/option"
 )
@@ -108,3 +109,22 @@ func TestOptionsWork(_ *testing.T) {
 	ts := oauth2.StaticTokenSource(&oauth2.Token{})
 	LoadSignerVerifier(context.Background(), "gcpkms://projects/a-project/locations/global/keyRings/a-keyring/cryptoKeys/key-name", option.WithTokenSource(ts))
 }
+
+func TestSupportedAlgorithms(t *testing.T) {
+	sv := &SignerVerifier{}
+	for _, algorithm := range sv.SupportedAlgorithms() {
+		if _, err := cryptoutils.GetSigningAlgorithm(algorithm); err != nil {
+			t.Errorf("supported algorithm %s is not in the registry: %v", algorithm, err)
+		}
+		if _, ok := algorithmMap[algorithm]; !ok {
+			t.Errorf("supported algorithm %s cannot be created", algorithm)
+		}
+	}
+	if sv.DefaultAlgorithm() != cryptoutils.AlgorithmECDSAP256SHA256 {
+		t.Errorf("unexpected default algorithm %s", sv.DefaultAlgorithm())
+	}
+	// names accepted before the canonical IDs still map to the same algorithm
+	if algorithmMap[AlgorithmRSAPSS2048SHA256] != algorithmMap[cryptoutils.AlgorithmRSAPSS2048SHA256] {
+		t.Error("expected legacy name to map to the same algorithm as the canonical ID")
+	}
+}
@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"slices"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 	"google.golang.org/api/option"
@@ -129,7 +131,8 @@ func (g *SignerVerifier) VerifySignature(signature, message io.Reader, opts ...s
 	return g.client.verify(signature, message, opts...)
 }
 
-// CreateKey attempts to create a new key in Vault with the specified algorithm.
+// CreateKey attempts to create a new key in GCP KMS with the specified algorithm, which is one of
+// SupportedAlgorithms or one of the Algorithm constants of this package.
 func (g *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
 	return g.client.createKey(ctx, algorithm)
 }
@@ -181,15 +184,12 @@ func (g *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error))
 	return csw, defaultHf, nil
 }
 
-// SupportedAlgorithms returns the list of algorithms supported by the GCP KMS service
-func (g *SignerVerifier) SupportedAlgorithms() (result []string) {
-	for k := range algorithmMap {
-		result = append(result, k)
-	}
-	return
+// SupportedAlgorithms returns the canonical IDs of the algorithms supported by the GCP KMS service
+func (g *SignerVerifier) SupportedAlgorithms() []string {
+	return slices.Clone(gcpSupportedAlgorithms)
 }
 
-// DefaultAlgorithm returns the default algorithm for the GCP KMS service
+// DefaultAlgorithm returns the canonical ID of the default algorithm for the GCP KMS service
 func (g *SignerVerifier) DefaultAlgorithm() string {
-	return AlgorithmECDSAP256SHA256
+	return cryptoutils.AlgorithmECDSAP256SHA256
 }
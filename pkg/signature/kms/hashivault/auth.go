@@ -0,0 +1,205 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+// DefaultKubernetesTokenPath is the path of the service account token mounted into Kubernetes pods
+const DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token" // nolint:gosec
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// authMethod logs in to vault with a configured auth method
+type authMethod struct {
+	name string
+	path string
+	// data returns the login request, which is built for each login so that
+	// rotated credentials such as projected service account tokens are picked up
+	data func() (map[string]interface{}, error)
+}
+
+// authMethodFromRPCAuth returns the auth method configured in rpcAuth, or nil if
+// a fixed token should be used.
+func authMethodFromRPCAuth(rpcAuth options.RPCAuth) (*authMethod, error) {
+	var methods []*authMethod
+	if rpcAuth.OIDC.Token != "" {
+		oidc := rpcAuth.OIDC
+		methods = append(methods, &authMethod{
+			name: "oidc",
+			path: defaultString(oidc.Path, "jwt"),
+			data: func() (map[string]interface{}, error) {
+				return map[string]interface{}{
+					"role": oidc.Role,
+					"jwt":  oidc.Token,
+				}, nil
+			},
+		})
+	}
+	if rpcAuth.AppRole.RoleID != "" {
+		appRole := rpcAuth.AppRole
+		methods = append(methods, &authMethod{
+			name: "approle",
+			path: defaultString(appRole.Path, "approle"),
+			data: func() (map[string]interface{}, error) {
+				data := map[string]interface{}{
+					"role_id": appRole.RoleID,
+				}
+				if appRole.SecretID != "" {
+					data["secret_id"] = appRole.SecretID
+				}
+				return data, nil
+			},
+		})
+	}
+	if rpcAuth.Kubernetes.Role != "" {
+		k8s := rpcAuth.Kubernetes
+		tokenPath := defaultString(k8s.TokenPath, DefaultKubernetesTokenPath)
+		methods = append(methods, &authMethod{
+			name: "kubernetes",
+			path: defaultString(k8s.Path, "kubernetes"),
+			data: func() (map[string]interface{}, error) {
+				jwt, err := os.ReadFile(tokenPath)
+				if err != nil {
+					return nil, fmt.Errorf("read service account token: %w", err)
+				}
+				return map[string]interface{}{
+					"role": k8s.Role,
+					"jwt":  strings.TrimSpace(string(jwt)),
+				}, nil
+			},
+		})
+	}
+	if rpcAuth.TLSCert.CertFile != "" {
+		cert := rpcAuth.TLSCert
+		if cert.KeyFile == "" {
+			return nil, errors.New("TLS certificate auth requires a key file")
+		}
+		// the client certificate itself is configured on the vault client
+		methods = append(methods, &authMethod{
+			name: "cert",
+			path: defaultString(cert.Path, "cert"),
+			data: func() (map[string]interface{}, error) {
+				data := map[string]interface{}{}
+				if cert.Name != "" {
+					data["name"] = cert.Name
+				}
+				return data, nil
+			},
+		})
+	}
+
+	switch len(methods) {
+	case 0:
+		return nil, nil
+	case 1:
+		return methods[0], nil
+	default:
+		names := make([]string, 0, len(methods))
+		for _, m := range methods {
+			names = append(names, m.name)
+		}
+		return nil, fmt.Errorf("only one vault auth method may be configured, got %s", strings.Join(names, ", "))
+	}
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// tokenManager keeps the token obtained from an auth method valid, renewing it
+// when less than a third of its lease remains and logging in again when it
+// cannot be renewed any further.
+type tokenManager struct {
+	client *vault.Client
+	method *authMethod
+
+	mu        sync.Mutex
+	lease     time.Duration
+	expiry    time.Time
+	renewable bool
+}
+
+func newTokenManager(ctx context.Context, client *vault.Client, method *authMethod) (*tokenManager, error) {
+	t := &tokenManager{
+		client: client,
+		method: method,
+	}
+	if err := t.login(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *tokenManager) login(ctx context.Context) error {
+	data, err := t.method.data()
+	if err != nil {
+		return fmt.Errorf("vault %s login: %w", t.method.name, err)
+	}
+	secret, err := t.client.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/login", t.method.path), data)
+	if err != nil {
+		return fmt.Errorf("vault %s login: %w", t.method.name, err)
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("vault %s login: response contains no token", t.method.name)
+	}
+	t.client.SetToken(secret.Auth.ClientToken)
+	t.setLease(secret.Auth)
+	return nil
+}
+
+func (t *tokenManager) setLease(auth *vault.SecretAuth) {
+	t.lease = time.Duration(auth.LeaseDuration) * time.Second
+	t.expiry = timeNow().Add(t.lease)
+	t.renewable = auth.Renewable
+}
+
+// ensureValid renews the token or logs in again if the token is near expiry.
+func (t *tokenManager) ensureValid(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// a zero lease means the token does not expire
+	if t.lease == 0 || t.expiry.Sub(timeNow()) > t.lease/3 {
+		return nil
+	}
+	if t.renewable {
+		secret, err := t.client.Auth().Token().RenewSelfWithContext(ctx, int(t.lease.Seconds()))
+		// a renewal capped close to the token's max TTL is not worth keeping
+		if err == nil && secret != nil && secret.Auth != nil && time.Duration(secret.Auth.LeaseDuration)*time.Second > t.lease/3 {
+			t.setLease(secret.Auth)
+			return nil
+		}
+	}
+	return t.login(ctx)
+}
@@ -0,0 +1,362 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashivault
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+	"github.com/sigstore/sigstore/test"
+)
+
+// fakeVault serves the auth endpoints and the public key of an ed25519 transit key,
+// and rejects requests that do not carry the most recently issued token.
+type fakeVault struct {
+	t   *testing.T
+	pub ed25519.PublicKey
+
+	mu sync.Mutex
+	// logins records the request body of each login by auth path
+	logins     map[string][]map[string]interface{}
+	renewals   int
+	token      string
+	issued     int
+	lease      int
+	renewLease int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeVault{
+		t:          t,
+		pub:        pub,
+		logins:     map[string][]map[string]interface{}{},
+		lease:      3600,
+		renewLease: 3600,
+	}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	respond := func(resp map[string]interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/auth/token/renew-self"):
+		if r.Header.Get("X-Vault-Token") != f.token {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		f.renewals++
+		respond(map[string]interface{}{"auth": map[string]interface{}{
+			"client_token": f.token, "lease_duration": f.renewLease, "renewable": true,
+		}})
+	case strings.HasPrefix(r.URL.Path, "/v1/auth/") && strings.HasSuffix(r.URL.Path, "/login"):
+		path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/auth/"), "/login")
+		body := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Errorf("decoding login request: %v", err)
+		}
+		if path == "cert" && (r.TLS == nil || len(r.TLS.PeerCertificates) == 0) {
+			http.Error(w, "missing client certificate", http.StatusBadRequest)
+			return
+		}
+		f.logins[path] = append(f.logins[path], body)
+		f.issued++
+		f.token = fmt.Sprintf("token-%d", f.issued)
+		respond(map[string]interface{}{"auth": map[string]interface{}{
+			"client_token": f.token, "lease_duration": f.lease, "renewable": true,
+		}})
+	case r.URL.Path == "/v1/transit/keys/key":
+		if r.Header.Get("X-Vault-Token") != f.token {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		respond(map[string]interface{}{"data": map[string]interface{}{
+			"latest_version": 1,
+			"keys": map[string]interface{}{"1": map[string]interface{}{
+				"name": "ed25519", "public_key": base64.StdEncoding.EncodeToString(f.pub),
+			}},
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeVault) loginsFor(path string) []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins[path]
+}
+
+func TestAuthMethods(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("service-account-jwt\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		rpcAuth   options.RPCAuth
+		wantPath  string
+		wantLogin map[string]interface{}
+	}{
+		{
+			name:      "oidc",
+			rpcAuth:   options.RPCAuth{OIDC: options.RPCAuthOIDC{Role: "signer", Token: "oidc-jwt"}},
+			wantPath:  "jwt",
+			wantLogin: map[string]interface{}{"role": "signer", "jwt": "oidc-jwt"},
+		},
+		{
+			name:      "approle",
+			rpcAuth:   options.RPCAuth{AppRole: options.RPCAuthAppRole{RoleID: "role-id", SecretID: "secret-id"}},
+			wantPath:  "approle",
+			wantLogin: map[string]interface{}{"role_id": "role-id", "secret_id": "secret-id"},
+		},
+		{
+			name:      "approle custom path",
+			rpcAuth:   options.RPCAuth{AppRole: options.RPCAuthAppRole{Path: "ci-approle", RoleID: "role-id"}},
+			wantPath:  "ci-approle",
+			wantLogin: map[string]interface{}{"role_id": "role-id"},
+		},
+		{
+			name:      "kubernetes",
+			rpcAuth:   options.RPCAuth{Kubernetes: options.RPCAuthKubernetes{Role: "signer", TokenPath: tokenPath}},
+			wantPath:  "kubernetes",
+			wantLogin: map[string]interface{}{"role": "signer", "jwt": "service-account-jwt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault := newFakeVault(t)
+			server := httptest.NewServer(vault)
+			defer server.Close()
+
+			tt.rpcAuth.Address = server.URL
+			sv, err := LoadSignerVerifier("hashivault://key", crypto.Hash(0), options.WithRPCAuthOpts(tt.rpcAuth))
+			if err != nil {
+				t.Fatalf("unexpected error loading signer: %v", err)
+			}
+			logins := vault.loginsFor(tt.wantPath)
+			if len(logins) != 1 {
+				t.Fatalf("expected one login at auth/%s, got %v", tt.wantPath, vault.logins)
+			}
+			if fmt.Sprint(logins[0]) != fmt.Sprint(tt.wantLogin) {
+				t.Fatalf("login request = %v, want %v", logins[0], tt.wantLogin)
+			}
+			pub, err := sv.PublicKey()
+			if err != nil {
+				t.Fatalf("unexpected error fetching public key with login token: %v", err)
+			}
+			if err := cryptoutils.EqualKeys(pub, vault.pub); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestAuthMethodErrors(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	for name, rpcAuth := range map[string]options.RPCAuth{
+		"multiple methods": {
+			AppRole:    options.RPCAuthAppRole{RoleID: "role-id"},
+			Kubernetes: options.RPCAuthKubernetes{Role: "signer"},
+		},
+		"missing token file": {
+			Kubernetes: options.RPCAuthKubernetes{Role: "signer", TokenPath: filepath.Join(t.TempDir(), "missing")},
+		},
+		"missing key file": {
+			TLSCert: options.RPCAuthTLSCert{CertFile: "client.pem"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rpcAuth.Address = server.URL
+			if _, err := LoadSignerVerifier("hashivault://key", crypto.Hash(0), options.WithRPCAuthOpts(rpcAuth)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestTLSCertAuth(t *testing.T) {
+	vault := newFakeVault(t)
+	server := httptest.NewUnstartedServer(vault)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert} // nolint:gosec
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	writePEM := func(name, pemType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cert, priv, err := test.GenerateRootCa()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsCert := options.RPCAuthTLSCert{
+		Name:       "signer",
+		CertFile:   writePEM("client.pem", "CERTIFICATE", cert.Raw),
+		KeyFile:    writePEM("client-key.pem", "PRIVATE KEY", keyDER),
+		CACertFile: writePEM("ca.pem", "CERTIFICATE", server.Certificate().Raw),
+	}
+
+	// the VAULT_* environment variables do not weaken the configured TLS settings
+	t.Setenv("VAULT_SKIP_VERIFY", "true")
+	t.Setenv("VAULT_CACERT", "")
+	untrusted := tlsCert
+	untrusted.CACertFile = ""
+	if _, err := LoadSignerVerifier("hashivault://key", crypto.Hash(0), options.WithRPCAuthOpts(options.RPCAuth{
+		Address: server.URL,
+		TLSCert: untrusted,
+	})); err == nil {
+		t.Fatal("expected error for a server certificate signed by an unknown CA")
+	}
+
+	sv, err := LoadSignerVerifier("hashivault://key", crypto.Hash(0), options.WithRPCAuthOpts(options.RPCAuth{
+		Address: server.URL,
+		TLSCert: tlsCert,
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	if logins := vault.loginsFor("cert"); len(logins) != 1 || logins[0]["name"] != "signer" {
+		t.Fatalf("unexpected cert logins: %v", logins)
+	}
+	if _, err := sv.PublicKey(); err != nil {
+		t.Fatalf("unexpected error fetching public key with login token: %v", err)
+	}
+}
+
+func TestTokenRenewal(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	sv, err := LoadSignerVerifier("hashivault://key", crypto.Hash(0), options.WithRPCAuthOpts(options.RPCAuth{
+		Address: server.URL,
+		AppRole: options.RPCAuthAppRole{RoleID: "role-id"},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	check := func(wantLogins, wantRenewals int) {
+		t.Helper()
+		if _, err := sv.client.fetchPublicKey(context.Background()); err != nil {
+			t.Fatalf("unexpected error fetching public key: %v", err)
+		}
+		if got := len(vault.loginsFor("approle")); got != wantLogins {
+			t.Fatalf("got %d logins, want %d", got, wantLogins)
+		}
+		if vault.renewals != wantRenewals {
+			t.Fatalf("got %d renewals, want %d", vault.renewals, wantRenewals)
+		}
+	}
+
+	// plenty of the lease remains
+	now = now.Add(30 * time.Minute)
+	check(1, 0)
+
+	// less than a third of the lease remains, so the token is renewed
+	now = now.Add(15 * time.Minute)
+	check(1, 1)
+	check(1, 1)
+
+	// the renewal is capped by the token's max TTL, so log in again
+	vault.renewLease = 60
+	now = now.Add(45 * time.Minute)
+	check(2, 2)
+
+	// an expired token that can no longer be renewed is replaced by logging in again
+	vault.token = "revoked"
+	now = now.Add(2 * time.Hour)
+	check(3, 2)
+}
+
+func TestTokenRenewalContext(t *testing.T) {
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	vault := newFakeVault(t)
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	sv, err := LoadSignerVerifier("hashivault://key", crypto.Hash(0), options.WithRPCAuthOpts(options.RPCAuth{
+		Address: server.URL,
+		AppRole: options.RPCAuthAppRole{RoleID: "role-id"},
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+
+	// the token must be renewed, which is abandoned with the caller's context
+	now = now.Add(45 * time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sv.SignMessage(bytes.NewReader([]byte("message")), options.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled signing, got %v", err)
+	}
+	if err := sv.VerifySignature(bytes.NewReader([]byte("sig")), bytes.NewReader([]byte("message")), options.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled verifying, got %v", err)
+	}
+	if _, err := sv.CreateKey(ctx, AlgorithmECDSAP256); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled creating key, got %v", err)
+	}
+	if vault.renewals != 0 || len(vault.loginsFor("approle")) != 1 {
+		t.Fatalf("unexpected requests to Vault with a cancelled context: %d renewals, %d logins", vault.renewals, len(vault.loginsFor("approle")))
+	}
+}
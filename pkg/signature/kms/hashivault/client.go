@@ -20,33 +20,42 @@ import (
 	"context"
 	"crypto"
 	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"time"
 
+	"github.com/hashicorp/go-cleanhttp"
 	vault "github.com/hashicorp/vault/api"
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/mitchellh/go-homedir"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
 func init() {
-	sigkms.AddProvider(ReferenceScheme, func(_ context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+	if err := sigkms.AddProvider(ReferenceScheme, func(_ context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
 		return LoadSignerVerifier(keyResourceID, hashFunc, opts...)
-	})
+	}); err != nil {
+		panic(err)
+	}
 }
 
 type hashivaultClient struct {
-	client                  *vault.Client
+	client *vault.Client
+	// tokens renews the token obtained from an auth method, and is nil for fixed tokens
+	tokens                  *tokenManager
 	keyPath                 string
 	transitSecretEnginePath string
 	keyCache                *ttlcache.Cache[string, crypto.PublicKey]
@@ -67,6 +76,18 @@ const (
 
 	// ReferenceScheme schemes for various KMS services are copied from https://github.com/google/go-cloud/tree/master/secrets
 	ReferenceScheme = "hashivault://"
+
+	// SignatureAlgorithmEnv selects the RSA signature scheme ("pss" or "pkcs1v15") when
+	// options.WithPSS is not given; "pkcs1v15" is used if unset
+	SignatureAlgorithmEnv = "TRANSIT_SIGNATURE_ALGORITHM"
+	// SaltLengthEnv selects the PSS salt length ("auto", "hash" or a number of bytes)
+	// when the RSA signature scheme is set with SignatureAlgorithmEnv
+	SaltLengthEnv = "TRANSIT_SALT_LENGTH"
+
+	signatureAlgorithmPSS      = "pss"
+	signatureAlgorithmPKCS1v15 = "pkcs1v15"
+	saltLengthAuto             = "auto"
+	saltLengthHash             = "hash"
 )
 
 // ValidReference returns a non-nil error if the reference string is invalid
@@ -88,7 +109,7 @@ func parseReference(resourceID string) (keyPath string, err error) {
 	return
 }
 
-func newHashivaultClient(address, token, transitSecretEnginePath, keyResourceID string, keyVersion uint64) (*hashivaultClient, error) {
+func newHashivaultClient(ctx context.Context, rpcAuth options.RPCAuth, keyResourceID string, keyVersion uint64) (*hashivaultClient, error) {
 	if err := ValidReference(keyResourceID); err != nil {
 		return nil, err
 	}
@@ -98,6 +119,12 @@ func newHashivaultClient(address, token, transitSecretEnginePath, keyResourceID
 		return nil, err
 	}
 
+	method, err := authMethodFromRPCAuth(rpcAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	address := rpcAuth.Address
 	if address == "" {
 		address = os.Getenv("VAULT_ADDR")
 	}
@@ -105,32 +132,57 @@ func newHashivaultClient(address, token, transitSecretEnginePath, keyResourceID
 		return nil, errors.New("VAULT_ADDR is not set")
 	}
 
-	client, err := vault.NewClient(&vault.Config{
+	config := &vault.Config{
 		Address: address,
-	})
+	}
+	if rpcAuth.TLSCert.CertFile != "" {
+		// ConfigureTLS needs a transport to configure; vault.DefaultConfig would also apply
+		// the VAULT_* environment variables, which the other auth methods do not use
+		transport := cleanhttp.DefaultPooledTransport()
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		config.HttpClient = &http.Client{Transport: transport}
+		if err := config.ConfigureTLS(&vault.TLSConfig{
+			CACert:     rpcAuth.TLSCert.CACertFile,
+			ClientCert: rpcAuth.TLSCert.CertFile,
+			ClientKey:  rpcAuth.TLSCert.KeyFile,
+		}); err != nil {
+			return nil, fmt.Errorf("configure vault TLS: %w", err)
+		}
+	}
+	client, err := vault.NewClient(config)
 	if err != nil {
 		return nil, fmt.Errorf("new vault client: %w", err)
 	}
 
-	if token == "" {
-		token = os.Getenv("VAULT_TOKEN")
-	}
-	if token == "" {
-		log.Printf("VAULT_TOKEN is not set, trying to read token from file at path ~/.vault-token")
-		homeDir, err := homedir.Dir()
+	var tokens *tokenManager
+	if method != nil {
+		tokens, err = newTokenManager(ctx, client, method)
 		if err != nil {
-			return nil, fmt.Errorf("get home directory: %w", err)
+			return nil, err
 		}
-
-		tokenFromFile, err := os.ReadFile(filepath.Join(homeDir, ".vault-token"))
-		if err != nil {
-			return nil, fmt.Errorf("read .vault-token file: %w", err)
+	} else {
+		token := rpcAuth.Token
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
 		}
+		if token == "" {
+			log.Printf("VAULT_TOKEN is not set, trying to read token from file at path ~/.vault-token")
+			homeDir, err := homedir.Dir()
+			if err != nil {
+				return nil, fmt.Errorf("get home directory: %w", err)
+			}
+
+			tokenFromFile, err := os.ReadFile(filepath.Join(homeDir, ".vault-token"))
+			if err != nil {
+				return nil, fmt.Errorf("read .vault-token file: %w", err)
+			}
 
-		token = string(tokenFromFile)
+			token = string(tokenFromFile)
+		}
+		client.SetToken(token)
 	}
-	client.SetToken(token)
 
+	transitSecretEnginePath := rpcAuth.Path
 	if transitSecretEnginePath == "" {
 		transitSecretEnginePath = os.Getenv("TRANSIT_SECRET_ENGINE_PATH")
 	}
@@ -140,6 +192,7 @@ func newHashivaultClient(address, token, transitSecretEnginePath, keyResourceID
 
 	hvClient := &hashivaultClient{
 		client:                  client,
+		tokens:                  tokens,
 		keyPath:                 keyPath,
 		transitSecretEnginePath: transitSecretEnginePath,
 		keyCache: ttlcache.New[string, crypto.PublicKey](
@@ -151,37 +204,10 @@ func newHashivaultClient(address, token, transitSecretEnginePath, keyResourceID
 	return hvClient, nil
 }
 
-func oidcLogin(_ context.Context, address, path, role, token string) (string, error) {
-	if address == "" {
-		address = os.Getenv("VAULT_ADDR")
-	}
-	if address == "" {
-		return "", errors.New("VAULT_ADDR is not set")
-	}
-	if path == "" {
-		path = "jwt"
-	}
-
-	client, err := vault.NewClient(&vault.Config{
-		Address: address,
-	})
-	if err != nil {
-		return "", fmt.Errorf("new vault client: %w", err)
-	}
-
-	loginData := map[string]interface{}{
-		"role": role,
-		"jwt":  token,
-	}
-	fullpath := fmt.Sprintf("auth/%s/login", path)
-	resp, err := client.Logical().Write(fullpath, loginData)
-	if err != nil {
-		return "", fmt.Errorf("vault oidc login: %w", err)
+func (h *hashivaultClient) fetchPublicKey(ctx context.Context) (crypto.PublicKey, error) {
+	if err := h.tokens.ensureValid(ctx); err != nil {
+		return nil, err
 	}
-	return resp.TokenID()
-}
-
-func (h *hashivaultClient) fetchPublicKey(_ context.Context) (crypto.PublicKey, error) {
 	client := h.client.Logical()
 
 	path := fmt.Sprintf("/%s/keys/%s", h.transitSecretEnginePath, h.keyPath)
@@ -247,12 +273,12 @@ func (h *hashivaultClient) fetchPublicKey(_ context.Context) (crypto.PublicKey,
 	return cryptoutils.UnmarshalPEMToPublicKey([]byte(strPublicKey))
 }
 
-func (h *hashivaultClient) public() (crypto.PublicKey, error) {
+func (h *hashivaultClient) public(ctx context.Context) (crypto.PublicKey, error) {
 	var lerr error
 	loader := ttlcache.LoaderFunc[string, crypto.PublicKey](
 		func(c *ttlcache.Cache[string, crypto.PublicKey], key string) *ttlcache.Item[string, crypto.PublicKey] {
 			var pubkey crypto.PublicKey
-			pubkey, lerr = h.fetchPublicKey(context.Background())
+			pubkey, lerr = h.fetchPublicKey(ctx)
 			if lerr == nil {
 				item := c.Set(key, pubkey, 300*time.Second)
 				return item
@@ -273,28 +299,91 @@ func (h *hashivaultClient) public() (crypto.PublicKey, error) {
 	return item.Value(), nil
 }
 
-func (h hashivaultClient) sign(digest []byte, alg crypto.Hash, opts ...signature.SignOption) ([]byte, error) {
-	client := h.client.Logical()
+// pssOptionsFromEnv returns the PSS options configured with SignatureAlgorithmEnv and
+// SaltLengthEnv, or nil if PKCS#1 v1.5 should be used.
+func pssOptionsFromEnv() (*rsa.PSSOptions, error) {
+	switch algorithm := os.Getenv(SignatureAlgorithmEnv); algorithm {
+	case "", signatureAlgorithmPKCS1v15:
+		return nil, nil
+	case signatureAlgorithmPSS:
+		saltLength, err := parseSaltLength(os.Getenv(SaltLengthEnv))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", SaltLengthEnv, err)
+		}
+		return &rsa.PSSOptions{SaltLength: saltLength}, nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported signature algorithm %q", SignatureAlgorithmEnv, algorithm)
+	}
+}
 
+// parseSaltLength parses a salt length in the format of the transit API.
+func parseSaltLength(s string) (int, error) {
+	switch s {
+	case "", saltLengthAuto:
+		return rsa.PSSSaltLengthAuto, nil
+	case saltLengthHash:
+		return rsa.PSSSaltLengthEqualsHash, nil
+	}
+	saltLength, err := strconv.Atoi(s)
+	if err != nil || saltLength <= 0 {
+		return 0, fmt.Errorf("invalid salt length %q", s)
+	}
+	return saltLength, nil
+}
+
+// formatSaltLength formats a salt length from rsa.PSSOptions in the format of the
+// transit API, which like crypto/rsa uses the maximum salt length when signing
+// and detects the salt length when verifying for "auto".
+func formatSaltLength(saltLength int) string {
+	switch saltLength {
+	case rsa.PSSSaltLengthAuto:
+		return saltLengthAuto
+	case rsa.PSSSaltLengthEqualsHash:
+		return saltLengthHash
+	default:
+		return strconv.Itoa(saltLength)
+	}
+}
+
+// setSignatureAlgorithm adds the transit parameters selecting the RSA signature scheme to
+// a sign or verify request. Vault ignores them for other key types.
+func setSignatureAlgorithm(data map[string]interface{}, pssOpts *rsa.PSSOptions) {
+	if pssOpts == nil {
+		data["signature_algorithm"] = signatureAlgorithmPKCS1v15
+		return
+	}
+	data["signature_algorithm"] = signatureAlgorithmPSS
+	data["salt_length"] = formatSaltLength(pssOpts.SaltLength)
+}
+
+func (h hashivaultClient) sign(digest []byte, alg crypto.Hash, pssOpts *rsa.PSSOptions, opts ...signature.SignOption) ([]byte, error) {
+	ctx := context.Background()
 	keyVersion := fmt.Sprintf("%d", h.keyVersion)
 	var keyVersionUsedPtr *string
 	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
 		opt.ApplyKeyVersion(&keyVersion)
 		opt.ApplyKeyVersionUsed(&keyVersionUsedPtr)
 	}
 
+	if err := h.tokens.ensureValid(ctx); err != nil {
+		return nil, err
+	}
+	client := h.client.Logical()
+
 	if keyVersion != "" {
 		if _, err := strconv.ParseUint(keyVersion, 10, 64); err != nil {
 			return nil, fmt.Errorf("parsing requested key version: %w", err)
 		}
 	}
 
-	signResult, err := client.Write(fmt.Sprintf("/%s/sign/%s%s", h.transitSecretEnginePath, h.keyPath, hashString(alg)), map[string]interface{}{
-		"input":               base64.StdEncoding.Strict().EncodeToString(digest),
-		"prehashed":           alg != crypto.Hash(0),
-		"key_version":         keyVersion,
-		"signature_algorithm": "pkcs1v15",
-	})
+	data := map[string]interface{}{
+		"input":       base64.StdEncoding.Strict().EncodeToString(digest),
+		"prehashed":   alg != crypto.Hash(0),
+		"key_version": keyVersion,
+	}
+	setSignatureAlgorithm(data, pssOpts)
+	signResult, err := client.WriteWithContext(ctx, fmt.Sprintf("/%s/sign/%s%s", h.transitSecretEnginePath, h.keyPath, hashString(alg)), data)
 	if err != nil {
 		return nil, fmt.Errorf("transit: failed to sign payload: %w", err)
 	}
@@ -307,15 +396,20 @@ func (h hashivaultClient) sign(digest []byte, alg crypto.Hash, opts ...signature
 	return vaultDecode(encodedSignature, keyVersionUsedPtr)
 }
 
-func (h hashivaultClient) verify(sig, digest []byte, alg crypto.Hash, opts ...signature.VerifyOption) error {
-	client := h.client.Logical()
-	encodedSig := base64.StdEncoding.EncodeToString(sig)
-
+func (h hashivaultClient) verify(sig, digest []byte, alg crypto.Hash, pssOpts *rsa.PSSOptions, opts ...signature.VerifyOption) error {
+	ctx := context.Background()
 	keyVersion := ""
 	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
 		opt.ApplyKeyVersion(&keyVersion)
 	}
 
+	if err := h.tokens.ensureValid(ctx); err != nil {
+		return err
+	}
+	client := h.client.Logical()
+	encodedSig := base64.StdEncoding.EncodeToString(sig)
+
 	var vaultDataPrefix string
 	if keyVersion != "" {
 		// keyVersion >= 1 on verification but can be set to 0 on signing
@@ -338,11 +432,13 @@ func (h hashivaultClient) verify(sig, digest []byte, alg crypto.Hash, opts ...si
 		}
 	}
 
-	result, err := client.Write(fmt.Sprintf("/%s/verify/%s/%s", h.transitSecretEnginePath, h.keyPath, hashString(alg)), map[string]interface{}{
+	data := map[string]interface{}{
 		"input":     base64.StdEncoding.EncodeToString(digest),
 		"prehashed": alg != crypto.Hash(0),
 		"signature": fmt.Sprintf("%s%s", vaultDataPrefix, encodedSig),
-	})
+	}
+	setSignatureAlgorithm(data, pssOpts)
+	result, err := client.WriteWithContext(ctx, fmt.Sprintf("/%s/verify/%s/%s", h.transitSecretEnginePath, h.keyPath, hashString(alg)), data)
 	if err != nil {
 		return fmt.Errorf("verify: %w", err)
 	}
@@ -394,13 +490,16 @@ func hashString(h crypto.Hash) string {
 	return hashStr
 }
 
-func (h hashivaultClient) createKey(typeStr string) (crypto.PublicKey, error) {
+func (h hashivaultClient) createKey(ctx context.Context, typeStr string) (crypto.PublicKey, error) {
+	if err := h.tokens.ensureValid(ctx); err != nil {
+		return nil, err
+	}
 	client := h.client.Logical()
 
-	if _, err := client.Write(fmt.Sprintf("/%s/keys/%s", h.transitSecretEnginePath, h.keyPath), map[string]interface{}{
+	if _, err := client.WriteWithContext(ctx, fmt.Sprintf("/%s/keys/%s", h.transitSecretEnginePath, h.keyPath), map[string]interface{}{
 		"type": typeStr,
 	}); err != nil {
 		return nil, fmt.Errorf("failed to create transit key: %w", err)
 	}
-	return h.public()
+	return h.public(ctx)
 }
@@ -24,6 +24,8 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"testing"
@@ -440,6 +442,75 @@ func (suite *VaultSuite) TestED25519() {
 	assert.Nil(suite.T(), err)
 }
 
+func (suite *VaultSuite) TestRSAPKCS1v15() {
+	provider := suite.GetProvider("testrsapkcs1v15")
+
+	key, err := provider.CreateKey(context.Background(), AlgorithmRSA2048)
+	require.Nil(suite.T(), err)
+
+	data := []byte("mydata")
+	sig, err := provider.SignMessage(bytes.NewReader(data))
+	require.Nil(suite.T(), err)
+
+	verifier, err := signature.LoadRSAPKCS1v15Verifier(key.(*rsa.PublicKey), crypto.SHA256)
+	require.Nil(suite.T(), err)
+	assert.Nil(suite.T(), verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+	assert.Nil(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+}
+
+func (suite *VaultSuite) TestRSAPSS() {
+	for _, saltLength := range []int{rsa.PSSSaltLengthAuto, rsa.PSSSaltLengthEqualsHash, 20} {
+		pssOpts := &rsa.PSSOptions{SaltLength: saltLength}
+		provider := suite.GetProvider("testrsapss", options.WithPSS(pssOpts))
+
+		key, err := provider.CreateKey(context.Background(), AlgorithmRSA2048)
+		require.Nil(suite.T(), err)
+
+		data := []byte("mydata")
+		sig, err := provider.SignMessage(bytes.NewReader(data))
+		require.Nil(suite.T(), err)
+
+		// the signature must verify with exactly the requested salt length
+		verifySaltLength := saltLength
+		if saltLength == rsa.PSSSaltLengthAuto {
+			verifySaltLength = (key.(*rsa.PublicKey).N.BitLen()-1+7)/8 - 2 - crypto.SHA256.Size()
+		}
+		pssVerifier, err := signature.LoadRSAPSSVerifier(key.(*rsa.PublicKey), crypto.SHA256, &rsa.PSSOptions{SaltLength: verifySaltLength})
+		require.Nil(suite.T(), err)
+		assert.Nil(suite.T(), pssVerifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+
+		pkcs1v15Verifier, err := signature.LoadRSAPKCS1v15Verifier(key.(*rsa.PublicKey), crypto.SHA256)
+		require.Nil(suite.T(), err)
+		assert.NotNil(suite.T(), pkcs1v15Verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+
+		verifier, err := provider.Verifier()
+		require.Nil(suite.T(), err)
+		require.IsType(suite.T(), &signature.RSAPSSVerifier{}, verifier)
+		assert.Nil(suite.T(), verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+
+		assert.Nil(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data), options.WithRemoteVerification(true)))
+		assert.NotNil(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data), options.WithCryptoSignerOpts(crypto.SHA256)))
+	}
+}
+
+func (suite *VaultSuite) TestRSAPSSCryptoSigner() {
+	provider := suite.GetProvider("testrsapsscryptosigner")
+
+	key, err := provider.CreateKey(context.Background(), AlgorithmRSA2048)
+	require.Nil(suite.T(), err)
+
+	data := []byte("mydata")
+	digest := sha256.Sum256(data)
+	pssOpts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	cs, _, err := provider.CryptoSigner(context.Background(), func(err error) { require.Nil(suite.T(), err) })
+	require.Nil(suite.T(), err)
+	sig, err := cs.Sign(rand.Reader, digest[:], pssOpts)
+	require.Nil(suite.T(), err)
+
+	assert.Nil(suite.T(), rsa.VerifyPSS(key.(*rsa.PublicKey), crypto.SHA256, digest[:], sig, pssOpts))
+	assert.Nil(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data), options.WithCryptoSignerOpts(pssOpts)))
+}
+
 func (suite *VaultSuite) TestVerifyBadData() {
 	provider := suite.GetProvider("testverify")
 
@@ -15,7 +15,24 @@
 
 package hashivault
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
 
 func TestParseReference(t *testing.T) {
 	tests := []struct {
@@ -54,3 +71,302 @@ func TestParseReference(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSaltLength(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "", want: rsa.PSSSaltLengthAuto},
+		{in: "auto", want: rsa.PSSSaltLengthAuto},
+		{in: "hash", want: rsa.PSSSaltLengthEqualsHash},
+		{in: "20", want: 20},
+		{in: "0", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "max", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSaltLength(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseSaltLength(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseSaltLength(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+		if !tt.wantErr && tt.in != "" {
+			if s := formatSaltLength(got); s != tt.in {
+				t.Errorf("formatSaltLength(%d) = %q, want %q", got, s, tt.in)
+			}
+		}
+	}
+}
+
+// fakeTransit serves the transit endpoints used by the client for a single RSA key,
+// signing with the scheme requested in each call.
+// Creating the key only records the request.
+type fakeTransit struct {
+	t    *testing.T
+	priv *rsa.PrivateKey
+	// requests records the body of each create, sign and verify request
+	requests []map[string]interface{}
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respond := func(data map[string]interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}
+	if r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/rsakey" {
+		pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(f.priv.Public())
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		respond(map[string]interface{}{
+			"latest_version": 1,
+			"keys":           map[string]interface{}{"1": map[string]interface{}{"name": "rsa-2048", "public_key": string(pemBytes)}},
+		})
+		return
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		f.t.Fatal(err)
+	}
+	f.requests = append(f.requests, body)
+	if r.URL.Path == "/v1/transit/keys/rsakey" {
+		respond(nil)
+		return
+	}
+	digest, err := base64.StdEncoding.DecodeString(body["input"].(string))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	var pssOpts *rsa.PSSOptions
+	if body["signature_algorithm"] == signatureAlgorithmPSS {
+		saltLength, err := parseSaltLength(body["salt_length"].(string))
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		pssOpts = &rsa.PSSOptions{SaltLength: saltLength}
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/transit/sign/rsakey"):
+		var sig []byte
+		if pssOpts != nil {
+			sig, err = rsa.SignPSS(rand.Reader, f.priv, crypto.SHA256, digest, pssOpts)
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, f.priv, crypto.SHA256, digest)
+		}
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		respond(map[string]interface{}{"signature": "vault:v1:" + base64.StdEncoding.EncodeToString(sig)})
+	case strings.HasPrefix(r.URL.Path, "/v1/transit/verify/rsakey"):
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(body["signature"].(string), "vault:v1:"))
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		if pssOpts != nil {
+			err = rsa.VerifyPSS(&f.priv.PublicKey, crypto.SHA256, digest, sig, pssOpts)
+		} else {
+			err = rsa.VerifyPKCS1v15(&f.priv.PublicKey, crypto.SHA256, digest, sig)
+		}
+		respond(map[string]interface{}{"valid": err == nil})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeTransit) lastRequest() map[string]interface{} {
+	return f.requests[len(f.requests)-1]
+}
+
+func TestRSASignatureAlgorithm(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transit := &fakeTransit{t: t, priv: priv}
+	server := httptest.NewServer(transit)
+	defer server.Close()
+	auth := options.WithRPCAuthOpts(options.RPCAuth{Address: server.URL, Token: "token"})
+	msg := []byte("mydata")
+	digest := sha256.Sum256(msg)
+
+	tests := []struct {
+		name           string
+		env            map[string]string
+		opts           []signature.RPCOption
+		wantAlgorithm  string
+		wantSaltLength interface{}
+		// checkSig verifies the signature independently of the provider
+		checkSig func([]byte) error
+	}{
+		{
+			name:          "default",
+			wantAlgorithm: signatureAlgorithmPKCS1v15,
+			checkSig: func(sig []byte) error {
+				return rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, digest[:], sig)
+			},
+		},
+		{
+			name:           "WithPSS",
+			opts:           []signature.RPCOption{options.WithPSS(&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})},
+			wantAlgorithm:  signatureAlgorithmPSS,
+			wantSaltLength: saltLengthHash,
+			checkSig: func(sig []byte) error {
+				return rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+			},
+		},
+		{
+			name:           "environment",
+			env:            map[string]string{SignatureAlgorithmEnv: "pss", SaltLengthEnv: "20"},
+			wantAlgorithm:  signatureAlgorithmPSS,
+			wantSaltLength: "20",
+			checkSig: func(sig []byte) error {
+				return rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: 20})
+			},
+		},
+		{
+			name:           "WithPSS overrides environment",
+			env:            map[string]string{SignatureAlgorithmEnv: "pss", SaltLengthEnv: "20"},
+			opts:           []signature.RPCOption{options.WithPSS(&rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})},
+			wantAlgorithm:  signatureAlgorithmPSS,
+			wantSaltLength: saltLengthAuto,
+			checkSig: func(sig []byte) error {
+				return rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SignatureAlgorithmEnv, "")
+			t.Setenv(SaltLengthEnv, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			sv, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, append(tt.opts, auth)...)
+			if err != nil {
+				t.Fatalf("unexpected error loading signer: %v", err)
+			}
+
+			sig, err := sv.SignMessage(bytes.NewReader(msg))
+			if err != nil {
+				t.Fatalf("unexpected error signing: %v", err)
+			}
+			req := transit.lastRequest()
+			if req["signature_algorithm"] != tt.wantAlgorithm || req["salt_length"] != tt.wantSaltLength {
+				t.Fatalf("sign request = %v, want signature_algorithm %v and salt_length %v", req, tt.wantAlgorithm, tt.wantSaltLength)
+			}
+			if err := tt.checkSig(sig); err != nil {
+				t.Fatalf("signature does not use the requested scheme: %v", err)
+			}
+
+			if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("unexpected error verifying remotely: %v", err)
+			}
+			req = transit.lastRequest()
+			if req["signature_algorithm"] != tt.wantAlgorithm || req["salt_length"] != tt.wantSaltLength {
+				t.Fatalf("verify request = %v, want signature_algorithm %v and salt_length %v", req, tt.wantAlgorithm, tt.wantSaltLength)
+			}
+
+			verifier, err := sv.Verifier()
+			if err != nil {
+				t.Fatalf("unexpected error loading local verifier: %v", err)
+			}
+			_, isPSS := verifier.(*signature.RSAPSSVerifier)
+			if isPSS != (tt.wantAlgorithm == signatureAlgorithmPSS) {
+				t.Fatalf("Verifier() returned %T for signature algorithm %s", verifier, tt.wantAlgorithm)
+			}
+			if err := verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg)); err != nil {
+				t.Fatalf("unexpected error verifying locally: %v", err)
+			}
+		})
+	}
+
+	t.Run("per-signature options", func(t *testing.T) {
+		t.Setenv(SignatureAlgorithmEnv, "")
+		sv, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, auth)
+		if err != nil {
+			t.Fatalf("unexpected error loading signer: %v", err)
+		}
+		cs, opts, err := sv.CryptoSigner(context.Background(), func(err error) { t.Fatal(err) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			t.Fatal("expected PKCS#1 v1.5 signer options by default")
+		}
+		pssOpts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+		sig, err := cs.Sign(rand.Reader, digest[:], pssOpts)
+		if err != nil {
+			t.Fatalf("unexpected error signing: %v", err)
+		}
+		if err := rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], sig, pssOpts); err != nil {
+			t.Fatalf("expected a PSS signature: %v", err)
+		}
+		if err := sv.VerifySignature(bytes.NewReader(sig), bytes.NewReader(msg), options.WithCryptoSignerOpts(pssOpts)); err != nil {
+			t.Fatalf("unexpected error verifying remotely: %v", err)
+		}
+
+		pssSV, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, auth, options.WithPSS(&rsa.PSSOptions{}))
+		if err != nil {
+			t.Fatalf("unexpected error loading signer: %v", err)
+		}
+		if _, opts, _ := pssSV.CryptoSigner(context.Background(), nil); opts.HashFunc() != crypto.SHA256 {
+			t.Fatalf("CryptoSigner() options %v do not carry the hash function", opts)
+		} else if _, ok := opts.(*rsa.PSSOptions); !ok {
+			t.Fatal("expected PSS signer options when RSA-PSS is configured")
+		}
+	})
+
+	// the hash function of the PSS options must not silently replace the one the signer is loaded with
+	if _, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, auth, options.WithPSS(&rsa.PSSOptions{Hash: crypto.SHA512})); err == nil {
+		t.Fatal("expected error for conflicting hash functions")
+	}
+	if _, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, auth, options.WithPSS(&rsa.PSSOptions{Hash: crypto.SHA256})); err != nil {
+		t.Fatalf("unexpected error for matching hash functions: %v", err)
+	}
+
+	t.Setenv(SignatureAlgorithmEnv, "rsa-oaep")
+	if _, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, auth); err == nil {
+		t.Fatal("expected error for unsupported signature algorithm")
+	}
+}
+
+func TestCreateKeyAlgorithms(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transit := &fakeTransit{t: t, priv: priv}
+	server := httptest.NewServer(transit)
+	defer server.Close()
+	sv, err := LoadSignerVerifier("hashivault://rsakey", crypto.SHA256, options.WithRPCAuthOpts(options.RPCAuth{Address: server.URL, Token: "token"}))
+	if err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+
+	if sv.DefaultAlgorithm() != cryptoutils.AlgorithmECDSAP256SHA256 {
+		t.Errorf("unexpected default algorithm %s", sv.DefaultAlgorithm())
+	}
+	for _, algorithm := range sv.SupportedAlgorithms() {
+		if _, err := cryptoutils.GetSigningAlgorithm(algorithm); err != nil {
+			t.Errorf("supported algorithm %s is not in the registry: %v", algorithm, err)
+		}
+	}
+	for algorithm, keyType := range map[string]string{
+		cryptoutils.AlgorithmRSAPKCS1v152048SHA256: AlgorithmRSA2048,
+		cryptoutils.AlgorithmED25519:               AlgorithmED25519,
+		AlgorithmECDSAP384:                         AlgorithmECDSAP384,
+	} {
+		if _, err := sv.CreateKey(context.Background(), algorithm); err != nil {
+			t.Fatalf("unexpected error creating key: %v", err)
+		}
+		if req := transit.lastRequest(); req["type"] != keyType {
+			t.Errorf("CreateKey(%s) requested key type %v, want %s", algorithm, req["type"], keyType)
+		}
+	}
+}
@@ -18,11 +18,14 @@ package hashivault
 import (
 	"context"
 	"crypto"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
@@ -39,14 +42,26 @@ const (
 	AlgorithmRSA4096   = "rsa-4096"
 )
 
+// hvSupportedAlgorithms are the canonical IDs of the algorithms of keys that can be created
 var hvSupportedAlgorithms = []string{
-	AlgorithmECDSAP256,
-	AlgorithmECDSAP384,
-	AlgorithmECDSAP521,
-	AlgorithmED25519,
-	AlgorithmRSA2048,
-	AlgorithmRSA3072,
-	AlgorithmRSA4096,
+	cryptoutils.AlgorithmECDSAP256SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384,
+	cryptoutils.AlgorithmECDSAP521SHA512,
+	cryptoutils.AlgorithmED25519,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256,
+}
+
+// hvKeyTypes maps the canonical IDs of the supported algorithms to transit key types
+var hvKeyTypes = map[string]string{
+	cryptoutils.AlgorithmECDSAP256SHA256:       AlgorithmECDSAP256,
+	cryptoutils.AlgorithmECDSAP384SHA384:       AlgorithmECDSAP384,
+	cryptoutils.AlgorithmECDSAP521SHA512:       AlgorithmECDSAP521,
+	cryptoutils.AlgorithmED25519:               AlgorithmED25519,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256: AlgorithmRSA2048,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256: AlgorithmRSA3072,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256: AlgorithmRSA4096,
 }
 
 var hvSupportedHashFuncs = []crypto.Hash{
@@ -60,6 +75,7 @@ var hvSupportedHashFuncs = []crypto.Hash{
 // SignerVerifier creates and verifies digital signatures over a message using Hashicorp Vault KMS service
 type SignerVerifier struct {
 	hashFunc crypto.Hash
+	pssOpts  *rsa.PSSOptions
 	client   *hashivaultClient
 }
 
@@ -67,15 +83,27 @@ type SignerVerifier struct {
 //
 // It also can verify signatures (via a remote vall to the Vault instance). hashFunc should be
 // set to crypto.Hash(0) if the key referred to by referenceStr is an ED25519 signing key.
+//
+// Vault is accessed with the token given in the RPCAuth options, or read from VAULT_TOKEN or
+// ~/.vault-token, unless the RPCAuth options configure a login with the JWT (OIDC), AppRole,
+// Kubernetes or TLS certificate auth method. The token obtained from a login is renewed, or
+// obtained again by logging in, when it nears expiry.
+//
+// RSA keys sign with PKCS#1 v1.5 unless options.WithPSS is passed in opts, or the
+// TRANSIT_SIGNATURE_ALGORITHM environment variable is set to "pss" (with the salt length
+// taken from TRANSIT_SALT_LENGTH). The salt length of the PSS options is honoured; the
+// hash function of the PSS options, if set, must be hashFunc.
 func LoadSignerVerifier(referenceStr string, hashFunc crypto.Hash, opts ...signature.RPCOption) (*SignerVerifier, error) {
 	h := &SignerVerifier{}
 	ctx := context.Background()
 	rpcAuth := options.RPCAuth{}
 	var keyVersion string
+	var pssOpts *rsa.PSSOptions
 	for _, opt := range opts {
 		opt.ApplyRPCAuthOpts(&rpcAuth)
 		opt.ApplyContext(&ctx)
 		opt.ApplyKeyVersion(&keyVersion)
+		opt.ApplyPSS(&pssOpts)
 	}
 
 	var keyVersionUint uint64
@@ -87,15 +115,24 @@ func LoadSignerVerifier(referenceStr string, hashFunc crypto.Hash, opts ...signa
 		}
 	}
 
-	if rpcAuth.OIDC.Token != "" {
-		rpcAuth.Token, err = oidcLogin(ctx, rpcAuth.Address, rpcAuth.OIDC.Path, rpcAuth.OIDC.Role, rpcAuth.OIDC.Token)
-		if err != nil {
+	h.client, err = newHashivaultClient(ctx, rpcAuth, referenceStr, keyVersionUint)
+	if err != nil {
+		return nil, err
+	}
+
+	if pssOpts == nil {
+		if pssOpts, err = pssOptionsFromEnv(); err != nil {
 			return nil, err
 		}
 	}
-	h.client, err = newHashivaultClient(rpcAuth.Address, rpcAuth.Token, rpcAuth.Path, referenceStr, keyVersionUint)
-	if err != nil {
-		return nil, err
+	if pssOpts != nil {
+		if pssOpts.Hash != crypto.Hash(0) && pssOpts.Hash != hashFunc {
+			return nil, fmt.Errorf("hash function %v of the PSS options does not match hash function %v", pssOpts.Hash, hashFunc)
+		}
+		h.pssOpts = &rsa.PSSOptions{
+			SaltLength: pssOpts.SaltLength,
+			Hash:       hashFunc,
+		}
 	}
 
 	switch hashFunc {
@@ -108,6 +145,24 @@ func LoadSignerVerifier(referenceStr string, hashFunc crypto.Hash, opts ...signa
 	return h, nil
 }
 
+// signerOpts returns the crypto.SignerOpts used when none are given: the PSS
+// options if RSA-PSS is configured, and the hash function otherwise.
+func (h SignerVerifier) signerOpts() crypto.SignerOpts {
+	if h.pssOpts != nil {
+		return h.pssOpts
+	}
+	return h.hashFunc
+}
+
+// pssOptions returns the PSS options to use for the given crypto.SignerOpts, following
+// the crypto.Signer convention that RSA-PSS is requested with *rsa.PSSOptions.
+func pssOptions(opts crypto.SignerOpts) *rsa.PSSOptions {
+	if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+		return pssOpts
+	}
+	return nil
+}
+
 // SignMessage signs the provided message using HashiCorp Vault KMS. If the message is provided,
 // this method will compute the digest according to the hash function specified
 // when the HashivaultSigner was created.
@@ -116,10 +171,15 @@ func LoadSignerVerifier(referenceStr string, hashFunc crypto.Hash, opts ...signa
 //
 // - WithDigest()
 //
+// - WithCryptoSignerOpts()
+//
+// RSA keys sign with PSS if the crypto.SignerOpts are *rsa.PSSOptions, which is the
+// default if RSA-PSS was configured when the SignerVerifier was loaded.
+//
 // All other options are ignored if specified.
 func (h SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
 	var digest []byte
-	var signerOpts crypto.SignerOpts = h.hashFunc
+	signerOpts := h.signerOpts()
 
 	for _, opt := range opts {
 		opt.ApplyDigest(&digest)
@@ -131,13 +191,30 @@ func (h SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOpt
 		return nil, err
 	}
 
-	return h.client.sign(digest, hf, opts...)
+	return h.client.sign(digest, hf, pssOptions(signerOpts), opts...)
 }
 
 // PublicKey returns the public key that can be used to verify signatures created by
 // this signer. All options provided in arguments to this method are ignored.
 func (h SignerVerifier) PublicKey(_ ...signature.PublicKeyOption) (crypto.PublicKey, error) {
-	return h.client.public()
+	return h.client.public(context.Background())
+}
+
+// Verifier returns a signature.Verifier that verifies signatures locally with the latest
+// public key of the Vault key. For RSA keys it is a *signature.RSAPSSVerifier using the
+// configured salt length if RSA-PSS was configured when the SignerVerifier was loaded.
+//
+// All options provided in arguments to this method are ignored.
+func (h SignerVerifier) Verifier(_ ...signature.PublicKeyOption) (signature.Verifier, error) {
+	pub, err := h.client.public(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	loadOpts := []signature.LoadOption{options.WithHash(h.hashFunc)}
+	if h.pssOpts != nil {
+		loadOpts = append(loadOpts, options.WithRSAPSS(h.pssOpts))
+	}
+	return signature.LoadVerifierWithOpts(pub, loadOpts...)
 }
 
 // VerifySignature verifies the signature for the given message. Unless provided
@@ -146,16 +223,22 @@ func (h SignerVerifier) PublicKey(_ ...signature.PublicKeyOption) (crypto.Public
 //
 // This function returns nil if the verification succeeded, and an error message otherwise.
 //
+// Verification is always performed remotely by Vault, as if WithRemoteVerification()
+// was given; use Verifier() to verify signatures locally.
+//
 // This function recognizes the following Options listed in order of preference:
 //
 // - WithDigest()
 //
 // - WithCryptoSignerOpts()
 //
+// RSA signatures are verified as PSS signatures if the crypto.SignerOpts are *rsa.PSSOptions,
+// which is the default if RSA-PSS was configured when the SignerVerifier was loaded.
+//
 // All other options are ignored if specified.
 func (h SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
 	var digest []byte
-	var signerOpts crypto.SignerOpts = h.hashFunc
+	signerOpts := h.signerOpts()
 
 	for _, opt := range opts {
 		opt.ApplyDigest(&digest)
@@ -172,19 +255,23 @@ func (h SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signatur
 		return fmt.Errorf("reading signature: %w", err)
 	}
 
-	return h.client.verify(sigBytes, digest, hf, opts...)
+	return h.client.verify(sigBytes, digest, hf, pssOptions(signerOpts), opts...)
 }
 
-// CreateKey attempts to create a new key in Vault with the specified algorithm.
-func (h SignerVerifier) CreateKey(_ context.Context, algorithm string) (crypto.PublicKey, error) {
-	return h.client.createKey(algorithm)
+// CreateKey attempts to create a new key in Vault with the specified algorithm, which is one of
+// SupportedAlgorithms or a transit key type such as the Algorithm constants of this package.
+func (h SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if keyType, ok := hvKeyTypes[algorithm]; ok {
+		algorithm = keyType
+	}
+	return h.client.createKey(ctx, algorithm)
 }
 
 type cryptoSignerWrapper struct {
-	ctx      context.Context
-	hashFunc crypto.Hash
-	sv       *SignerVerifier
-	errFunc  func(error)
+	ctx        context.Context
+	signerOpts crypto.SignerOpts
+	sv         *SignerVerifier
+	errFunc    func(error)
 }
 
 func (c cryptoSignerWrapper) Public() crypto.PublicKey {
@@ -196,38 +283,40 @@ func (c cryptoSignerWrapper) Public() crypto.PublicKey {
 }
 
 func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
-	hashFunc := c.hashFunc
+	// *rsa.PSSOptions are passed through so that RSA-PSS can be requested per signature
+	signerOpts := c.signerOpts
 	if opts != nil {
-		hashFunc = opts.HashFunc()
+		signerOpts = opts
 	}
 	hvOptions := []signature.SignOption{
 		options.WithContext(c.ctx),
 		options.WithDigest(digest),
-		options.WithCryptoSignerOpts(hashFunc),
+		options.WithCryptoSignerOpts(signerOpts),
 	}
 
 	return c.sv.SignMessage(nil, hvOptions...)
 }
 
 // CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
-// that allows the KMS to be used in APIs that only accept the standard golang objects
+// that allows the KMS to be used in APIs that only accept the standard golang objects. The crypto.SignerOpts are
+// *rsa.PSSOptions if RSA-PSS was configured when the SignerVerifier was loaded.
 func (h *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
 	csw := &cryptoSignerWrapper{
-		ctx:      ctx,
-		sv:       h,
-		hashFunc: h.hashFunc,
-		errFunc:  errFunc,
+		ctx:        ctx,
+		sv:         h,
+		signerOpts: h.signerOpts(),
+		errFunc:    errFunc,
 	}
 
-	return csw, h.hashFunc, nil
+	return csw, h.signerOpts(), nil
 }
 
-// SupportedAlgorithms returns the list of algorithms supported by the Hashicorp Vault service
+// SupportedAlgorithms returns the canonical IDs of the algorithms supported by the Hashicorp Vault service
 func (h *SignerVerifier) SupportedAlgorithms() []string {
-	return hvSupportedAlgorithms
+	return slices.Clone(hvSupportedAlgorithms)
 }
 
-// DefaultAlgorithm returns the default algorithm for the Hashicorp Vault service
+// DefaultAlgorithm returns the canonical ID of the default algorithm for the Hashicorp Vault service
 func (h *SignerVerifier) DefaultAlgorithm() string {
-	return AlgorithmECDSAP256
+	return cryptoutils.AlgorithmECDSAP256SHA256
 }
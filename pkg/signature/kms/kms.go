@@ -19,10 +19,14 @@ package kms
 import (
 	"context"
 	"crypto"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms/cliplugin"
 )
 
 // ProviderNotFoundError indicates that no matching KMS provider was found
@@ -40,31 +44,91 @@ func (e *ProviderNotFoundError) Error() string {
 // SignerVerifier using that resource, or any error that was encountered.
 type ProviderInit func(context.Context, string, crypto.Hash, ...signature.RPCOption) (SignerVerifier, error)
 
-// AddProvider adds the provider implementation into the local cache
-func AddProvider(keyResourceID string, init ProviderInit) {
+// DuplicateProviderError indicates that a provider is already registered for a reference scheme
+type DuplicateProviderError struct {
+	scheme string
+}
+
+func (e *DuplicateProviderError) Error() string {
+	return fmt.Sprintf("kms provider already registered for: %s", e.scheme)
+}
+
+var (
+	providersMu  sync.RWMutex
+	providersMap = map[string]ProviderInit{}
+)
+
+// AddProvider adds the provider implementation into the local cache under the
+// given reference scheme (e.g. "awskms://"). It returns a DuplicateProviderError
+// if a provider is already registered for the scheme.
+//
+// AddProvider is safe for concurrent use, so providers may be registered from
+// init functions as well as lazily at runtime.
+func AddProvider(keyResourceID string, init ProviderInit) error {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if _, ok := providersMap[keyResourceID]; ok {
+		return &DuplicateProviderError{scheme: keyResourceID}
+	}
 	providersMap[keyResourceID] = init
+	return nil
 }
 
-var providersMap = map[string]ProviderInit{}
+// findProvider returns the provider registered for the scheme of the reference
+// (everything up to and including "://"), falling back to the provider with the
+// longest registered prefix of the reference.
+func findProvider(keyResourceID string) (ProviderInit, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
 
-// Get returns a KMS SignerVerifier for the given resource string and hash function.
-// If no matching provider is found, Get returns a ProviderNotFoundError. It
-// also returns an error if initializing the SignerVerifier fails.
-func Get(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (SignerVerifier, error) {
+	if i := strings.Index(keyResourceID, "://"); i >= 0 {
+		if pi, ok := providersMap[keyResourceID[:i+len("://")]]; ok {
+			return pi, true
+		}
+	}
+
+	var match string
+	var init ProviderInit
 	for ref, pi := range providersMap {
-		if strings.HasPrefix(keyResourceID, ref) {
-			return pi(ctx, keyResourceID, hashFunc, opts...)
+		if strings.HasPrefix(keyResourceID, ref) && len(ref) > len(match) {
+			match, init = ref, pi
 		}
 	}
-	return nil, &ProviderNotFoundError{ref: keyResourceID}
+	return init, init != nil
 }
 
-// SupportedProviders returns list of initialized providers
+// Get returns a KMS SignerVerifier for the given resource string and hash function.
+// The provider registered for the exact scheme of the resource string is preferred;
+// otherwise the provider with the longest matching prefix is used.
+// If no provider is registered, Get falls back to a plugin executable named
+// "sigstore-kms-<scheme>" in PATH (see package cliplugin), and returns a
+// ProviderNotFoundError if there is none. It also returns an error if
+// initializing the SignerVerifier fails.
+func Get(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (SignerVerifier, error) {
+	if pi, ok := findProvider(keyResourceID); ok {
+		return pi(ctx, keyResourceID, hashFunc, opts...)
+	}
+	sv, err := cliplugin.LoadSignerVerifier(ctx, keyResourceID, hashFunc, opts...)
+	if errors.Is(err, cliplugin.ErrorPluginNotFound) {
+		return nil, &ProviderNotFoundError{ref: keyResourceID}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sv, nil
+}
+
+// SupportedProviders returns list of initialized providers, sorted by scheme
 func SupportedProviders() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
 	keys := make([]string, 0, len(providersMap))
 	for key := range providersMap {
 		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 	return keys
 }
 
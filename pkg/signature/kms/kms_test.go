@@ -0,0 +1,165 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kms
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+)
+
+// namedError lets tests identify which provider was selected without building a SignerVerifier
+type namedError string
+
+func (e namedError) Error() string { return string(e) }
+
+func namedProvider(name string) ProviderInit {
+	return func(context.Context, string, crypto.Hash, ...signature.RPCOption) (SignerVerifier, error) {
+		return nil, namedError(name)
+	}
+}
+
+func resetProviders(t *testing.T) {
+	t.Helper()
+	providersMu.Lock()
+	saved := providersMap
+	providersMap = map[string]ProviderInit{}
+	providersMu.Unlock()
+	t.Cleanup(func() {
+		providersMu.Lock()
+		providersMap = saved
+		providersMu.Unlock()
+	})
+}
+
+func selectedProvider(t *testing.T, ref string) string {
+	t.Helper()
+	_, err := Get(context.Background(), ref, crypto.SHA256)
+	var named namedError
+	if errors.As(err, &named) {
+		return string(named)
+	}
+	var notFound *ProviderNotFoundError
+	if errors.As(err, &notFound) {
+		return ""
+	}
+	t.Fatalf("unexpected error: %v", err)
+	return ""
+}
+
+func TestGetSelectsProvider(t *testing.T) {
+	resetProviders(t)
+	for scheme, name := range map[string]string{
+		"hashivault://":              "hashivault",
+		"hashivault-ent://":          "hashivault-ent",
+		"gcpkms://":                  "gcp",
+		"gcpkms://projects/special/": "gcp-special",
+		"pkcs11:":                    "pkcs11",
+	} {
+		if err := AddProvider(scheme, namedProvider(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "hashivault://key", want: "hashivault"},
+		{ref: "hashivault-ent://key", want: "hashivault-ent"},
+		// an exact scheme match wins over a longer prefix
+		{ref: "gcpkms://projects/special/key", want: "gcp"},
+		// without a "://" separator, the longest prefix is used
+		{ref: "pkcs11:token=foo;object=bar", want: "pkcs11"},
+		{ref: "awskms:///alias/key", want: ""},
+		{ref: "hashivault", want: ""},
+	}
+	// repeat to catch any dependence on map iteration order
+	for i := 0; i < 20; i++ {
+		for _, tt := range tests {
+			if got := selectedProvider(t, tt.ref); got != tt.want {
+				t.Fatalf("Get(%q) selected %q, want %q", tt.ref, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestGetLongestPrefix(t *testing.T) {
+	resetProviders(t)
+	if err := AddProvider("vault", namedProvider("short")); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddProvider("vault-ent", namedProvider("long")); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if got := selectedProvider(t, "vault-ent://key"); got != "long" {
+			t.Fatalf("Get() selected %q, want %q", got, "long")
+		}
+		if got := selectedProvider(t, "vault://key"); got != "short" {
+			t.Fatalf("Get() selected %q, want %q", got, "short")
+		}
+	}
+}
+
+func TestAddProviderDuplicate(t *testing.T) {
+	resetProviders(t)
+	if err := AddProvider("dup://", namedProvider("first")); err != nil {
+		t.Fatal(err)
+	}
+	err := AddProvider("dup://", namedProvider("second"))
+	var dupErr *DuplicateProviderError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("expected DuplicateProviderError, got %v", err)
+	}
+	if got := selectedProvider(t, "dup://key"); got != "first" {
+		t.Fatalf("duplicate registration replaced the original provider, got %q", got)
+	}
+}
+
+func TestAddProviderConcurrent(t *testing.T) {
+	resetProviders(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := AddProvider(fmt.Sprintf("p%d://", i), namedProvider(fmt.Sprint(i))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = Get(context.Background(), fmt.Sprintf("p%d://key", i), crypto.SHA256)
+			_ = SupportedProviders()
+		}(i)
+	}
+	wg.Wait()
+
+	providers := SupportedProviders()
+	if len(providers) != 50 {
+		t.Fatalf("expected 50 providers, got %d", len(providers))
+	}
+	if !sort.StringsAreSorted(providers) {
+		t.Fatalf("SupportedProviders() is not sorted: %v", providers)
+	}
+}
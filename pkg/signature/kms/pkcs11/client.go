@@ -0,0 +1,549 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+// Package pkcs11 implement the interface with PKCS#11 hardware security modules
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+// constants from PKCS#11 v3.0 which are not defined by github.com/miekg/pkcs11
+const (
+	ckkECEdwards           = 0x00000040
+	ckmECEdwardsKeyPairGen = 0x00001055
+	ckmEDDSA               = 0x00001057
+)
+
+// fetching two objects is enough to detect references that match more than one key
+const maxObjectsForDisambiguation = 2
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidNamedCurveP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidNamedCurveP521 = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+	oidEd25519        = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+	// DigestInfo prefixes from RFC 8017, section 9.2
+	pkcs1v15Prefixes = map[crypto.Hash][]byte{
+		crypto.SHA224: {0x30, 0x2d, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x04, 0x05, 0x00, 0x04, 0x1c},
+		crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+		crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+		crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+	}
+
+	errKeyNotFound = errors.New("no matching key found on token")
+
+	// C_Initialize may only be called once per process for a given module,
+	// so module handles are shared between all clients
+	modulesMu sync.Mutex
+	modules   = map[string]module{}
+
+	// tokens support a limited number of sessions, so a single session per
+	// slot is shared between all clients
+	sessionsMu sync.Mutex
+	sessions   = map[sessionKey]*session{}
+)
+
+// module is the subset of the PKCS#11 API used by the client, as implemented by *pkcs11.Ctx
+type module interface {
+	GetSlotList(tokenPresent bool) ([]uint, error)
+	GetTokenInfo(slotID uint) (pkcs11.TokenInfo, error)
+	OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error)
+	CloseSession(sh pkcs11.SessionHandle) error
+	Login(sh pkcs11.SessionHandle, userType uint, pin string) error
+	FindObjectsInit(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error
+	FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error)
+	FindObjectsFinal(sh pkcs11.SessionHandle) error
+	GetAttributeValue(sh pkcs11.SessionHandle, o pkcs11.ObjectHandle, a []*pkcs11.Attribute) ([]*pkcs11.Attribute, error)
+	SignInit(sh pkcs11.SessionHandle, m []*pkcs11.Mechanism, o pkcs11.ObjectHandle) error
+	Sign(sh pkcs11.SessionHandle, message []byte) ([]byte, error)
+	VerifyInit(sh pkcs11.SessionHandle, m []*pkcs11.Mechanism, key pkcs11.ObjectHandle) error
+	Verify(sh pkcs11.SessionHandle, data []byte, signature []byte) error
+	GenerateKeyPair(sh pkcs11.SessionHandle, m []*pkcs11.Mechanism, public, private []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error)
+}
+
+type sessionKey struct {
+	module string
+	slot   uint
+}
+
+type session struct {
+	// PKCS#11 sessions must not be used concurrently
+	mu     sync.Mutex
+	handle pkcs11.SessionHandle
+}
+
+type pkcs11Client struct {
+	ctx module
+	uri *URI
+
+	// session.mu also guards publicKey
+	session   *session
+	publicKey crypto.PublicKey
+}
+
+func loadModule(path string) (module, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	if p, ok := modules[path]; ok {
+		return p, nil
+	}
+	p := pkcs11.New(path)
+	if p == nil {
+		return nil, fmt.Errorf("loading PKCS#11 module %q", path)
+	}
+	if err := p.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		p.Destroy()
+		return nil, fmt.Errorf("initializing PKCS#11 module %q: %w", path, err)
+	}
+	modules[path] = p
+	return p, nil
+}
+
+func newPKCS11Client(_ context.Context, uri *URI) (*pkcs11Client, error) {
+	path, err := uri.modulePath()
+	if err != nil {
+		return nil, err
+	}
+	p, err := loadModule(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pin, err := uri.pin()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &pkcs11Client{
+		ctx: p,
+		uri: uri,
+	}
+
+	slot, err := c.findSlot()
+	if err != nil {
+		return nil, err
+	}
+	c.session, err = openSession(p, path, slot, pin)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// openSession returns the session shared by all clients of the slot, opening
+// it if needed. The login state applies to all sessions of the token, so
+// logging in again with an existing session is harmless.
+func openSession(p module, path string, slot uint, pin string) (*session, error) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	key := sessionKey{module: path, slot: slot}
+	s, ok := sessions[key]
+	if !ok {
+		handle, err := p.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+		if err != nil {
+			return nil, fmt.Errorf("opening session on slot %d: %w", slot, err)
+		}
+		s = &session{handle: handle}
+	}
+
+	if pin != "" {
+		s.mu.Lock()
+		err := p.Login(s.handle, pkcs11.CKU_USER, pin)
+		s.mu.Unlock()
+		if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			if !ok {
+				_ = p.CloseSession(s.handle)
+			}
+			return nil, fmt.Errorf("logging in to token: %w", err)
+		}
+	}
+	sessions[key] = s
+	return s, nil
+}
+
+func (c *pkcs11Client) findSlot() (uint, error) {
+	slots, err := c.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("listing slots: %w", err)
+	}
+	for _, slot := range slots {
+		if c.uri.SlotID != nil && *c.uri.SlotID != slot {
+			continue
+		}
+		info, err := c.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("reading token info for slot %d: %w", slot, err)
+		}
+		if matches(c.uri.Token, info.Label) && matches(c.uri.Manufacturer, info.ManufacturerID) &&
+			matches(c.uri.Serial, info.SerialNumber) && matches(c.uri.Model, info.Model) {
+			return slot, nil
+		}
+	}
+	return 0, errors.New("no token matching the reference was found")
+}
+
+func matches(want, got string) bool {
+	return want == "" || want == got
+}
+
+// findObject returns the handle of the single object of the given class that
+// matches the reference. The caller must hold c.session.mu.
+func (c *pkcs11Client) findObject(class uint) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if c.uri.Object != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, c.uri.Object))
+	}
+	if len(c.uri.ID) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, c.uri.ID))
+	}
+
+	if err := c.ctx.FindObjectsInit(c.session.handle, template); err != nil {
+		return 0, fmt.Errorf("finding objects: %w", err)
+	}
+	handles, _, err := c.ctx.FindObjects(c.session.handle, maxObjectsForDisambiguation)
+	if finalErr := c.ctx.FindObjectsFinal(c.session.handle); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("finding objects: %w", err)
+	}
+
+	switch len(handles) {
+	case 0:
+		return 0, errKeyNotFound
+	case 1:
+		return handles[0], nil
+	default:
+		return 0, errors.New("reference matches more than one key; add an id or object attribute")
+	}
+}
+
+func (c *pkcs11Client) public(_ context.Context) (crypto.PublicKey, error) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	if c.publicKey != nil {
+		return c.publicKey, nil
+	}
+	handle, err := c.findObject(pkcs11.CKO_PUBLIC_KEY)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	c.publicKey, err = c.readPublicKey(handle)
+	if err != nil {
+		return nil, err
+	}
+	return c.publicKey, nil
+}
+
+func (c *pkcs11Client) readPublicKey(handle pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	attrs, err := c.ctx.GetAttributeValue(c.session.handle, handle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading key type: %w", err)
+	}
+
+	switch keyType := bytesToUint(attrs[0].Value); keyType {
+	case pkcs11.CKK_RSA:
+		attrs, err := c.ctx.GetAttributeValue(c.session.handle, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading RSA public key: %w", err)
+		}
+		e := new(big.Int).SetBytes(attrs[1].Value)
+		if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+			return nil, errors.New("RSA public exponent is too large")
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(e.Int64()),
+		}, nil
+	case pkcs11.CKK_EC, ckkECEdwards:
+		attrs, err := c.ctx.GetAttributeValue(c.session.handle, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reading EC public key: %w", err)
+		}
+		// CKA_EC_POINT should be a DER OCTET STRING, but some modules return the raw point
+		point := attrs[1].Value
+		var unwrapped []byte
+		if rest, err := asn1.Unmarshal(point, &unwrapped); err == nil && len(rest) == 0 {
+			point = unwrapped
+		}
+		if keyType == ckkECEdwards {
+			if len(point) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("ed25519 public key length is %d, should be %d", len(point), ed25519.PublicKeySize)
+			}
+			return ed25519.PublicKey(point), nil
+		}
+		var curve asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(attrs[0].Value, &curve); err != nil {
+			return nil, fmt.Errorf("parsing EC parameters: %w", err)
+		}
+		return parseECPoint(curve, point)
+	default:
+		return nil, fmt.Errorf("unsupported key type 0x%x", keyType)
+	}
+}
+
+// parseECPoint converts an uncompressed EC point into an *ecdsa.PublicKey by
+// round-tripping through a SubjectPublicKeyInfo, which validates the point.
+func parseECPoint(curve asn1.ObjectIdentifier, point []byte) (crypto.PublicKey, error) {
+	params, err := asn1.Marshal(curve)
+	if err != nil {
+		return nil, err
+	}
+	spki, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(spki)
+	if err != nil {
+		return nil, fmt.Errorf("parsing EC public key: %w", err)
+	}
+	return pub, nil
+}
+
+// mechanism returns the PKCS#11 mechanism and the input to C_Sign or C_Verify
+// for the given public key and digest.
+func mechanism(pub crypto.PublicKey, digest []byte, hf crypto.Hash) (*pkcs11.Mechanism, []byte, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		prefix, ok := pkcs1v15Prefixes[hf]
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported hash function for RSA keys: %v", hf)
+		}
+		data := make([]byte, 0, len(prefix)+len(digest))
+		data = append(data, prefix...)
+		return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil), append(data, digest...), nil
+	case *ecdsa.PublicKey:
+		if hf == crypto.Hash(0) {
+			return nil, nil, errors.New("a hash function must be specified for ECDSA keys")
+		}
+		return pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil), digest, nil
+	case ed25519.PublicKey:
+		if hf != crypto.Hash(0) {
+			return nil, nil, errors.New("ed25519 keys sign the message directly; hash function must be crypto.Hash(0)")
+		}
+		return pkcs11.NewMechanism(ckmEDDSA, nil), digest, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func (c *pkcs11Client) sign(ctx context.Context, digest []byte, hf crypto.Hash) ([]byte, error) {
+	pub, err := c.public(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mech, data, err := mechanism(pub, digest, hf)
+	if err != nil {
+		return nil, err
+	}
+
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	handle, err := c.findObject(pkcs11.CKO_PRIVATE_KEY)
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+	if err := c.ctx.SignInit(c.session.handle, []*pkcs11.Mechanism{mech}, handle); err != nil {
+		return nil, fmt.Errorf("pkcs11: failed to sign payload: %w", err)
+	}
+	sig, err := c.ctx.Sign(c.session.handle, data)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: failed to sign payload: %w", err)
+	}
+
+	if _, ok := pub.(*ecdsa.PublicKey); ok {
+		// PKCS#11 returns r || s, but callers expect an ASN.1 encoded signature
+		if len(sig) == 0 || len(sig)%2 != 0 {
+			return nil, errors.New("pkcs11: malformed ECDSA signature")
+		}
+		n := len(sig) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(sig[:n]),
+			S: new(big.Int).SetBytes(sig[n:]),
+		})
+	}
+	return sig, nil
+}
+
+func (c *pkcs11Client) verifyRemotely(ctx context.Context, sig, digest []byte, hf crypto.Hash) error {
+	pub, err := c.public(ctx)
+	if err != nil {
+		return err
+	}
+	mech, data, err := mechanism(pub, digest, hf)
+	if err != nil {
+		return err
+	}
+
+	if ecPub, ok := pub.(*ecdsa.PublicKey); ok {
+		var esig struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(sig, &esig); err != nil || len(rest) != 0 {
+			return errors.New("invalid ECDSA signature encoding")
+		}
+		size := (ecPub.Curve.Params().BitSize + 7) / 8
+		if esig.R.Sign() <= 0 || esig.S.Sign() <= 0 || esig.R.BitLen() > 8*size || esig.S.BitLen() > 8*size {
+			return errors.New("invalid ECDSA signature values")
+		}
+		raw := make([]byte, 2*size)
+		esig.R.FillBytes(raw[:size])
+		esig.S.FillBytes(raw[size:])
+		sig = raw
+	}
+
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	handle, err := c.findObject(pkcs11.CKO_PUBLIC_KEY)
+	if err != nil {
+		return fmt.Errorf("public key: %w", err)
+	}
+	if err := c.ctx.VerifyInit(c.session.handle, []*pkcs11.Mechanism{mech}, handle); err != nil {
+		return fmt.Errorf("verify: %w", err)
+	}
+	if err := c.ctx.Verify(c.session.handle, data, sig); err != nil {
+		return fmt.Errorf("failed pkcs11 verification: %w", err)
+	}
+	return nil
+}
+
+func (c *pkcs11Client) createKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	// look for existing key first
+	pub, err := c.public(ctx)
+	if err == nil {
+		return pub, nil
+	}
+	if !errors.Is(err, errKeyNotFound) {
+		return nil, fmt.Errorf("looking up key: %w", err)
+	}
+
+	common := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+	}
+	if c.uri.Object != "" {
+		common = append(common, pkcs11.NewAttribute(pkcs11.CKA_LABEL, c.uri.Object))
+	}
+	if len(c.uri.ID) > 0 {
+		common = append(common, pkcs11.NewAttribute(pkcs11.CKA_ID, c.uri.ID))
+	}
+	public := append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+	}, common...)
+	private := append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+	}, common...)
+
+	var mech *pkcs11.Mechanism
+	switch algorithm {
+	case AlgorithmECDSAP256, AlgorithmECDSAP384, AlgorithmECDSAP521, AlgorithmED25519:
+		oid := map[string]asn1.ObjectIdentifier{
+			AlgorithmECDSAP256: oidNamedCurveP256,
+			AlgorithmECDSAP384: oidNamedCurveP384,
+			AlgorithmECDSAP521: oidNamedCurveP521,
+			AlgorithmED25519:   oidEd25519,
+		}[algorithm]
+		params, err := asn1.Marshal(oid)
+		if err != nil {
+			return nil, err
+		}
+		public = append(public, pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params))
+		if algorithm == AlgorithmED25519 {
+			mech = pkcs11.NewMechanism(ckmECEdwardsKeyPairGen, nil)
+		} else {
+			mech = pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)
+		}
+	case AlgorithmRSA2048, AlgorithmRSA3072, AlgorithmRSA4096:
+		bits := map[string]int{
+			AlgorithmRSA2048: 2048,
+			AlgorithmRSA3072: 3072,
+			AlgorithmRSA4096: 4096,
+		}[algorithm]
+		public = append(public,
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, bits),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{0x01, 0x00, 0x01}),
+		)
+		mech = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+
+	pubHandle, _, err := c.ctx.GenerateKeyPair(c.session.handle, []*pkcs11.Mechanism{mech}, public, private)
+	if err != nil {
+		return nil, fmt.Errorf("generating key pair: %w", err)
+	}
+	c.publicKey, err = c.readPublicKey(pubHandle)
+	if err != nil {
+		return nil, err
+	}
+	return c.publicKey, nil
+}
+
+// bytesToUint decodes a CK_ULONG attribute value, which is in native byte order.
+func bytesToUint(b []byte) uint {
+	switch len(b) {
+	case 4:
+		return uint(binary.NativeEndian.Uint32(b))
+	case 8:
+		return uint(binary.NativeEndian.Uint64(b))
+	default:
+		return ^uint(0)
+	}
+}
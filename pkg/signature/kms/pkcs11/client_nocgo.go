@@ -0,0 +1,50 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+// +build !cgo
+
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"errors"
+)
+
+// loading a PKCS#11 module requires dlopen, which is only available through cgo
+var errNoCGO = errors.New("pkcs11 support requires a binary built with cgo enabled")
+
+type pkcs11Client struct{}
+
+func newPKCS11Client(_ context.Context, _ *URI) (*pkcs11Client, error) {
+	return nil, errNoCGO
+}
+
+func (*pkcs11Client) public(_ context.Context) (crypto.PublicKey, error) {
+	return nil, errNoCGO
+}
+
+func (*pkcs11Client) sign(_ context.Context, _ []byte, _ crypto.Hash) ([]byte, error) {
+	return nil, errNoCGO
+}
+
+func (*pkcs11Client) verifyRemotely(_ context.Context, _, _ []byte, _ crypto.Hash) error {
+	return errNoCGO
+}
+
+func (*pkcs11Client) createKey(_ context.Context, _ string) (crypto.PublicKey, error) {
+	return nil, errNoCGO
+}
@@ -0,0 +1,137 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+)
+
+// fakeModule is a module with a single token labelled "sigstore" in slot 0,
+// which only supports opening sessions and logging in
+type fakeModule struct {
+	module
+	pin      string
+	sessions map[pkcs11.SessionHandle]bool
+	next     pkcs11.SessionHandle
+}
+
+func (f *fakeModule) GetSlotList(bool) ([]uint, error) {
+	return []uint{0}, nil
+}
+
+func (f *fakeModule) GetTokenInfo(uint) (pkcs11.TokenInfo, error) {
+	return pkcs11.TokenInfo{Label: "sigstore"}, nil
+}
+
+func (f *fakeModule) OpenSession(uint, uint) (pkcs11.SessionHandle, error) {
+	f.next++
+	f.sessions[f.next] = true
+	return f.next, nil
+}
+
+func (f *fakeModule) CloseSession(sh pkcs11.SessionHandle) error {
+	delete(f.sessions, sh)
+	return nil
+}
+
+func (f *fakeModule) Login(_ pkcs11.SessionHandle, _ uint, pin string) error {
+	if pin != f.pin {
+		return pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)
+	}
+	return nil
+}
+
+// withFakeModule registers a fake module under a module path for the duration of the test
+func withFakeModule(t *testing.T, path string) *fakeModule {
+	t.Helper()
+	f := &fakeModule{pin: "1234", sessions: map[pkcs11.SessionHandle]bool{}}
+	modulesMu.Lock()
+	modules[path] = f
+	modulesMu.Unlock()
+	t.Cleanup(func() {
+		modulesMu.Lock()
+		delete(modules, path)
+		modulesMu.Unlock()
+		sessionsMu.Lock()
+		for key := range sessions {
+			if key.module == path {
+				delete(sessions, key)
+			}
+		}
+		sessionsMu.Unlock()
+	})
+	return f
+}
+
+func TestSessionReuse(t *testing.T) {
+	f := withFakeModule(t, "fake-session-reuse")
+
+	for i := 0; i < 5; i++ {
+		if _, err := LoadSignerVerifier(context.Background(), "pkcs11:token=sigstore;object=release?module-path=fake-session-reuse&pin-value=1234", crypto.SHA256); err != nil {
+			t.Fatalf("unexpected error loading signer: %v", err)
+		}
+	}
+	if len(f.sessions) != 1 {
+		t.Fatalf("got %d open sessions after repeated loads, want 1", len(f.sessions))
+	}
+}
+
+func TestSessionClosedOnLoginError(t *testing.T) {
+	f := withFakeModule(t, "fake-login-error")
+
+	if _, err := LoadSignerVerifier(context.Background(), "pkcs11:token=sigstore;object=release?module-path=fake-login-error&pin-value=wrong", crypto.SHA256); err == nil {
+		t.Fatal("expected error logging in with the wrong PIN")
+	}
+	if len(f.sessions) != 0 {
+		t.Fatalf("got %d open sessions after a failed login, want 0", len(f.sessions))
+	}
+
+	// a failed login does not close a session shared with other clients
+	if _, err := LoadSignerVerifier(context.Background(), "pkcs11:token=sigstore;object=release?module-path=fake-login-error&pin-value=1234", crypto.SHA256); err != nil {
+		t.Fatalf("unexpected error loading signer: %v", err)
+	}
+	if _, err := LoadSignerVerifier(context.Background(), "pkcs11:token=sigstore;object=release?module-path=fake-login-error&pin-value=wrong", crypto.SHA256); err == nil {
+		t.Fatal("expected error logging in with the wrong PIN")
+	}
+	if len(f.sessions) != 1 {
+		t.Fatalf("got %d open sessions, want the shared session to remain open", len(f.sessions))
+	}
+}
+
+func TestKMSGet(t *testing.T) {
+	withFakeModule(t, "fake-kms-get")
+
+	for _, ref := range []string{
+		"pkcs11:token=sigstore;object=release?module-path=fake-kms-get&pin-value=1234",
+		"pkcs11://token=sigstore;object=release?module-path=fake-kms-get&pin-value=1234",
+	} {
+		sv, err := kms.Get(context.Background(), ref, crypto.SHA256)
+		if err != nil {
+			t.Fatalf("unexpected error getting %s: %v", ref, err)
+		}
+		if _, ok := sv.(*SignerVerifier); !ok {
+			t.Fatalf("got %T for %s, want *SignerVerifier", sv, ref)
+		}
+	}
+}
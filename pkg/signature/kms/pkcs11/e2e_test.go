@@ -0,0 +1,180 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+const (
+	softHSMTokenLabel = "sigstore"
+	softHSMPin        = "1234"
+)
+
+// SoftHSMSuite runs against a SoftHSM2 token created in a temporary directory.
+// The module path defaults to the Debian/Ubuntu location and can be overridden
+// with PKCS11_MODULE_PATH.
+type SoftHSMSuite struct {
+	suite.Suite
+}
+
+func (suite *SoftHSMSuite) SetupSuite() {
+	if os.Getenv(ModulePathEnv) == "" {
+		os.Setenv(ModulePathEnv, "/usr/lib/softhsm/libsofthsm2.so")
+	}
+
+	dir := suite.T().TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	require.NoError(suite.T(), os.Mkdir(tokenDir, 0o700))
+	conf := filepath.Join(dir, "softhsm2.conf")
+	require.NoError(suite.T(), os.WriteFile(conf, []byte("directories.tokendir = "+tokenDir+"\nobjectstore.backend = file\n"), 0o600))
+	os.Setenv("SOFTHSM2_CONF", conf)
+
+	out, err := exec.Command("softhsm2-util", "--init-token", "--free", "--label", softHSMTokenLabel, "--so-pin", softHSMPin, "--pin", softHSMPin).CombinedOutput()
+	require.NoError(suite.T(), err, string(out))
+}
+
+func (suite *SoftHSMSuite) GetProvider(object string, hf crypto.Hash) *SignerVerifier {
+	provider, err := LoadSignerVerifier(context.Background(), "pkcs11://token="+softHSMTokenLabel+";object="+object+"?pin-value="+softHSMPin, hf)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), provider)
+	return provider
+}
+
+func (suite *SoftHSMSuite) TestProvider() {
+	sv, err := kms.Get(context.Background(), "pkcs11://token="+softHSMTokenLabel+";object=provider?pin-value="+softHSMPin, crypto.SHA256)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), sv)
+}
+
+func (suite *SoftHSMSuite) TestCreateKey() {
+	provider := suite.GetProvider("createkey", crypto.SHA256)
+
+	key, err := provider.CreateKey(context.Background(), AlgorithmECDSAP256)
+	require.NoError(suite.T(), err)
+	assert.IsType(suite.T(), &ecdsa.PublicKey{}, key)
+
+	// creating the key again returns the existing key
+	again, err := provider.CreateKey(context.Background(), AlgorithmECDSAP256)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), key.(*ecdsa.PublicKey).Equal(again))
+
+	pub, err := suite.GetProvider("createkey", crypto.SHA256).PublicKey()
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), key.(*ecdsa.PublicKey).Equal(pub))
+}
+
+func (suite *SoftHSMSuite) TestSignVerify() {
+	tests := []struct {
+		algorithm string
+		hashFunc  crypto.Hash
+	}{
+		{AlgorithmECDSAP256, crypto.SHA256},
+		{AlgorithmECDSAP384, crypto.SHA384},
+		{AlgorithmECDSAP521, crypto.SHA512},
+		{AlgorithmRSA2048, crypto.SHA256},
+		{AlgorithmRSA3072, crypto.SHA384},
+		{AlgorithmED25519, crypto.Hash(0)},
+	}
+	for _, tt := range tests {
+		suite.Run(tt.algorithm, func() {
+			provider := suite.GetProvider("signverify-"+tt.algorithm, tt.hashFunc)
+			key, err := provider.CreateKey(context.Background(), tt.algorithm)
+			require.NoError(suite.T(), err)
+
+			data := []byte("mydata")
+			sig, err := provider.SignMessage(bytes.NewReader(data))
+			require.NoError(suite.T(), err)
+
+			verifier, err := signature.LoadVerifier(key, tt.hashFunc)
+			require.NoError(suite.T(), err)
+			assert.NoError(suite.T(), verifier.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+
+			assert.NoError(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data)))
+			assert.NoError(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader(data), options.WithRemoteVerification(true)))
+
+			assert.Error(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("otherdata"))))
+			assert.Error(suite.T(), provider.VerifySignature(bytes.NewReader(sig), bytes.NewReader([]byte("otherdata")), options.WithRemoteVerification(true)))
+		})
+	}
+}
+
+func (suite *SoftHSMSuite) TestCryptoSigner() {
+	provider := suite.GetProvider("cryptosigner", crypto.SHA256)
+	_, err := provider.CreateKey(context.Background(), AlgorithmRSA2048)
+	require.NoError(suite.T(), err)
+
+	cs, opts, err := provider.CryptoSigner(context.Background(), func(err error) { require.NoError(suite.T(), err) })
+	require.NoError(suite.T(), err)
+	assert.IsType(suite.T(), &rsa.PublicKey{}, cs.Public())
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pkcs11"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(nil, tmpl, tmpl, cs.Public(), cs)
+	require.NoError(suite.T(), err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(suite.T(), err)
+	assert.NoError(suite.T(), cert.CheckSignatureFrom(cert))
+	assert.Equal(suite.T(), crypto.SHA256, opts.HashFunc())
+}
+
+func (suite *SoftHSMSuite) TestEd25519RequiresNoHash() {
+	provider := suite.GetProvider("ed25519-hash", crypto.SHA256)
+	key, err := provider.CreateKey(context.Background(), AlgorithmED25519)
+	require.NoError(suite.T(), err)
+	assert.IsType(suite.T(), ed25519.PublicKey{}, key)
+
+	_, err = provider.SignMessage(bytes.NewReader([]byte("mydata")))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *SoftHSMSuite) TestNoKey() {
+	provider := suite.GetProvider("nokey", crypto.SHA256)
+	_, err := provider.PublicKey()
+	assert.ErrorIs(suite.T(), err, errKeyNotFound)
+}
+
+func TestSoftHSM(t *testing.T) {
+	suite.Run(t, new(SoftHSMSuite))
+}
@@ -0,0 +1,284 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	sigkms "github.com/sigstore/sigstore/pkg/signature/kms"
+	"github.com/sigstore/sigstore/pkg/signature/options"
+)
+
+func init() {
+	// RFC 7512 references have no "://", so they are matched by the "pkcs11:" prefix
+	for _, scheme := range []string{ReferenceScheme, uriScheme} {
+		if err := sigkms.AddProvider(scheme, func(ctx context.Context, keyResourceID string, hashFunc crypto.Hash, opts ...signature.RPCOption) (sigkms.SignerVerifier, error) {
+			return LoadSignerVerifier(ctx, keyResourceID, hashFunc, opts...)
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Algorithm names accepted by CreateKey in addition to the canonical IDs in cryptoutils
+//
+// nolint:revive
+const (
+	AlgorithmECDSAP256 = "ecdsa-p256"
+	AlgorithmECDSAP384 = "ecdsa-p384"
+	AlgorithmECDSAP521 = "ecdsa-p521"
+	AlgorithmED25519   = "ed25519"
+	AlgorithmRSA2048   = "rsa-2048"
+	AlgorithmRSA3072   = "rsa-3072"
+	AlgorithmRSA4096   = "rsa-4096"
+)
+
+// pkcs11SupportedAlgorithms are the canonical IDs of the algorithms of keys that can be generated
+var pkcs11SupportedAlgorithms = []string{
+	cryptoutils.AlgorithmECDSAP256SHA256,
+	cryptoutils.AlgorithmECDSAP384SHA384,
+	cryptoutils.AlgorithmECDSAP521SHA512,
+	cryptoutils.AlgorithmED25519,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256,
+}
+
+// pkcs11KeyAlgorithms maps the canonical IDs of the supported algorithms to the names used by the client
+var pkcs11KeyAlgorithms = map[string]string{
+	cryptoutils.AlgorithmECDSAP256SHA256:       AlgorithmECDSAP256,
+	cryptoutils.AlgorithmECDSAP384SHA384:       AlgorithmECDSAP384,
+	cryptoutils.AlgorithmECDSAP521SHA512:       AlgorithmECDSAP521,
+	cryptoutils.AlgorithmED25519:               AlgorithmED25519,
+	cryptoutils.AlgorithmRSAPKCS1v152048SHA256: AlgorithmRSA2048,
+	cryptoutils.AlgorithmRSAPKCS1v153072SHA256: AlgorithmRSA3072,
+	cryptoutils.AlgorithmRSAPKCS1v154096SHA256: AlgorithmRSA4096,
+}
+
+var pkcs11SupportedHashFuncs = []crypto.Hash{
+	crypto.SHA224,
+	crypto.SHA256,
+	crypto.SHA384,
+	crypto.SHA512,
+	crypto.Hash(0),
+}
+
+// SignerVerifier creates and verifies digital signatures over a message using a key stored in a PKCS#11 token
+type SignerVerifier struct {
+	hashFunc crypto.Hash
+	client   *pkcs11Client
+}
+
+// LoadSignerVerifier generates signatures using the key identified by the PKCS#11 URI
+// referenceStr and hash algorithm.
+//
+// The PKCS#11 module is loaded from the module-path attribute of the reference, from the
+// module named by the module-name attribute in the standard module directories, or from
+// the path in the PKCS11_MODULE_PATH environment variable. The token PIN is taken from the
+// pin-value or pin-source attribute, or from the PKCS11_PIN environment variable.
+// hashFunc should be set to crypto.Hash(0) if the key is an ED25519 signing key.
+func LoadSignerVerifier(ctx context.Context, referenceStr string, hashFunc crypto.Hash, opts ...signature.RPCOption) (*SignerVerifier, error) {
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+
+	uri, err := ParseReference(referenceStr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch hashFunc {
+	case 0, crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512:
+	default:
+		return nil, errors.New("hash function not supported by PKCS#11")
+	}
+
+	p := &SignerVerifier{
+		hashFunc: hashFunc,
+	}
+	p.client, err = newPKCS11Client(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SignMessage signs the provided message using the PKCS#11 token. If the message is provided,
+// this method will compute the digest according to the hash function specified
+// when the SignerVerifier was created.
+//
+// SignMessage recognizes the following Options listed in order of preference:
+//
+// - WithContext()
+//
+// - WithDigest()
+//
+// - WithCryptoSignerOpts()
+//
+// All other options are ignored if specified.
+func (p *SignerVerifier) SignMessage(message io.Reader, opts ...signature.SignOption) ([]byte, error) {
+	ctx := context.Background()
+	var signerOpts crypto.SignerOpts = p.hashFunc
+
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+		opt.ApplyCryptoSignerOpts(&signerOpts)
+	}
+
+	digest, hf, err := signature.ComputeDigestForSigning(message, signerOpts.HashFunc(), pkcs11SupportedHashFuncs, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.client.sign(ctx, digest, hf)
+}
+
+// PublicKey returns the public key that can be used to verify signatures created by
+// this signer. If the caller wishes to specify the context to use to obtain
+// the public key, pass option.WithContext(desiredCtx).
+//
+// All other options are ignored if specified.
+func (p *SignerVerifier) PublicKey(opts ...signature.PublicKeyOption) (crypto.PublicKey, error) {
+	ctx := context.Background()
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+	}
+
+	return p.client.public(ctx)
+}
+
+// VerifySignature verifies the signature for the given message. Unless provided
+// in an option, the digest of the message will be computed using the hash function specified
+// when the SignerVerifier was created.
+//
+// This function returns nil if the verification succeeded, and an error message otherwise.
+//
+// This function recognizes the following Options listed in order of preference:
+//
+// - WithContext()
+//
+// - WithDigest()
+//
+// - WithRemoteVerification()
+//
+// - WithCryptoSignerOpts()
+//
+// All other options are ignored if specified.
+func (p *SignerVerifier) VerifySignature(sig, message io.Reader, opts ...signature.VerifyOption) error {
+	ctx := context.Background()
+	var remoteVerification bool
+	var signerOpts crypto.SignerOpts = p.hashFunc
+
+	for _, opt := range opts {
+		opt.ApplyContext(&ctx)
+		opt.ApplyRemoteVerification(&remoteVerification)
+		opt.ApplyCryptoSignerOpts(&signerOpts)
+	}
+
+	if !remoteVerification {
+		pub, err := p.client.public(ctx)
+		if err != nil {
+			return err
+		}
+		verifier, err := signature.LoadVerifier(pub, signerOpts.HashFunc())
+		if err != nil {
+			return err
+		}
+		return verifier.VerifySignature(sig, message, opts...)
+	}
+
+	digest, hf, err := signature.ComputeDigestForVerifying(message, signerOpts.HashFunc(), pkcs11SupportedHashFuncs, opts...)
+	if err != nil {
+		return err
+	}
+
+	sigBytes, err := io.ReadAll(sig)
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+
+	return p.client.verifyRemotely(ctx, sigBytes, digest, hf)
+}
+
+// CreateKey generates a new key pair on the token with the specified algorithm, labelled
+// with the object and id attributes of the reference. If a matching key already exists,
+// its public key is returned instead. The algorithm is one of SupportedAlgorithms or one of the
+// Algorithm constants of this package.
+func (p *SignerVerifier) CreateKey(ctx context.Context, algorithm string) (crypto.PublicKey, error) {
+	if name, ok := pkcs11KeyAlgorithms[algorithm]; ok {
+		algorithm = name
+	}
+	return p.client.createKey(ctx, algorithm)
+}
+
+type cryptoSignerWrapper struct {
+	ctx      context.Context
+	hashFunc crypto.Hash
+	sv       *SignerVerifier
+	errFunc  func(error)
+}
+
+func (c cryptoSignerWrapper) Public() crypto.PublicKey {
+	pk, err := c.sv.PublicKey(options.WithContext(c.ctx))
+	if err != nil && c.errFunc != nil {
+		c.errFunc(err)
+	}
+	return pk
+}
+
+func (c cryptoSignerWrapper) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hashFunc := c.hashFunc
+	if opts != nil {
+		hashFunc = opts.HashFunc()
+	}
+	pkcs11Options := []signature.SignOption{
+		options.WithContext(c.ctx),
+		options.WithDigest(digest),
+		options.WithCryptoSignerOpts(hashFunc),
+	}
+
+	return c.sv.SignMessage(nil, pkcs11Options...)
+}
+
+// CryptoSigner returns a crypto.Signer object that uses the underlying SignerVerifier, along with a crypto.SignerOpts object
+// that allows the KMS to be used in APIs that only accept the standard golang objects
+func (p *SignerVerifier) CryptoSigner(ctx context.Context, errFunc func(error)) (crypto.Signer, crypto.SignerOpts, error) {
+	csw := &cryptoSignerWrapper{
+		ctx:      ctx,
+		sv:       p,
+		hashFunc: p.hashFunc,
+		errFunc:  errFunc,
+	}
+
+	return csw, p.hashFunc, nil
+}
+
+// SupportedAlgorithms returns the canonical IDs of the key algorithms that can be generated on a PKCS#11 token
+func (*SignerVerifier) SupportedAlgorithms() []string {
+	return slices.Clone(pkcs11SupportedAlgorithms)
+}
+
+// DefaultAlgorithm returns the canonical ID of the default algorithm for PKCS#11 tokens
+func (*SignerVerifier) DefaultAlgorithm() string {
+	return cryptoutils.AlgorithmECDSAP256SHA256
+}
@@ -0,0 +1,247 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ReferenceScheme is the scheme under which this provider is registered with the kms package.
+	ReferenceScheme = "pkcs11://"
+
+	// uriScheme is the scheme defined in RFC 7512; "pkcs11://" references are accepted as an alias
+	uriScheme = "pkcs11:"
+
+	// ModulePathEnv names the environment variable consulted when a reference does not specify module-path
+	ModulePathEnv = "PKCS11_MODULE_PATH"
+	// PinEnv names the environment variable consulted when a reference does not specify pin-value or pin-source
+	PinEnv = "PKCS11_PIN"
+)
+
+// moduleDirs are searched in order for the module named by the module-name attribute
+var moduleDirs = []string{
+	"/usr/local/lib/pkcs11",
+	"/usr/lib64/pkcs11",
+	"/usr/lib/x86_64-linux-gnu/pkcs11",
+	"/usr/lib/aarch64-linux-gnu/pkcs11",
+	"/usr/lib/pkcs11",
+	"/usr/local/lib",
+	"/usr/lib64",
+	"/usr/lib/x86_64-linux-gnu",
+	"/usr/lib/aarch64-linux-gnu",
+	"/usr/lib",
+}
+
+var errReference = errors.New("kms specification should be in the format pkcs11:[path-attributes][?query-attributes] (RFC 7512)")
+
+// URI holds the attributes of an RFC 7512 PKCS#11 URI that are used to select a key.
+type URI struct {
+	// path attributes
+	Token        string
+	Manufacturer string
+	Serial       string
+	Model        string
+	SlotID       *uint
+	Object       string
+	ID           []byte
+	// Type is validated but does not affect key selection, as the public
+	// and private key objects of a key pair are each looked up when needed
+	Type string
+
+	// query attributes
+	ModulePath string
+	ModuleName string
+	PinValue   string
+	PinSource  string
+}
+
+// ValidReference returns a non-nil error if the reference string is invalid
+func ValidReference(ref string) error {
+	if _, err := ParseReference(ref); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseReference parses a PKCS#11 URI as described in RFC 7512. Both the
+// "pkcs11:" form defined by the RFC and the "pkcs11://" form used for KMS
+// references are accepted.
+func ParseReference(ref string) (*URI, error) {
+	if !strings.HasPrefix(ref, uriScheme) {
+		return nil, errReference
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(ref, uriScheme), "//")
+
+	path, query, _ := strings.Cut(rest, "?")
+	u := &URI{}
+	seen := map[string]bool{}
+
+	if path != "" {
+		for _, attr := range strings.Split(path, ";") {
+			name, value, err := parseAttribute(attr, seen)
+			if err != nil {
+				return nil, err
+			}
+			if err := u.setPathAttribute(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if query != "" {
+		for _, attr := range strings.Split(query, "&") {
+			name, value, err := parseAttribute(attr, seen)
+			if err != nil {
+				return nil, err
+			}
+			u.setQueryAttribute(name, value)
+		}
+	}
+
+	if u.Object == "" && len(u.ID) == 0 {
+		return nil, fmt.Errorf("%w: object or id attribute is required", errReference)
+	}
+	if u.PinValue != "" && u.PinSource != "" {
+		return nil, fmt.Errorf("%w: pin-value and pin-source are mutually exclusive", errReference)
+	}
+	switch u.Type {
+	case "", "private", "public":
+	default:
+		return nil, fmt.Errorf("%w: unsupported object type %q", errReference, u.Type)
+	}
+	return u, nil
+}
+
+func parseAttribute(attr string, seen map[string]bool) (string, string, error) {
+	name, rawValue, ok := strings.Cut(attr, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("%w: malformed attribute %q", errReference, attr)
+	}
+	if seen[name] {
+		return "", "", fmt.Errorf("%w: duplicate attribute %q", errReference, name)
+	}
+	seen[name] = true
+	value, err := url.PathUnescape(rawValue)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: decoding attribute %q: %v", errReference, name, err)
+	}
+	return name, value, nil
+}
+
+func (u *URI) setPathAttribute(name, value string) error {
+	switch name {
+	case "token":
+		u.Token = value
+	case "manufacturer":
+		u.Manufacturer = value
+	case "serial":
+		u.Serial = value
+	case "model":
+		u.Model = value
+	case "slot-id":
+		id, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return fmt.Errorf("%w: parsing slot-id: %v", errReference, err)
+		}
+		slot := uint(id)
+		u.SlotID = &slot
+	case "object":
+		u.Object = value
+	case "id":
+		u.ID = []byte(value)
+	case "type":
+		u.Type = value
+	}
+	// library-*, slot-description, slot-manufacturer and vendor specific
+	// attributes do not affect key selection and are ignored
+	return nil
+}
+
+func (u *URI) setQueryAttribute(name, value string) {
+	switch name {
+	case "module-path":
+		u.ModulePath = value
+	case "module-name":
+		u.ModuleName = value
+	case "pin-value":
+		u.PinValue = value
+	case "pin-source":
+		u.PinSource = value
+	}
+}
+
+// modulePath returns the path of the PKCS#11 module that should be loaded for this URI.
+func (u *URI) modulePath() (string, error) {
+	if u.ModulePath != "" {
+		return u.ModulePath, nil
+	}
+	if u.ModuleName != "" {
+		return findModule(u.ModuleName)
+	}
+	if p := os.Getenv(ModulePathEnv); p != "" {
+		return p, nil
+	}
+	return "", fmt.Errorf("no PKCS#11 module specified: set module-path or module-name in the reference or %s", ModulePathEnv)
+}
+
+// findModule returns the path of the module library with the given name, which
+// excludes the platform specific prefix and suffix, in moduleDirs.
+func findModule(name string) (string, error) {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("%w: module-name %q must not be a path, use module-path instead", errReference, name)
+	}
+	var files []string
+	switch runtime.GOOS {
+	case "windows":
+		files = []string{name + ".dll"}
+	case "darwin":
+		files = []string{"lib" + name + ".dylib", name + ".dylib"}
+	default:
+		files = []string{"lib" + name + ".so", name + ".so"}
+	}
+	for _, dir := range moduleDirs {
+		for _, file := range files {
+			path := filepath.Join(dir, file)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("PKCS#11 module %q not found in %s", name, strings.Join(moduleDirs, ", "))
+}
+
+// pin returns the user PIN for the token, or an empty string if none was provided.
+func (u *URI) pin() (string, error) {
+	if u.PinValue != "" {
+		return u.PinValue, nil
+	}
+	if u.PinSource != "" {
+		path := strings.TrimPrefix(u.PinSource, "file:")
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading pin-source: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return os.Getenv(PinEnv), nil
+}
@@ -0,0 +1,191 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func uintPtr(u uint) *uint {
+	return &u
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *URI
+		wantErr bool
+	}{
+		{
+			in:   "pkcs11:token=sigstore;object=release",
+			want: &URI{Token: "sigstore", Object: "release"},
+		},
+		{
+			in:   "pkcs11://token=sigstore;object=release",
+			want: &URI{Token: "sigstore", Object: "release"},
+		},
+		{
+			// RFC 7512 section 7, percent-encoded id
+			in:   "pkcs11:token=The%20Software%20PKCS%2311%20Softtoken;manufacturer=Snake%20Oil,%20Inc.;model=1.0;object=my-certificate;type=private;id=%69%95%3E%5C%F4%BD%EC%91",
+			want: &URI{Token: "The Software PKCS#11 Softtoken", Manufacturer: "Snake Oil, Inc.", Model: "1.0", Object: "my-certificate", Type: "private", ID: []byte{0x69, 0x95, 0x3e, 0x5c, 0xf4, 0xbd, 0xec, 0x91}},
+		},
+		{
+			in:   "pkcs11:slot-id=2;serial=abc123;id=%01?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234",
+			want: &URI{SlotID: uintPtr(2), Serial: "abc123", ID: []byte{0x01}, ModulePath: "/usr/lib/softhsm/libsofthsm2.so", PinValue: "1234"},
+		},
+		{
+			in:   "pkcs11:object=release;x-vendor=ignored;library-version=3?pin-source=file:/run/pin",
+			want: &URI{Object: "release", PinSource: "file:/run/pin"},
+		},
+		{
+			// no key selector
+			in:      "pkcs11:token=sigstore",
+			wantErr: true,
+		},
+		{
+			in:      "pkcs11:object=a;object=b",
+			wantErr: true,
+		},
+		{
+			in:      "pkcs11:object=release;slot-id=one",
+			wantErr: true,
+		},
+		{
+			in:      "pkcs11:object=release;type=secret-key",
+			wantErr: true,
+		},
+		{
+			in:      "pkcs11:object=release?pin-value=1234&pin-source=/run/pin",
+			wantErr: true,
+		},
+		{
+			in:      "pkcs11:object=%zz",
+			wantErr: true,
+		},
+		{
+			in:      "pkcs11:object",
+			wantErr: true,
+		},
+		{
+			in:      "hashivault://release",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReference(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+			if err := ValidReference(tt.in); (err != nil) != tt.wantErr {
+				t.Errorf("ValidReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModulePathAndPin(t *testing.T) {
+	t.Setenv(ModulePathEnv, "/env/module.so")
+	t.Setenv(PinEnv, "env-pin")
+
+	u, err := ParseReference("pkcs11:object=release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := u.modulePath(); path != "/env/module.so" {
+		t.Errorf("modulePath() = %q, want value from environment", path)
+	}
+	if pin, _ := u.pin(); pin != "env-pin" {
+		t.Errorf("pin() = %q, want value from environment", pin)
+	}
+
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	if err := os.WriteFile(pinFile, []byte("file-pin\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	u, err = ParseReference("pkcs11:object=release?module-path=/uri/module.so&pin-source=file:" + pinFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := u.modulePath(); path != "/uri/module.so" {
+		t.Errorf("modulePath() = %q, want value from reference", path)
+	}
+	if pin, _ := u.pin(); pin != "file-pin" {
+		t.Errorf("pin() = %q, want value from pin-source", pin)
+	}
+
+	t.Setenv(ModulePathEnv, "")
+	u, err = ParseReference("pkcs11:object=release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.modulePath(); err == nil {
+		t.Error("modulePath() expected error when no module is configured")
+	}
+}
+
+func TestModuleName(t *testing.T) {
+	t.Setenv(ModulePathEnv, "/env/module.so")
+	dir := t.TempDir()
+	orig := moduleDirs
+	moduleDirs = []string{filepath.Join(dir, "missing"), dir}
+	t.Cleanup(func() { moduleDirs = orig })
+
+	file := "libsofthsm2.so"
+	switch runtime.GOOS {
+	case "windows":
+		file = "softhsm2.dll"
+	case "darwin":
+		file = "libsofthsm2.dylib"
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := ParseReference("pkcs11:object=release?module-name=softhsm2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, err := u.modulePath(); err != nil || path != filepath.Join(dir, file) {
+		t.Errorf("modulePath() = %q, %v, want module from module-name", path, err)
+	}
+
+	// module-path takes precedence
+	u, err = ParseReference("pkcs11:object=release?module-name=softhsm2&module-path=/uri/module.so")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := u.modulePath(); path != "/uri/module.so" {
+		t.Errorf("modulePath() = %q, want value of module-path", path)
+	}
+
+	for _, name := range []string{"missing", "../softhsm2", ".."} {
+		u, err := ParseReference("pkcs11:object=release?module-name=" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path, err := u.modulePath(); err == nil {
+			t.Errorf("modulePath() = %q, expected error for module-name %q", path, name)
+		}
+	}
+}
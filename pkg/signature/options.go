@@ -21,6 +21,7 @@ import (
 	"crypto/rsa"
 	"io"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
@@ -30,6 +31,7 @@ type RPCOption interface {
 	ApplyRemoteVerification(*bool)
 	ApplyRPCAuthOpts(opts *options.RPCAuth)
 	ApplyKeyVersion(keyVersion *string)
+	ApplyPSS(opts **rsa.PSSOptions)
 }
 
 // PublicKeyOption specifies options to be used when obtaining a public key
@@ -62,4 +64,5 @@ type LoadOption interface {
 	ApplyHash(*crypto.Hash)
 	ApplyED25519ph(*bool)
 	ApplyRSAPSS(**rsa.PSSOptions)
+	ApplyKeyPolicy(**cryptoutils.KeyPolicy)
 }
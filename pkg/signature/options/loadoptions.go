@@ -18,6 +18,8 @@ package options
 import (
 	"crypto"
 	"crypto/rsa"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
 // RequestHash implements the functional option pattern for setting a Hash
@@ -74,3 +76,22 @@ func (r RequestPSSOptions) ApplyRSAPSS(opts **rsa.PSSOptions) {
 func WithRSAPSS(opts *rsa.PSSOptions) RequestPSSOptions {
 	return RequestPSSOptions{opts: opts}
 }
+
+// RequestKeyPolicy implements the functional option pattern for specifying a
+// policy that the key must satisfy when loading a signer or verifier
+type RequestKeyPolicy struct {
+	NoOpOptionImpl
+	policy *cryptoutils.KeyPolicy
+}
+
+// ApplyKeyPolicy sets the key policy as requested by the functional option
+func (r RequestKeyPolicy) ApplyKeyPolicy(policy **cryptoutils.KeyPolicy) {
+	*policy = r.policy
+}
+
+// WithKeyPolicy specifies that the public key must be accepted by cryptoutils.ValidatePubKeyWithPolicy
+// with the given policy when loading a signer or verifier. Use cryptoutils.DefaultKeyPolicy() to
+// enforce the checks made by cryptoutils.ValidatePubKey.
+func WithKeyPolicy(policy *cryptoutils.KeyPolicy) RequestKeyPolicy {
+	return RequestKeyPolicy{policy: policy}
+}
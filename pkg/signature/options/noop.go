@@ -20,6 +20,8 @@ import (
 	"crypto"
 	"crypto/rsa"
 	"io"
+
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 )
 
 // NoOpOptionImpl implements the RPCOption, SignOption, VerifyOption interfaces as no-ops.
@@ -46,6 +48,9 @@ func (NoOpOptionImpl) ApplyRPCAuthOpts(_ *RPCAuth) {}
 // ApplyKeyVersion is a no-op required to fully implement the requisite interfaces
 func (NoOpOptionImpl) ApplyKeyVersion(_ *string) {}
 
+// ApplyPSS is a no-op required to fully implement the requisite interfaces
+func (NoOpOptionImpl) ApplyPSS(_ **rsa.PSSOptions) {}
+
 // ApplyKeyVersionUsed is a no-op required to fully implement the requisite interfaces
 func (NoOpOptionImpl) ApplyKeyVersionUsed(_ **string) {}
 
@@ -57,3 +62,6 @@ func (NoOpOptionImpl) ApplyED25519ph(_ *bool) {}
 
 // ApplyRSAPSS is a no-op required to fully implement the requisite interfaces
 func (NoOpOptionImpl) ApplyRSAPSS(_ **rsa.PSSOptions) {}
+
+// ApplyKeyPolicy is a no-op required to fully implement the requisite interfaces
+func (NoOpOptionImpl) ApplyKeyPolicy(_ **cryptoutils.KeyPolicy) {}
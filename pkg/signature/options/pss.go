@@ -0,0 +1,36 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import "crypto/rsa"
+
+// RequestPSS implements the functional option pattern for specifying that a KMS should sign and verify
+// with RSA-PSS when the key is a RSA key
+type RequestPSS struct {
+	NoOpOptionImpl
+	opts *rsa.PSSOptions
+}
+
+// ApplyPSS sets the RSA-PSS options as a functional option
+func (r RequestPSS) ApplyPSS(opts **rsa.PSSOptions) {
+	*opts = r.opts
+}
+
+// WithPSS specifies that a KMS should sign and verify with RSA-PSS using the salt length of opts. The hash
+// function of opts, if set, must match the hash function the KMS signer is loaded with.
+func WithPSS(opts *rsa.PSSOptions) RequestPSS {
+	return RequestPSS{opts: opts}
+}
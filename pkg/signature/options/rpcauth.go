@@ -27,6 +27,12 @@ type RPCAuth struct {
 	Path    string // path for the RPC, in vault this is the transit path which default to "transit"
 	Token   string // token used for RPC, in vault this is the VAULT_TOKEN value
 	OIDC    RPCAuthOIDC
+
+	// AppRole, Kubernetes and TLSCert log in with the corresponding vault auth method
+	// instead of a fixed token; at most one login method may be set
+	AppRole    RPCAuthAppRole
+	Kubernetes RPCAuthKubernetes
+	TLSCert    RPCAuthTLSCert
 }
 
 // RPCAuthOIDC is used to perform the RPC login using OIDC instead of a fixed token
@@ -36,6 +42,29 @@ type RPCAuthOIDC struct {
 	Token string // token is a jwt with vault
 }
 
+// RPCAuthAppRole is used to perform the RPC login using a vault AppRole
+type RPCAuthAppRole struct {
+	Path     string // path defaults to "approle" for vault
+	RoleID   string // role ID is required for approle logins
+	SecretID string // secret ID is required unless the role does not bind a secret ID
+}
+
+// RPCAuthKubernetes is used to perform the RPC login using a Kubernetes service account token
+type RPCAuthKubernetes struct {
+	Path      string // path defaults to "kubernetes" for vault
+	Role      string // role is required for kubernetes logins
+	TokenPath string // token path defaults to "/var/run/secrets/kubernetes.io/serviceaccount/token"
+}
+
+// RPCAuthTLSCert is used to perform the RPC login using a TLS client certificate
+type RPCAuthTLSCert struct {
+	Path       string // path defaults to "cert" for vault
+	Name       string // name of the certificate role, optional for vault
+	CertFile   string // cert file is the path to the PEM-encoded client certificate
+	KeyFile    string // key file is the path to the PEM-encoded client private key
+	CACertFile string // CA cert file is the path to the PEM-encoded CA used to verify the server, optional
+}
+
 // ApplyRPCAuthOpts sets the RPCAuth as a function option
 func (r RPCAuthOpts) ApplyRPCAuthOpts(opts *RPCAuth) {
 	if r.opts.Address != "" {
@@ -50,6 +79,15 @@ func (r RPCAuthOpts) ApplyRPCAuthOpts(opts *RPCAuth) {
 	if r.opts.OIDC.Token != "" {
 		opts.OIDC = r.opts.OIDC
 	}
+	if r.opts.AppRole.RoleID != "" {
+		opts.AppRole = r.opts.AppRole
+	}
+	if r.opts.Kubernetes.Role != "" {
+		opts.Kubernetes = r.opts.Kubernetes
+	}
+	if r.opts.TLSCert.CertFile != "" {
+		opts.TLSCert = r.opts.TLSCert
+	}
 }
 
 // WithRPCAuthOpts specifies RPCAuth settings to be used with RPC logins
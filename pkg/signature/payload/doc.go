@@ -13,5 +13,6 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package payload contains types and utilities related to the Cosign signature format.
+// Package payload contains types and utilities related to the Cosign signature format
+// and in-toto attestation statements.
 package payload
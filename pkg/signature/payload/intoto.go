@@ -0,0 +1,257 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payload
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// InTotoPayloadType is the DSSE payload type of in-toto statements, to be used with dsse.WrapSigner
+	InTotoPayloadType = "application/vnd.in-toto+json"
+	// InTotoStatementTypeV1 is the value of `_type` in an in-toto v1 statement
+	InTotoStatementTypeV1 = "https://in-toto.io/Statement/v1"
+)
+
+// digestLengths maps the digest algorithms defined by in-toto to the length of their hex encoded values
+// See https://github.com/in-toto/attestation/blob/main/spec/v1/digest_set.md
+var digestLengths = map[string][]int{
+	"sha256":     {64},
+	"sha224":     {56},
+	"sha384":     {96},
+	"sha512":     {128},
+	"sha512_224": {56},
+	"sha512_256": {64},
+	"sha3_224":   {56},
+	"sha3_256":   {64},
+	"sha3_384":   {96},
+	"sha3_512":   {128},
+	"shake128":   {64},
+	"shake256":   {128},
+	"blake2b":    {128},
+	"blake2s":    {64},
+	"ripemd160":  {40},
+	"sm3":        {64},
+	"gost":       {64},
+	"sha1":       {40},
+	"md5":        {32},
+	"gitCommit":  {40, 64},
+	"gitTree":    {40, 64},
+	"gitBlob":    {40, 64},
+	"gitTag":     {40, 64},
+}
+
+// ResourceDescriptor describes a software artifact or resource, as defined at:
+// https://github.com/in-toto/attestation/blob/main/spec/v1/resource_descriptor.md
+type ResourceDescriptor struct {
+	Name             string                 `json:"name,omitempty"`
+	URI              string                 `json:"uri,omitempty"`
+	Digest           map[string]string      `json:"digest,omitempty"`
+	Content          []byte                 `json:"content,omitempty"`
+	DownloadLocation string                 `json:"downloadLocation,omitempty"`
+	MediaType        string                 `json:"mediaType,omitempty"`
+	Annotations      map[string]interface{} `json:"annotations,omitempty"`
+}
+
+// Validate returns an error if the descriptor identifies no resource or contains a malformed digest.
+// Values of digest algorithms defined by in-toto must be lowercase hex of the correct length;
+// values of other algorithms must not be empty.
+func (r ResourceDescriptor) Validate() error {
+	if r.URI == "" && len(r.Digest) == 0 && len(r.Content) == 0 {
+		return errors.New("resource descriptor must contain at least one of uri, digest or content")
+	}
+	for alg, value := range r.Digest {
+		if err := validateDigest(alg, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDigest(alg, value string) error {
+	if alg == "" {
+		return errors.New("digest algorithm must not be empty")
+	}
+	if value == "" {
+		return fmt.Errorf("%s digest must not be empty", alg)
+	}
+	lengths, ok := digestLengths[alg]
+	if !ok {
+		return nil
+	}
+	if _, err := hex.DecodeString(value); err != nil || strings.ToLower(value) != value {
+		return fmt.Errorf("%s digest %q is not lowercase hex", alg, value)
+	}
+	for _, l := range lengths {
+		if len(value) == l {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s digest %q has invalid length %d", alg, value, len(value))
+}
+
+// MatchesDigest reports whether the descriptor's digest has at least one algorithm in common with
+// digest, and the values of all algorithms in common are equal. Hex values are compared case-insensitively.
+func (r ResourceDescriptor) MatchesDigest(digest map[string]string) bool {
+	matched := false
+	for alg, value := range digest {
+		own, ok := r.Digest[alg]
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(own, value) {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+// Statement is an in-toto v1 statement, as defined at:
+// https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     json.RawMessage      `json:"predicate,omitempty"`
+}
+
+// NewStatement returns a validated statement about subjects, with predicate marshaled to JSON
+func NewStatement(predicateType string, predicate interface{}, subjects ...ResourceDescriptor) (*Statement, error) {
+	s := &Statement{
+		Type:          InTotoStatementTypeV1,
+		Subject:       subjects,
+		PredicateType: predicateType,
+	}
+	if predicate != nil {
+		b, err := json.Marshal(predicate)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling predicate: %w", err)
+		}
+		s.Predicate = b
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseStatement unmarshals and validates an in-toto v1 statement, such as the payload of a
+// verified DSSE envelope with the InTotoPayloadType payload type
+func ParseStatement(data []byte) (*Statement, error) {
+	s := &Statement{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate returns an error if the statement is not a well-formed in-toto v1 statement. Every subject
+// must have a valid, non-empty digest.
+func (s *Statement) Validate() error {
+	if s.Type != InTotoStatementTypeV1 {
+		return fmt.Errorf("in-toto statement was of an unknown type: %q", s.Type)
+	}
+	if s.PredicateType == "" {
+		return errors.New("in-toto statement has no predicate type")
+	}
+	if len(s.Subject) == 0 {
+		return errors.New("in-toto statement has no subjects")
+	}
+	for i, subject := range s.Subject {
+		if len(subject.Digest) == 0 {
+			return fmt.Errorf("subject %d has no digest", i)
+		}
+		if err := subject.Validate(); err != nil {
+			return fmt.Errorf("subject %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// MatchSubject returns the first subject whose digest matches digest, as defined by
+// ResourceDescriptor.MatchesDigest
+func (s *Statement) MatchSubject(digest map[string]string) (*ResourceDescriptor, error) {
+	for i := range s.Subject {
+		if s.Subject[i].MatchesDigest(digest) {
+			return &s.Subject[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no subject matches digest %v", digest)
+}
+
+// DecodePredicate unmarshals the predicate into a new value of the type registered for the
+// statement's predicate type with RegisterPredicateType, and returns it
+func (s *Statement) DecodePredicate() (interface{}, error) {
+	predicatesMu.RLock()
+	newPredicate, ok := predicatesMap[s.PredicateType]
+	predicatesMu.RUnlock()
+	if !ok {
+		return nil, &UnknownPredicateTypeError{predicateType: s.PredicateType}
+	}
+	p := newPredicate()
+	if len(s.Predicate) == 0 {
+		return p, nil
+	}
+	if err := json.Unmarshal(s.Predicate, p); err != nil {
+		return nil, fmt.Errorf("unmarshaling %s predicate: %w", s.PredicateType, err)
+	}
+	return p, nil
+}
+
+// UnknownPredicateTypeError indicates that no type was registered for a predicate type
+type UnknownPredicateTypeError struct {
+	predicateType string
+}
+
+func (e *UnknownPredicateTypeError) Error() string {
+	return fmt.Sprintf("no predicate registered for type: %s", e.predicateType)
+}
+
+// DuplicatePredicateTypeError indicates that a type is already registered for a predicate type
+type DuplicatePredicateTypeError struct {
+	predicateType string
+}
+
+func (e *DuplicatePredicateTypeError) Error() string {
+	return fmt.Sprintf("predicate already registered for type: %s", e.predicateType)
+}
+
+var (
+	predicatesMu  sync.RWMutex
+	predicatesMap = map[string]func() interface{}{}
+)
+
+// RegisterPredicateType associates predicateType with a function returning a pointer to a new,
+// empty predicate value, which Statement.DecodePredicate unmarshals into. It returns a
+// DuplicatePredicateTypeError if the predicate type is already registered.
+func RegisterPredicateType(predicateType string, newPredicate func() interface{}) error {
+	predicatesMu.Lock()
+	defer predicatesMu.Unlock()
+	if _, ok := predicatesMap[predicateType]; ok {
+		return &DuplicatePredicateTypeError{predicateType: predicateType}
+	}
+	predicatesMap[predicateType] = newPredicate
+	return nil
+}
@@ -0,0 +1,75 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payload_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/signature/dsse"
+	"github.com/sigstore/sigstore/pkg/signature/payload"
+)
+
+const subjectDigest = "d34db33fd34db33fd34db33fd34db33fd34db33fd34db33fd34db33fd34db33f"
+
+func TestSignStatement(t *testing.T) {
+	t.Parallel()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv, err := signature.LoadECDSASignerVerifier(priv, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := payload.NewStatement("https://example.com/predicate/v1", map[string]string{"key": "value"},
+		payload.ResourceDescriptor{Name: "artifact", Digest: map[string]string{"sha256": subjectDigest}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	env, err := dsse.WrapSigner(sv, payload.InTotoPayloadType).Sign(ctx, payload.InTotoPayloadType, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vp, err := dsse.WrapVerifier(sv, dsse.WithPayloadType(payload.InTotoPayloadType)).Verify(ctx, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := payload.ParseStatement(vp.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(parsed, s); diff != nil {
+		t.Error(diff)
+	}
+	if _, err := parsed.MatchSubject(map[string]string{"sha256": subjectDigest}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,130 @@
+//
+// Copyright 2025 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payload
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+const validSHA256 = "d34db33fd34db33fd34db33fd34db33fd34db33fd34db33fd34db33fd34db33f"
+
+func TestResourceDescriptorValidate(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		desc    string
+		rd      ResourceDescriptor
+		wantErr bool
+	}{
+		{desc: "sha256", rd: ResourceDescriptor{Digest: map[string]string{"sha256": validSHA256}}},
+		{desc: "uri only", rd: ResourceDescriptor{URI: "https://example.com/artifact"}},
+		{desc: "unknown algorithm", rd: ResourceDescriptor{Digest: map[string]string{"custom": "anything"}}},
+		{desc: "sha1 git commit", rd: ResourceDescriptor{Digest: map[string]string{"gitCommit": validSHA256[:40]}}},
+		{desc: "empty", rd: ResourceDescriptor{}, wantErr: true},
+		{desc: "uppercase hex", rd: ResourceDescriptor{Digest: map[string]string{"sha256": strings.ToUpper(validSHA256)}}, wantErr: true},
+		{desc: "not hex", rd: ResourceDescriptor{Digest: map[string]string{"sha256": strings.Repeat("z", 64)}}, wantErr: true},
+		{desc: "wrong length", rd: ResourceDescriptor{Digest: map[string]string{"sha512": validSHA256}}, wantErr: true},
+		{desc: "empty value", rd: ResourceDescriptor{Digest: map[string]string{"custom": ""}}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			if err := tc.rd.Validate(); (err != nil) != tc.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStatementValidate(t *testing.T) {
+	t.Parallel()
+	subject := ResourceDescriptor{Name: "artifact", Digest: map[string]string{"sha256": validSHA256}}
+	if _, err := NewStatement("https://example.com/predicate/v1", nil, subject); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewStatement("", nil, subject); err == nil {
+		t.Error("expected error for missing predicate type")
+	}
+	if _, err := NewStatement("https://example.com/predicate/v1", nil); err == nil {
+		t.Error("expected error for missing subjects")
+	}
+	if _, err := NewStatement("https://example.com/predicate/v1", nil, ResourceDescriptor{URI: "https://example.com"}); err == nil {
+		t.Error("expected error for subject without digest")
+	}
+	if _, err := ParseStatement([]byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"p","subject":[{"digest":{"sha256":"` + validSHA256 + `"}}]}`)); err == nil {
+		t.Error("expected error for unknown statement type")
+	}
+}
+
+func TestMatchSubject(t *testing.T) {
+	t.Parallel()
+	other := strings.Repeat("ab", 32)
+	s, err := NewStatement("https://example.com/predicate/v1", nil,
+		ResourceDescriptor{Name: "a", Digest: map[string]string{"sha256": validSHA256}},
+		ResourceDescriptor{Name: "b", Digest: map[string]string{"sha256": other, "sha1": strings.Repeat("cd", 20)}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if subject, err := s.MatchSubject(map[string]string{"sha256": strings.ToUpper(other)}); err != nil || subject.Name != "b" {
+		t.Errorf("expected subject b, got %v, %v", subject, err)
+	}
+	if _, err := s.MatchSubject(map[string]string{"sha256": other, "sha1": strings.Repeat("00", 20)}); err == nil {
+		t.Error("expected a conflicting digest not to match")
+	}
+	if _, err := s.MatchSubject(map[string]string{"sha512": strings.Repeat("00", 64)}); err == nil {
+		t.Error("expected a digest without a common algorithm not to match")
+	}
+}
+
+type testPredicate struct {
+	Builder string `json:"builder"`
+}
+
+func TestDecodePredicate(t *testing.T) {
+	t.Parallel()
+	predicateType := "https://example.com/test-predicate/v1"
+	if err := RegisterPredicateType(predicateType, func() interface{} { return &testPredicate{} }); err != nil {
+		t.Fatal(err)
+	}
+	var dupErr *DuplicatePredicateTypeError
+	if err := RegisterPredicateType(predicateType, func() interface{} { return &testPredicate{} }); !errors.As(err, &dupErr) {
+		t.Fatalf("expected DuplicatePredicateTypeError, got %v", err)
+	}
+
+	s, err := NewStatement(predicateType, testPredicate{Builder: "ci"}, ResourceDescriptor{Digest: map[string]string{"sha256": validSHA256}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := s.DecodePredicate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(p, &testPredicate{Builder: "ci"}); diff != nil {
+		t.Error(diff)
+	}
+
+	s.PredicateType = "https://example.com/unregistered/v1"
+	var unknownErr *UnknownPredicateTypeError
+	if _, err := s.DecodePredicate(); !errors.As(err, &unknownErr) {
+		t.Fatalf("expected UnknownPredicateTypeError, got %v", err)
+	}
+}
@@ -68,11 +68,19 @@ func LoadSigner(privateKey crypto.PrivateKey, hashFunc crypto.Hash) (Signer, err
 func LoadSignerWithOpts(privateKey crypto.PrivateKey, opts ...LoadOption) (Signer, error) {
 	var rsaPSSOptions *rsa.PSSOptions
 	var useED25519ph bool
+	var keyPolicy *cryptoutils.KeyPolicy
 	hashFunc := crypto.SHA256
 	for _, o := range opts {
 		o.ApplyED25519ph(&useED25519ph)
 		o.ApplyHash(&hashFunc)
 		o.ApplyRSAPSS(&rsaPSSOptions)
+		o.ApplyKeyPolicy(&keyPolicy)
+	}
+
+	if keyPolicy != nil {
+		if err := validatePrivateKeyWithPolicy(privateKey, keyPolicy); err != nil {
+			return nil, err
+		}
 	}
 
 	switch pk := privateKey.(type) {
@@ -123,3 +131,12 @@ func LoadSignerFromPEMFileWithOpts(path string, pf cryptoutils.PassFunc, opts ..
 	}
 	return LoadSignerWithOpts(priv, opts...)
 }
+
+// validatePrivateKeyWithPolicy checks the public key of privateKey against policy
+func validatePrivateKeyWithPolicy(privateKey crypto.PrivateKey, policy *cryptoutils.KeyPolicy) error {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return errors.New("unsupported public key type")
+	}
+	return cryptoutils.ValidatePubKeyWithPolicy(signer.Public(), policy)
+}
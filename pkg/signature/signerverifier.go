@@ -48,11 +48,19 @@ func LoadSignerVerifier(privateKey crypto.PrivateKey, hashFunc crypto.Hash) (Sig
 func LoadSignerVerifierWithOpts(privateKey crypto.PrivateKey, opts ...LoadOption) (SignerVerifier, error) {
 	var rsaPSSOptions *rsa.PSSOptions
 	var useED25519ph bool
+	var keyPolicy *cryptoutils.KeyPolicy
 	hashFunc := crypto.SHA256
 	for _, o := range opts {
 		o.ApplyED25519ph(&useED25519ph)
 		o.ApplyHash(&hashFunc)
 		o.ApplyRSAPSS(&rsaPSSOptions)
+		o.ApplyKeyPolicy(&keyPolicy)
+	}
+
+	if keyPolicy != nil {
+		if err := validatePrivateKeyWithPolicy(privateKey, keyPolicy); err != nil {
+			return nil, err
+		}
 	}
 
 	switch pk := privateKey.(type) {
@@ -49,11 +49,19 @@ func LoadVerifier(publicKey crypto.PublicKey, hashFunc crypto.Hash) (Verifier, e
 func LoadVerifierWithOpts(publicKey crypto.PublicKey, opts ...LoadOption) (Verifier, error) {
 	var rsaPSSOptions *rsa.PSSOptions
 	var useED25519ph bool
+	var keyPolicy *cryptoutils.KeyPolicy
 	hashFunc := crypto.SHA256
 	for _, o := range opts {
 		o.ApplyED25519ph(&useED25519ph)
 		o.ApplyHash(&hashFunc)
 		o.ApplyRSAPSS(&rsaPSSOptions)
+		o.ApplyKeyPolicy(&keyPolicy)
+	}
+
+	if keyPolicy != nil {
+		if err := cryptoutils.ValidatePubKeyWithPolicy(publicKey, keyPolicy); err != nil {
+			return nil, err
+		}
 	}
 
 	switch pk := publicKey.(type) {
@@ -18,7 +18,12 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
 	"testing"
+
+	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
 func TestLoadUnsafeVerifier(t *testing.T) {
@@ -50,3 +55,29 @@ func TestLoadVerifier(t *testing.T) {
 		t.Fatalf("public keys were not equal")
 	}
 }
+
+func TestLoadWithKeyPolicy(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	strict := cryptoutils.DefaultKeyPolicy()
+	strict.MinRSAKeySize = 3072
+
+	if _, err := LoadVerifierWithOpts(key.Public(), options.WithKeyPolicy(cryptoutils.DefaultKeyPolicy())); err != nil {
+		t.Errorf("unexpected error loading verifier: %v", err)
+	}
+	if _, err := LoadVerifierWithOpts(key.Public(), options.WithKeyPolicy(strict)); !errors.Is(err, goodkey.ErrBadKey) {
+		t.Errorf("expected verifier key to be rejected, got %v", err)
+	}
+	if _, err := LoadSignerWithOpts(key, options.WithKeyPolicy(strict)); !errors.Is(err, goodkey.ErrBadKey) {
+		t.Errorf("expected signer key to be rejected, got %v", err)
+	}
+	if _, err := LoadSignerVerifierWithOpts(key, options.WithHash(crypto.SHA256), options.WithKeyPolicy(strict)); !errors.Is(err, goodkey.ErrBadKey) {
+		t.Errorf("expected signer verifier key to be rejected, got %v", err)
+	}
+	// without the option, no policy is enforced
+	if _, err := LoadVerifierWithOpts(key.Public(), options.WithHash(crypto.SHA256)); err != nil {
+		t.Errorf("unexpected error loading verifier: %v", err)
+	}
+}
@@ -124,6 +124,12 @@ func GenerateLeafCert(subject, oidcIssuer string, parentTemplate *x509.Certifica
 		Critical: false,
 		Value:    []byte(oidcIssuer),
 	})
+	return GenerateLeafCertWithExtensions(subject, parentTemplate, parentPriv, exts...)
+}
+
+// GenerateLeafCertWithExtensions generates a test leaf certificate with an email SAN and the specified
+// extensions, such as those returned by cryptoutils.MarshalFulcioExtensions
+func GenerateLeafCertWithExtensions(subject string, parentTemplate *x509.Certificate, parentPriv crypto.Signer, exts ...pkix.Extension) (*x509.Certificate, *ecdsa.PrivateKey, error) {
 	certTemplate := &x509.Certificate{
 		SerialNumber:    big.NewInt(1),
 		EmailAddresses:  []string{subject},